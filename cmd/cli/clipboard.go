@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/x/term"
+)
+
+// clipboard is the system clipboard, a variable so that tests can swap in a
+// fake one
+var clipboard interface {
+	Copy(text string) error
+	Paste() (string, error)
+} = systemClipboard{}
+
+// systemClipboard copies with the clipboard tools of the platform, or over
+// SSH through the terminal with OSC 52, and reads with the tools
+type systemClipboard struct{}
+
+// clipboardCommand is a tool accessing the clipboard, tried in turn
+type clipboardCommand struct {
+	name string
+	args []string
+}
+
+// Tools reading and writing the clipboard, by platform. Wayland's come first
+// since X11 tools also run under XWayland with a separate clipboard.
+var (
+	pasteCommands = map[string][]clipboardCommand{
+		"darwin":  {{"pbpaste", nil}},
+		"windows": {{"powershell.exe", []string{"-NoProfile", "-Command", "Get-Clipboard -Raw"}}},
+		"":        {{"wl-paste", []string{"--no-newline"}}, {"xclip", []string{"-selection", "clipboard", "-out"}}, {"xsel", []string{"--clipboard", "--output"}}},
+	}
+	copyCommands = map[string][]clipboardCommand{
+		"darwin":  {{"pbcopy", nil}},
+		"windows": {{"clip.exe", nil}},
+		"":        {{"wl-copy", nil}, {"xclip", []string{"-selection", "clipboard", "-in"}}, {"xsel", []string{"--clipboard", "--input"}}},
+	}
+)
+
+// commandsFor returns the clipboard tools of the platform
+func commandsFor(commands map[string][]clipboardCommand) []clipboardCommand {
+	if c, ok := commands[runtime.GOOS]; ok {
+		return c
+	}
+	return commands[""]
+}
+
+// Copy sets the clipboard with the clipboard tools in a local session, since
+// not every terminal honours OSC 52. Over SSH, or without the tools, it
+// writes an OSC 52 sequence to the terminal instead, which sets the clipboard
+// of the machine the terminal runs on.
+func (systemClipboard) Copy(text string) error {
+	if !overSSH() {
+		if found, err := copyWithTool(text); found {
+			return err
+		}
+	}
+	if tty := terminal(); tty != nil {
+		defer tty.Close()
+		seq := osc52.New(text)
+		switch {
+		case os.Getenv("TMUX") != "":
+			seq = seq.Tmux()
+		case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+			seq = seq.Screen()
+		}
+		_, err := seq.WriteTo(tty)
+		return err
+	}
+	if found, err := copyWithTool(text); found {
+		return err
+	}
+	return errors.New("no terminal or clipboard tool to copy with")
+}
+
+// copyWithTool copies with the first clipboard tool installed, reporting
+// whether there was one
+func copyWithTool(text string) (bool, error) {
+	for _, c := range commandsFor(copyCommands) {
+		if _, err := exec.LookPath(c.name); err != nil {
+			continue
+		}
+		cmd := exec.Command(c.name, c.args...)
+		cmd.Stdin = strings.NewReader(text)
+		return true, cmd.Run()
+	}
+	return false, nil
+}
+
+// overSSH tells whether the CLI runs in an SSH session, where the clipboard
+// tools would set the clipboard of the remote machine
+func overSSH() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// Paste reads the clipboard with the first clipboard tool installed. The
+// terminal is not asked with OSC 52, since few terminals allow it.
+func (systemClipboard) Paste() (string, error) {
+	var names []string
+	for _, c := range commandsFor(pasteCommands) {
+		names = append(names, c.name)
+		if _, err := exec.LookPath(c.name); err != nil {
+			continue
+		}
+		var stderr bytes.Buffer
+		cmd := exec.Command(c.name, c.args...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w %s", c.name, err, strings.TrimSpace(stderr.String()))
+		}
+		return string(out), nil
+	}
+	return "", fmt.Errorf("reading the clipboard needs one of %s", strings.Join(names, ", "))
+}
+
+// terminal returns the terminal the CLI runs in, stderr if it is one, or nil
+func terminal() io.WriteCloser {
+	if term.IsTerminal(os.Stderr.Fd()) {
+		return nopWriteCloser{os.Stderr}
+	}
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		return tty
+	}
+	return nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// copyLink puts a link on the clipboard for --copy. Failing to only warns,
+// since the link is printed too.
+func (c *CLI) copyLink(link string) {
+	if !c.Copy || link == "" {
+		return
+	}
+	if err := clipboard.Copy(link); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not copy the link: %v\n", err)
+		return
+	}
+	if c.format() == outputPlain {
+		fmt.Fprintln(os.Stderr, "📎 Copied the link to the clipboard")
+	}
+}
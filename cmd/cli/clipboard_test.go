@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClipboard struct{ text string }
+
+func (c *fakeClipboard) Copy(text string) error { c.text = text; return nil }
+func (c *fakeClipboard) Paste() (string, error) { return c.text, nil }
+
+// useClipboard swaps the system clipboard for a fake one during the test
+func useClipboard(t *testing.T, text string) *fakeClipboard {
+	fake := &fakeClipboard{text: text}
+	system := clipboard
+	clipboard = fake
+	t.Cleanup(func() { clipboard = system })
+	return fake
+}
+
+func TestOverSSH(t *testing.T) {
+	t.Setenv("SSH_TTY", "")
+	t.Setenv("SSH_CONNECTION", "")
+	assert.False(t, overSSH(), "local sessions use the clipboard tools")
+	t.Setenv("SSH_CONNECTION", "10.0.0.2 52114 10.0.0.1 22")
+	assert.True(t, overSSH())
+}
+
+func TestPasteFromClipboard(t *testing.T) {
+	var created apiv1.CreatePasteRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		json.NewEncoder(w).Encode(apiv1.PasteCreated{ID: "abc", View: apiv1.ViewPath("abc"), Raw: apiv1.PasteRawPath("abc")})
+	}))
+	defer srv.Close()
+
+	fake := useClipboard(t, "copied text")
+	cli := &CLI{Server: srv.URL, Copy: true, Quiet: true}
+	captureStdout(t, func() {
+		require.NoError(t, (&PasteCmd{FromClipboard: true}).Run(cli))
+	})
+	assert.Equal(t, "copied text", created.Content)
+	assert.Equal(t, srv.URL+apiv1.ViewPath("abc"), fake.text, "--copy replaces the clipboard with the link")
+
+	useClipboard(t, " \n")
+	assert.ErrorContains(t, (&PasteCmd{FromClipboard: true}).Run(cli), "empty")
+	assert.Error(t, (&PasteCmd{FromClipboard: true, Files: []string{"notes.txt"}}).Run(cli))
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kong"
+)
+
+// CompletionCmd prints the completion script of a shell. The scripts call
+// quip __complete on each Tab, so that completion follows the history and
+// the server.
+type CompletionCmd struct {
+	Shell string `arg:"" enum:"bash,zsh,fish" help:"Shell to complete in: bash, zsh or fish"`
+}
+
+func (cmd *CompletionCmd) Run() error {
+	fmt.Print(completionScripts[cmd.Shell])
+	return nil
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for quip, load it with: source <(quip completion bash)
+_quip() {
+    local IFS=$'\n' candidate
+    COMPREPLY=()
+    for candidate in $("${COMP_WORDS[0]}" __complete -- "${COMP_WORDS[@]:1:COMP_CWORD-1}" "${COMP_WORDS[COMP_CWORD]}" 2>/dev/null); do
+        candidate="${candidate%%$'\t'*}"
+        COMPREPLY+=("${candidate// /\\ }")
+    done
+}
+complete -o default -F _quip quip
+`,
+	"zsh": `#compdef quip
+# zsh completion for quip, load it with: source <(quip completion zsh)
+_quip() {
+    local -a candidates
+    local line
+    for line in "${(@f)$("${words[1]}" __complete -- "${(@)words[2,CURRENT-1]}" "$PREFIX" 2>/dev/null)}"; do
+        [[ -n $line ]] || continue
+        if [[ $line == *$'\t'* ]]; then
+            candidates+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+        else
+            candidates+=("${line//:/\\:}")
+        fi
+    done
+    if (( ${#candidates} )); then
+        _describe quip candidates
+    else
+        _files
+    fi
+}
+compdef _quip quip
+`,
+	"fish": `# fish completion for quip, load it with: quip completion fish | source
+function __quip_complete
+    set -l tokens (commandline -opc)
+    set -l quip $tokens[1]
+    set -e tokens[1]
+    set -l candidates ($quip __complete -- $tokens (commandline -ct) 2>/dev/null)
+    if test (count $candidates) -gt 0
+        printf '%s\n' $candidates
+    else
+        __fish_complete_path (commandline -ct)
+    end
+end
+complete -c quip -f -a '(__quip_complete)'
+`,
+}
+
+// CompleteCmd completes a command line for the completion scripts. It
+// prints a candidate per line, followed by a tab and its description if any.
+// Printing none lets the shell complete file names.
+type CompleteCmd struct {
+	Words []string `arg:"" optional:"" help:"Words after quip, the last being the one completed"`
+}
+
+func (cmd *CompleteCmd) Run(cli *CLI, kctx *kong.Context) error {
+	for _, c := range cli.complete(kctx.Model.Node, cmd.Words) {
+		if c.help != "" {
+			fmt.Printf("%s\t%s\n", c.value, c.help)
+		} else {
+			fmt.Println(c.value)
+		}
+	}
+	return nil
+}
+
+type candidate struct {
+	value, help string
+}
+
+// complete returns the candidates for the last of words, the command line
+// after quip. Flags on the line that pick the server, profile or history
+// are applied first, so that candidates come from them.
+func (c *CLI) complete(root *kong.Node, words []string) []candidate {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	words, current := words[:len(words)-1], words[len(words)-1]
+
+	node := root
+	var pending *kong.Flag // flag waiting for its value
+	args := 0              // positional arguments of node so far
+	for _, word := range words {
+		switch {
+		case pending != nil:
+			c.setGlobal(pending, word)
+			pending = nil
+		case len(word) > 1 && strings.HasPrefix(word, "-"):
+			name, value, hasValue := strings.Cut(word, "=")
+			flag := findFlag(node, name)
+			switch {
+			case flag == nil || flag.IsBool() || flag.IsCounter():
+			case hasValue:
+				c.setGlobal(flag, value)
+			default:
+				pending = flag
+			}
+		default:
+			if args == 0 {
+				if child := findCommand(node, word); child != nil {
+					node = child
+					continue
+				}
+				if node.DefaultCmd != nil {
+					node = node.DefaultCmd
+				}
+			}
+			args++
+		}
+	}
+	if c.config == nil {
+		// Completion has nothing to report errors to, a broken configuration
+		// only leaves fewer candidates
+		_ = c.loadProfile(true)
+	}
+
+	var candidates []candidate
+	switch {
+	case pending != nil:
+		candidates = c.predict(pending.Value, current)
+	case strings.HasPrefix(current, "-"):
+		for n := node; n != nil; n = n.Parent {
+			for _, flag := range n.Flags {
+				if flag.Hidden {
+					continue
+				}
+				candidates = append(candidates, candidate{"--" + flag.Name, flag.Help})
+				if flag.Short != 0 {
+					candidates = append(candidates, candidate{"-" + string(flag.Short), flag.Help})
+				}
+			}
+		}
+	default:
+		if args == 0 {
+			for _, child := range node.Children {
+				if !child.Hidden {
+					candidates = append(candidates, candidate{child.Name, child.Help})
+				}
+			}
+			if node.DefaultCmd != nil {
+				node = node.DefaultCmd
+			}
+		}
+		if positional := node.Positional; len(positional) > 0 {
+			arg := positional[min(args, len(positional)-1)]
+			if args < len(positional) || arg.IsSlice() {
+				candidates = append(candidates, c.predict(arg, current)...)
+			}
+		}
+	}
+
+	matching := candidates[:0]
+	for _, cand := range candidates {
+		if strings.HasPrefix(cand.value, current) {
+			matching = append(matching, cand)
+		}
+	}
+	return matching
+}
+
+// findFlag returns the flag of node or its parents named as on the command
+// line, --name or -s
+func findFlag(node *kong.Node, name string) *kong.Flag {
+	for n := node; n != nil; n = n.Parent {
+		for _, flag := range n.Flags {
+			if name == "--"+flag.Name || (flag.Short != 0 && name == "-"+string(flag.Short)) ||
+				slices.ContainsFunc(flag.Aliases, func(alias string) bool { return name == "--"+alias }) {
+				return flag
+			}
+		}
+	}
+	return nil
+}
+
+func findCommand(node *kong.Node, name string) *kong.Node {
+	for _, child := range node.Children {
+		if child.Type == kong.CommandNode && (child.Name == name || slices.Contains(child.Aliases, name)) {
+			return child
+		}
+	}
+	return nil
+}
+
+// setGlobal applies the value of a flag choosing where candidates come from
+func (c *CLI) setGlobal(flag *kong.Flag, value string) {
+	switch flag.Name {
+	case "server":
+		c.Server = value
+	case "token":
+		c.Token = value
+	case "profile":
+		c.Profile = value
+	case "config":
+		c.Config = value
+	case "history-file":
+		c.HistoryFile = value
+	}
+}
+
+// predict returns the candidates for the value of a flag or argument: the
+// values of enums, and for those tagged with a predictor, IDs from the
+// history ("content" or "paste"), languages or profiles
+func (c *CLI) predict(v *kong.Value, current string) []candidate {
+	var candidates []candidate
+	if v.Enum != "" {
+		for _, value := range strings.Split(v.Enum, ",") {
+			if value != "" {
+				candidates = append(candidates, candidate{value: value})
+			}
+		}
+		return candidates
+	}
+
+	switch predictor := v.Tag.Get("predictor"); predictor {
+	case "content", "paste":
+		h, err := c.loadHistory()
+		if err != nil {
+			return nil
+		}
+		// Aliases only when asked for, they would double every entry
+		aliases := strings.HasPrefix(current, "~") || (current != "" && strings.HasPrefix("last", current))
+		for i := len(h.Entries) - 1; i >= 0; i-- {
+			e := h.Entries[i]
+			if predictor == "paste" && e.Kind != "paste" {
+				continue
+			}
+			help := strings.TrimSpace(e.Kind + " " + e.Name)
+			if aliases {
+				if i == len(h.Entries)-1 {
+					candidates = append(candidates, candidate{"last", help})
+				}
+				candidates = append(candidates, candidate{fmt.Sprintf("~%d", len(h.Entries)-i), help})
+			}
+			if e.Server == c.Server {
+				candidates = append(candidates, candidate{e.ID, help})
+			}
+		}
+	case "language":
+		for _, language := range c.languages() {
+			candidates = append(candidates, candidate{value: language})
+		}
+	case "profile":
+		if c.config != nil {
+			for name := range c.config.Profiles {
+				candidates = append(candidates, candidate{name, c.config.Profiles[name].Server})
+			}
+			slices.SortFunc(candidates, func(a, b candidate) int { return strings.Compare(a.value, b.value) })
+		}
+	}
+	return candidates
+}
+
+// languagesTTL is how long the languages of a server are cached, as
+// completion needs them on every Tab and they only change with upgrades
+const languagesTTL = 24 * time.Hour
+
+type cachedLanguages struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Languages []string  `json:"languages"`
+}
+
+// languages returns the languages of the server, from the cache if fresh.
+// Completion must stay quick and quiet, so failures leave none.
+func (c *CLI) languages() []string {
+	path := languagesCachePath()
+	cache := map[string]cachedLanguages{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+	if cached, ok := cache[c.Server]; ok && time.Since(cached.FetchedAt) < languagesTTL {
+		return cached.Languages
+	}
+
+	ctx, cancel := context.WithTimeout(c.context(), 3*time.Second)
+	defer cancel()
+	languages, err := c.client().Languages(ctx)
+	if err != nil {
+		return nil
+	}
+	if path != "" {
+		cache[c.Server] = cachedLanguages{FetchedAt: time.Now(), Languages: languages}
+		if data, err := json.Marshal(cache); err == nil && os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+			_ = os.WriteFile(path, data, 0o600)
+		}
+	}
+	return languages
+}
+
+// languagesCachePath returns ~/.cache/quip/languages.json, or where the
+// platform keeps caches
+func languagesCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "quip", "languages.json")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/alecthomas/kong"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplete(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, apiv1.LanguagesPath(), r.URL.Path)
+		_ = json.NewEncoder(w).Encode(apiv1.LanguageList{Languages: []string{"Go", "Go Module", "Python"}})
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history.jsonl")
+	writer := &CLI{Server: srv.URL, HistoryFile: path}
+	writer.remember(historyEntry{ID: "abc", Kind: "paste", Name: "notes"})
+	writer.remember(historyEntry{ID: "def", Kind: "file", Name: "report.pdf"})
+	(&CLI{Server: "https://other.example", HistoryFile: path}).remember(historyEntry{ID: "xyz", Kind: "file"})
+
+	complete := func(words ...string) []string {
+		var cli CLI
+		parser, err := kong.New(&cli, kong.Vars{"config_path": filepath.Join(dir, "config.toml"), "history_path": path})
+		require.NoError(t, err)
+		_, err = parser.Parse([]string{"__complete"})
+		require.NoError(t, err)
+		cli.Server = ""
+		var values []string
+		for _, c := range cli.complete(parser.Model.Node, append([]string{"--server", srv.URL}, words...)) {
+			values = append(values, c.value)
+		}
+		return values
+	}
+
+	assert.Equal(t, []string{"paste"}, complete("pa"))
+	assert.Contains(t, complete(""), "history")
+	assert.NotContains(t, complete(""), "__complete", "hidden commands are not offered")
+	assert.Equal(t, []string{"--output"}, complete("--out"))
+	assert.Equal(t, []string{"--force", "--format"}, complete("get", "x", "--fo"), "flags of the command")
+	assert.Equal(t, []string{"--history-file"}, complete("get", "x", "--hi"), "flags of its parents")
+	assert.Equal(t, []string{"json"}, complete("--output", "j"))
+	assert.Equal(t, []string{"json"}, complete("-q", "info", "--output=plain", "--output", "j"), "flags with values are skipped")
+	assert.Equal(t, []string{"zsh"}, complete("completion", "z"))
+
+	// IDs of the server in use, newest first, and aliases when asked for
+	assert.Equal(t, []string{"def", "abc"}, complete("get", ""))
+	assert.Equal(t, []string{"~1", "~2", "~3"}, complete("rm", "~"))
+	assert.Equal(t, []string{"last"}, complete("info", "la"))
+	assert.Equal(t, []string{"abc"}, complete("edit", ""), "edit only takes pastes")
+	assert.Equal(t, []string{"~3"}, complete("cat", "~"))
+	assert.Empty(t, complete("get", "abc", ""), "get takes a single target")
+	assert.Empty(t, complete("paste", "main.go", ""), "files are left to the shell")
+
+	// Languages of the server, cached
+	assert.Equal(t, []string{"Go", "Go Module"}, complete("paste", "-l", "G"))
+	assert.Equal(t, []string{"Python"}, complete("paste", "--language", "P"))
+	assert.Equal(t, 1, requests)
+}
+
+func TestMan(t *testing.T) {
+	var cli CLI
+	parser, err := kong.New(&cli, kong.Name("quip"), kong.Description("Simple file sharing and pastebin"), kong.Vars{"config_path": "", "history_path": ""})
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	require.NoError(t, writeMan(&b, parser.Model))
+	page := b.String()
+	assert.Contains(t, page, ".TH QUIP 1")
+	assert.Contains(t, page, `.SS "quip paste [<file> ...] [flags]"`)
+	assert.Contains(t, page, `\fB\-l\fR, \fB\-\-language\fR=\fILANGUAGE\fR`)
+	assert.Contains(t, page, ".B QUIP_TOKEN")
+	assert.NotContains(t, page, "__complete")
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// defaultServer is used when neither a flag nor a profile names a server
+const defaultServer = "http://localhost:8080"
+
+// profile is a named set of defaults in the configuration file
+type profile struct {
+	Server   string `toml:"server,omitempty"`
+	Token    string `toml:"token,omitempty"`
+	TTL      string `toml:"ttl,omitempty"`
+	Language string `toml:"language,omitempty"`
+	// Encrypt is "always" to share only encrypted content, or "never"
+	Encrypt string `toml:"encrypt,omitempty"`
+}
+
+// Values of the encrypt setting
+const (
+	encryptAlways = "always"
+	encryptNever  = "never"
+)
+
+// checkEncryption refuses to send content when the profile asks for
+// encryption, which the CLI cannot do yet, rather than send it in the clear.
+// Every command sending content to the server calls it.
+func (p *profile) checkEncryption() error {
+	if p.Encrypt == encryptAlways {
+		return fmt.Errorf("the profile asks for encryption, which quip does not support yet; run quip config unset encrypt to share unencrypted")
+	}
+	return nil
+}
+
+// cliConfig is the configuration file of the CLI:
+//
+//	default_profile = "work"
+//
+//	[profiles.work]
+//	server = "https://quip.example"
+//	token = "..."
+//	ttl = "7d"
+//	encrypt = "never"
+type cliConfig struct {
+	DefaultProfile string              `toml:"default_profile,omitempty"`
+	Profiles       map[string]*profile `toml:"profiles,omitempty"`
+}
+
+// defaultConfigPath returns ~/.config/quip/config.toml, or where the
+// platform keeps configuration
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "quip", "config.toml")
+}
+
+// loadConfig reads the configuration file at path, which may not exist yet
+func loadConfig(path string) (*cliConfig, error) {
+	config := &cliConfig{Profiles: map[string]*profile{}}
+	if path == "" {
+		return config, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := toml.Decode(string(data), config); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = map[string]*profile{}
+	}
+	return config, nil
+}
+
+// save writes the configuration to path, readable by the user only since it
+// holds API keys. It replaces the file at once, so that an interrupted save
+// does not lose it.
+func (c *cliConfig) save(path string) error {
+	if path == "" {
+		return fmt.Errorf("no configuration file, set --config or QUIP_CONFIG")
+	}
+	var b bytes.Buffer
+	if err := toml.NewEncoder(&b).Encode(c); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".config-*.toml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(b.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// profileName returns the profile to use: the requested one, or the default
+// one if any
+func (c *cliConfig) profileName(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if c.DefaultProfile != "" {
+		return c.DefaultProfile
+	}
+	if _, ok := c.Profiles["default"]; ok {
+		return "default"
+	}
+	return ""
+}
+
+// names returns the profile names, sorted
+func (c *cliConfig) names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadProfile reads the configuration file and fills in the server and
+// token from the selected profile, unless given by flags or the
+// environment. It is called once the command line is parsed, with creating
+// set for the commands that may create the profile.
+func (c *CLI) loadProfile(creating bool) error {
+	config, err := loadConfig(c.Config)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(c.Config); err == nil && runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  %s is readable by other users, run chmod 600 on it\n", c.Config)
+	}
+
+	c.config = config
+	c.given = given{server: c.Server, token: c.Token}
+	c.profile = &profile{}
+	if name := config.profileName(c.Profile); name != "" {
+		p, ok := config.Profiles[name]
+		if !ok && c.Profile != "" && !creating {
+			return fmt.Errorf("no profile %q in %s", name, c.Config)
+		}
+		if ok {
+			c.profile = p
+		}
+	}
+	if c.Server == "" {
+		c.Server = orDefault(c.profile.Server, defaultServer)
+	}
+	c.Server = strings.TrimRight(c.Server, "/")
+	if c.Token == "" {
+		c.Token = c.profile.Token
+	}
+	return nil
+}
+
+// given holds the server and token given on the command line or in the
+// environment, before profiles fill them in
+type given struct {
+	server, token string
+}
+
+// useServer points the CLI at server, such as the one of a link. The API key
+// is only kept if it is the one of that server, so that it is never sent to
+// another.
+func (c *CLI) useServer(server string) {
+	if server == c.Server {
+		return
+	}
+	c.Server, c.Token = server, ""
+	if c.config == nil {
+		return
+	}
+	for _, name := range c.config.names() {
+		if p := c.config.Profiles[name]; strings.TrimRight(p.Server, "/") == server {
+			c.Token = p.Token
+			return
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Gandalf-Le-Dev/quip/pkg/client"
+	"github.com/charmbracelet/x/term"
+)
+
+type LoginCmd struct {
+	URL string `arg:"" optional:"" name:"server" help:"Server URL (defaults to --server, or the profile's)"`
+}
+
+// Run checks an API key against the server and saves both in the profile,
+// asking for the key unless --token or QUIP_TOKEN gives it
+func (c *LoginCmd) Run(cli *CLI) error {
+	server := strings.TrimRight(orDefault(c.URL, orDefault(cli.given.server, cli.defaults().Server)), "/")
+	if server == "" {
+		return fmt.Errorf("give the URL of the server to log in to")
+	}
+	token := cli.given.token
+	if token == "" {
+		var err error
+		if token, err = readSecret(fmt.Sprintf("API key for %s: ", server)); err != nil {
+			return err
+		}
+	}
+	if token == "" {
+		return fmt.Errorf("no API key given")
+	}
+
+	user, err := client.New(server, client.WithToken(token), client.WithUserAgent("quip-cli")).Me(cli.context())
+	if err != nil {
+		return fmt.Errorf("checking the API key: %w", err)
+	}
+
+	name := orDefault(cli.config.profileName(cli.Profile), "default")
+	p := cli.config.Profiles[name]
+	if p == nil {
+		p = &profile{}
+		cli.config.Profiles[name] = p
+	}
+	p.Server, p.Token = server, token
+	if cli.config.DefaultProfile == "" {
+		cli.config.DefaultProfile = name
+	}
+	if err := cli.config.save(cli.Config); err != nil {
+		return err
+	}
+	fmt.Printf("🔑 Logged in to %s as %s, saved as profile %q in %s\n", server, user.Name, name, cli.Config)
+	return nil
+}
+
+// readSecret asks for a secret on the terminal without echoing it, or reads
+// a line of stdin when it is not a terminal
+func readSecret(prompt string) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("reading the API key from stdin: %w", err)
+		}
+		return strings.TrimSpace(line), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	return strings.TrimSpace(string(secret)), err
+}
+
+type ConfigCmd struct {
+	List  ConfigListCmd  `cmd:"" default:"1" help:"List the profiles (default)"`
+	Path  ConfigPathCmd  `cmd:"" help:"Print the path of the configuration file"`
+	Set   ConfigSetCmd   `cmd:"" help:"Set a setting of the profile"`
+	Unset ConfigUnsetCmd `cmd:"" help:"Remove a setting of the profile"`
+	Use   ConfigUseCmd   `cmd:"" help:"Make a profile the default one"`
+	Rm    ConfigRmCmd    `cmd:"" help:"Delete a profile"`
+}
+
+// profileKeys are the settings of a profile, by name
+var profileKeys = map[string]func(p *profile) *string{
+	"server":   func(p *profile) *string { return &p.Server },
+	"token":    func(p *profile) *string { return &p.Token },
+	"ttl":      func(p *profile) *string { return &p.TTL },
+	"language": func(p *profile) *string { return &p.Language },
+	"encrypt":  func(p *profile) *string { return &p.Encrypt },
+}
+
+// setting returns the setting named key of the profile being configured,
+// creating the profile if needed
+func setting(cli *CLI, key string) (*string, string, error) {
+	field, ok := profileKeys[key]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown setting %q, settings are server, token, ttl, language and encrypt", key)
+	}
+	name := orDefault(cli.config.profileName(cli.Profile), "default")
+	p := cli.config.Profiles[name]
+	if p == nil {
+		p = &profile{}
+		cli.config.Profiles[name] = p
+	}
+	return field(p), name, nil
+}
+
+type ConfigListCmd struct{}
+
+func (c *ConfigListCmd) Run(cli *CLI) error {
+	if len(cli.config.Profiles) == 0 {
+		fmt.Fprintf(os.Stderr, "No profiles yet, create one with quip login or quip config set\n")
+		return nil
+	}
+	current := cli.config.profileName(cli.Profile)
+	for _, name := range cli.config.names() {
+		p := cli.config.Profiles[name]
+		marker := " "
+		if name == current {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, name)
+		fmt.Printf("    server:   %s\n", orDefault(p.Server, defaultServer))
+		token := "not set"
+		if p.Token != "" {
+			token = "set"
+		}
+		fmt.Printf("    token:    %s\n", token)
+		if p.TTL != "" {
+			fmt.Printf("    ttl:      %s\n", p.TTL)
+		}
+		if p.Language != "" {
+			fmt.Printf("    language: %s\n", p.Language)
+		}
+		if p.Encrypt != "" {
+			fmt.Printf("    encrypt:  %s\n", p.Encrypt)
+		}
+	}
+	return nil
+}
+
+type ConfigPathCmd struct{}
+
+func (c *ConfigPathCmd) Run(cli *CLI) error {
+	fmt.Println(cli.Config)
+	return nil
+}
+
+type ConfigSetCmd struct {
+	Key   string `arg:"" help:"Setting: server, token, ttl, language or encrypt"`
+	Value string `arg:"" help:"Value of the setting"`
+}
+
+func (c *ConfigSetCmd) Run(cli *CLI) error {
+	field, name, err := setting(cli, c.Key)
+	if err != nil {
+		return err
+	}
+	switch c.Key {
+	case "server":
+		c.Value = strings.TrimRight(c.Value, "/")
+	case "encrypt":
+		if c.Value != encryptAlways && c.Value != encryptNever {
+			return fmt.Errorf("encrypt must be %s or %s, got %q", encryptAlways, encryptNever, c.Value)
+		}
+		if c.Value == encryptAlways {
+			fmt.Fprintln(os.Stderr, "⚠️  quip does not support encryption yet, so this profile will refuse to share anything")
+		}
+	}
+	*field = c.Value
+	if cli.config.DefaultProfile == "" {
+		cli.config.DefaultProfile = name
+	}
+	if err := cli.config.save(cli.Config); err != nil {
+		return err
+	}
+	fmt.Printf("⚙️  Set %s of profile %q\n", c.Key, name)
+	return nil
+}
+
+type ConfigUnsetCmd struct {
+	Key string `arg:"" help:"Setting: server, token, ttl, language or encrypt"`
+}
+
+func (c *ConfigUnsetCmd) Run(cli *CLI) error {
+	field, name, err := setting(cli, c.Key)
+	if err != nil {
+		return err
+	}
+	*field = ""
+	if err := cli.config.save(cli.Config); err != nil {
+		return err
+	}
+	fmt.Printf("⚙️  Unset %s of profile %q\n", c.Key, name)
+	return nil
+}
+
+type ConfigUseCmd struct {
+	Name string `arg:"" predictor:"profile" help:"Profile to use by default"`
+}
+
+func (c *ConfigUseCmd) Run(cli *CLI) error {
+	if _, ok := cli.config.Profiles[c.Name]; !ok {
+		return fmt.Errorf("no profile %q in %s", c.Name, cli.Config)
+	}
+	cli.config.DefaultProfile = c.Name
+	if err := cli.config.save(cli.Config); err != nil {
+		return err
+	}
+	fmt.Printf("⚙️  Using profile %q by default\n", c.Name)
+	return nil
+}
+
+type ConfigRmCmd struct {
+	Name string `arg:"" predictor:"profile" help:"Profile to delete"`
+}
+
+func (c *ConfigRmCmd) Run(cli *CLI) error {
+	if _, ok := cli.config.Profiles[c.Name]; !ok {
+		return fmt.Errorf("no profile %q in %s", c.Name, cli.Config)
+	}
+	delete(cli.config.Profiles, c.Name)
+	if cli.config.DefaultProfile == c.Name {
+		cli.config.DefaultProfile = ""
+	}
+	if err := cli.config.save(cli.Config); err != nil {
+		return err
+	}
+	fmt.Printf("🗑️  Deleted profile %q\n", c.Name)
+	return nil
+}
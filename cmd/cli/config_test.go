@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quip", "config.toml")
+	config, err := loadConfig(path)
+	require.NoError(t, err, "a missing file is an empty configuration")
+	assert.Empty(t, config.Profiles)
+
+	config.DefaultProfile = "work"
+	config.Profiles["work"] = &profile{Server: "https://quip.example", Token: "secret", TTL: "7d", Encrypt: encryptNever}
+	require.NoError(t, config.save(path))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+	loaded, err := loadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, config, loaded)
+}
+
+func TestCheckEncryption(t *testing.T) {
+	assert.NoError(t, (&profile{}).checkEncryption())
+	assert.NoError(t, (&profile{Encrypt: encryptNever}).checkEncryption())
+	assert.Error(t, (&profile{Encrypt: encryptAlways}).checkEncryption(), "content is never shared in the clear against the profile's wish")
+
+	// Commands sending content refuse before reaching the server
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { requests++ }))
+	defer srv.Close()
+	cli := &CLI{Server: srv.URL, profile: &profile{Encrypt: encryptAlways}}
+	local := filepath.Join(t.TempDir(), "local.txt")
+	require.NoError(t, os.WriteFile(local, []byte("secret\n"), 0o600))
+	for name, cmd := range map[string]interface{ Run(*CLI) error }{
+		"up":    &UpCmd{Files: []string{local}},
+		"paste": &PasteCmd{Files: []string{local}},
+		"edit":  &EditCmd{Target: "abc"},
+		"diff":  &DiffCmd{Paste: "abc", File: local},
+	} {
+		assert.ErrorContains(t, cmd.Run(cli), "encryption", name)
+	}
+	assert.Zero(t, requests)
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+default_profile = "work"
+
+[profiles.work]
+server = "https://work.example/"
+token = "work-key"
+ttl = "7d"
+
+[profiles.home]
+server = "https://home.example"
+token = "home-key"
+`), 0o600))
+
+	cli := &CLI{Config: path}
+	require.NoError(t, cli.loadProfile(false))
+	assert.Equal(t, "https://work.example", cli.Server)
+	assert.Equal(t, "work-key", cli.Token)
+	assert.Equal(t, "7d", cli.defaults().TTL)
+
+	cli = &CLI{Config: path, Profile: "home", Token: "flag-key"}
+	require.NoError(t, cli.loadProfile(false))
+	assert.Equal(t, "https://home.example", cli.Server)
+	assert.Equal(t, "flag-key", cli.Token, "flags win over profiles")
+
+	assert.Error(t, (&CLI{Config: path, Profile: "nope"}).loadProfile(false))
+	assert.NoError(t, (&CLI{Config: path, Profile: "nope"}).loadProfile(true), "login creates it")
+
+	// Links only get the API key of their own server
+	cli = &CLI{Config: path}
+	require.NoError(t, cli.loadProfile(false))
+	_, err := cli.resolve("https://home.example/api/v1/view/abc")
+	require.NoError(t, err)
+	assert.Equal(t, "home-key", cli.Token)
+	_, err = cli.resolve("https://elsewhere.example/api/v1/view/abc")
+	require.NoError(t, err)
+	assert.Empty(t, cli.Token)
+}
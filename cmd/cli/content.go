@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/Gandalf-Le-Dev/quip/pkg/client"
+)
+
+// linkKinds are the path segments of links followed by an ID
+var linkKinds = map[string]bool{"file": true, "paste": true, "bundle": true, "view": true, "content": true}
+
+// resolve returns the ID named by target, which is either an ID, a link to
+// the content such as the ones printed on upload, or a history alias such as
+// last or ~2. Links and aliases point the CLI at the server they belong to,
+// see useServer.
+func (c *CLI) resolve(target string) (string, error) {
+	if target == "last" || strings.HasPrefix(target, "~") {
+		h, err := c.loadHistory()
+		if err != nil {
+			return "", err
+		}
+		e, err := h.alias(target)
+		if err != nil {
+			return "", err
+		}
+		c.useServer(e.Server)
+		return e.ID, nil
+	}
+	if !strings.Contains(target, "/") {
+		if target == "" {
+			return "", fmt.Errorf("missing ID")
+		}
+		return target, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%q is neither an ID nor a link", target)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if !linkKinds[segments[i]] || segments[i+1] == "" {
+			continue
+		}
+		// The server may be served under a path, in front of /api
+		base := i
+		for j := i - 1; j >= 0; j-- {
+			if segments[j] == "api" {
+				base = j
+				break
+			}
+		}
+		server := u.Scheme + "://" + u.Host
+		if base > 0 {
+			server += "/" + strings.Join(segments[:base], "/")
+		}
+		c.useServer(server)
+		return segments[i+1], nil
+	}
+	return "", fmt.Errorf("%s is not a link to a file, paste or bundle", target)
+}
+
+type InfoCmd struct {
+	Target string `arg:"" predictor:"content" help:"ID, link or history alias of the file, paste or bundle, looking up a paste counts as a view"`
+}
+
+func (c *InfoCmd) Run(cli *CLI) error {
+	id, err := cli.resolve(c.Target)
+	if err != nil {
+		return err
+	}
+	content, err := cli.client().Content(cli.context(), id)
+	if err != nil {
+		return err
+	}
+
+	if content.File == nil && content.Bundle == nil && content.Paste == nil {
+		return fmt.Errorf("the server answered with unknown content %q", content.Kind)
+	}
+	link := cli.Server + apiv1.ViewPath(id)
+	return cli.print(link, content, func() {
+		switch {
+		case content.File != nil:
+			f := content.File
+			fmt.Printf("📄 %s\n", f.Filename)
+			fmt.Printf("   Size:      %s\n", formatSize(f.Size))
+			fmt.Printf("   Type:      %s\n", f.ContentType)
+			fmt.Printf("   Downloads: %s\n", formatCount(f.Downloads, f.MaxDownloads))
+			if f.BundleID != "" {
+				fmt.Printf("   Bundle:    %s\n", f.BundleID)
+			}
+			printLifetime(f.CreatedAt, f.ExpiresAt)
+		case content.Bundle != nil:
+			b := content.Bundle
+			fmt.Printf("📦 %s\n", orDefault(b.Title, "Bundle "+b.ID))
+			fmt.Printf("   Size:      %s in %d files\n", formatSize(b.Size), len(b.Files))
+			fmt.Printf("   Downloads: %s\n", formatCount(b.Downloads, b.MaxDownloads))
+			printLifetime(b.CreatedAt, b.ExpiresAt)
+			for _, f := range b.Files {
+				fmt.Printf("   - %s (%s)\n", f.Filename, formatSize(f.Size))
+			}
+		case content.Paste != nil:
+			p := content.Paste
+			fmt.Printf("📋 %s\n", orDefault(p.Title, "Paste "+p.ID))
+			fmt.Printf("   Language:  %s\n", p.Language)
+			fmt.Printf("   Size:      %s\n", formatSize(int64(len(p.Content))))
+			fmt.Printf("   Views:     %s\n", formatCount(p.Views, p.MaxViews))
+			fmt.Printf("   Revision:  %d\n", p.Revision)
+			if p.ParentID != "" {
+				fmt.Printf("   Fork of:   %s\n", p.ParentID)
+			}
+			printLifetime(p.CreatedAt, p.ExpiresAt)
+			for _, f := range p.Files {
+				fmt.Printf("   - %s (%s, %s)\n", f.Name, f.Language, formatSize(f.Size))
+			}
+		}
+		fmt.Printf("   View:      %s\n", link)
+	})
+}
+
+func printLifetime(created time.Time, expires *time.Time) {
+	fmt.Printf("   Created:   %s\n", created.Local().Format(time.DateTime))
+	if expires == nil {
+		fmt.Printf("   Expires:   never\n")
+		return
+	}
+	fmt.Printf("   Expires:   %s (in %s)\n", expires.Local().Format(time.DateTime), time.Until(*expires).Round(time.Minute))
+}
+
+// formatCount formats a count against its limit, which is negative when
+// there is none
+func formatCount(n, limit int) string {
+	if limit < 0 {
+		return fmt.Sprintf("%d", n)
+	}
+	return fmt.Sprintf("%d of %d", n, limit)
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+type RmCmd struct {
+	Target      string `arg:"" predictor:"content" help:"ID, link or history alias of the file, paste or bundle"`
+	ManageToken string `env:"QUIP_MANAGE_TOKEN" help:"Manage token of the content, unless it was created with your API key or is in the history"`
+}
+
+// Run deletes the content. Deleting does not tell what an ID names, so unless
+// the history does, each kind is tried in turn until one exists.
+func (c *RmCmd) Run(cli *CLI) error {
+	id, err := cli.resolve(c.Target)
+	if err != nil {
+		return err
+	}
+	api := cli.client()
+	deletes := map[string]func(ctx context.Context, id, manageToken string) error{
+		"file":   api.DeleteFile,
+		"bundle": api.DeleteBundle,
+		"paste":  api.DeletePaste,
+	}
+	kinds := []string{"file", "bundle", "paste"}
+	token := c.ManageToken
+	if h, err := cli.loadHistory(); err == nil {
+		if e := h.find(cli.Server, id); e != nil && deletes[e.Kind] != nil {
+			kinds = []string{e.Kind}
+			if token == "" {
+				token = e.ManageToken
+			}
+		}
+	}
+	var deleted string
+	for _, kind := range kinds {
+		err = deletes[kind](cli.context(), id, token)
+		if !errors.Is(err, client.ErrNotFound) {
+			deleted = kind
+			break
+		}
+	}
+	if errors.Is(err, client.ErrNotFound) && len(kinds) == 1 {
+		// Already gone, such as deleted from the web page
+		cli.forget(id)
+	}
+	if err != nil {
+		return err
+	}
+	cli.forget(id)
+	result := struct {
+		ID   string `json:"id"`
+		Kind string `json:"kind"`
+	}{id, deleted}
+	return cli.print("", result, func() {
+		fmt.Printf("🗑️  Deleted %s\n", id)
+	})
+}
+
+type CatCmd struct {
+	Target   string `arg:"" predictor:"paste" help:"ID, link or history alias of the paste"`
+	File     string `short:"f" help:"File of a multi-file paste to print, rather than the first"`
+	Revision int    `short:"r" help:"Revision to print, rather than the latest"`
+}
+
+func (c *CatCmd) Run(cli *CLI) error {
+	id, err := cli.resolve(c.Target)
+	if err != nil {
+		return err
+	}
+	api := cli.client()
+	var raw *client.Download
+	switch {
+	case c.File != "" && c.Revision != 0:
+		return fmt.Errorf("--file and --revision cannot be combined, revisions only hold the first file")
+	case c.File != "":
+		raw, err = api.RawPasteFile(cli.context(), id, c.File)
+	case c.Revision != 0:
+		raw, err = api.RawRevision(cli.context(), id, c.Revision, "")
+	default:
+		raw, err = api.RawPaste(cli.context(), id)
+	}
+	if err != nil {
+		return err
+	}
+	defer raw.Close()
+	_, err = io.Copy(os.Stdout, raw)
+	return err
+}
+
+type OpenCmd struct {
+	Target string `arg:"" predictor:"content" help:"ID, link or history alias of the file, paste or bundle"`
+}
+
+func (c *OpenCmd) Run(cli *CLI) error {
+	id, err := cli.resolve(c.Target)
+	if err != nil {
+		return err
+	}
+	link := cli.Server + apiv1.ViewPath(id)
+	if err := openBrowser(link); err != nil {
+		return fmt.Errorf("opening %s: %w", link, err)
+	}
+	cli.printf("👀 Opened %s\n", link)
+	return nil
+}
+
+// openBrowser opens link in the user's browser without waiting for it
+func openBrowser(link string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
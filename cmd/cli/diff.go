@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/muesli/termenv"
+)
+
+type DiffCmd struct {
+	Paste string `arg:"" predictor:"paste" help:"ID, link or history alias of the paste to compare with"`
+	File  string `arg:"" help:"Local file to compare, - for stdin"`
+}
+
+func (c *DiffCmd) Run(cli *CLI) error {
+	// The local file is sent to the server to be compared
+	if err := cli.defaults().checkEncryption(); err != nil {
+		return err
+	}
+	var text io.Reader = os.Stdin
+	name := "stdin"
+	if c.File != "-" {
+		f, err := os.Open(c.File)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		text, name = f, filepath.Base(c.File)
+	}
+
+	id, err := cli.resolve(c.Paste)
+	if err != nil {
+		return err
+	}
+	diff, err := cli.client().DiffText(cli.context(), id, name, text)
+	if err != nil {
+		return err
+	}
+
+	if len(diff.Hunks) == 0 {
+		fmt.Fprintln(os.Stderr, "No differences")
+		return nil
+	}
+	printDiff(termenv.NewOutput(os.Stdout), unified(diff))
+	return nil
+}
+
+// unified formats a diff as the server's text format does
+func unified(d *apiv1.Diff) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", d.From, d.To)
+	for _, h := range d.Hunks {
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", formatRange(h.FromLine, h.FromCount), formatRange(h.ToLine, h.ToCount))
+		for _, line := range h.Lines {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+func formatRange(line, count int) string {
+	if count == 1 {
+		return strconv.Itoa(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// printDiff prints a unified diff, colored when the output supports it
+func printDiff(out *termenv.Output, diff string) {
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		style := out.String(line)
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			style = style.Bold()
+		case strings.HasPrefix(line, "@@"):
+			style = style.Foreground(termenv.ANSICyan)
+		case strings.HasPrefix(line, "+"):
+			style = style.Foreground(termenv.ANSIGreen)
+		case strings.HasPrefix(line, "-"):
+			style = style.Foreground(termenv.ANSIRed)
+		}
+		fmt.Fprintln(out, style)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnified(t *testing.T) {
+	diff := &apiv1.Diff{From: "abc", To: "local.txt", Hunks: []apiv1.DiffHunk{
+		{FromLine: 1, FromCount: 2, ToLine: 1, ToCount: 1, Lines: []string{" a", "-b"}},
+	}}
+	assert.Equal(t, "--- abc\n+++ local.txt\n@@ -1,2 +1 @@\n a\n-b\n", unified(diff))
+}
+
+func TestDiffResolvesAlias(t *testing.T) {
+	var compared string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		compared = r.URL.Query().Get("a")
+		json.NewEncoder(w).Encode(apiv1.Diff{From: compared, To: "local.txt"})
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	h := &history{path: filepath.Join(dir, "history.jsonl"), Entries: []historyEntry{{ID: "abc", Kind: "paste", Server: srv.URL}}}
+	require.NoError(t, h.save())
+	local := filepath.Join(dir, "local.txt")
+	require.NoError(t, os.WriteFile(local, []byte("text\n"), 0o600))
+
+	cli := &CLI{Server: "http://default.invalid", HistoryFile: h.path}
+	require.NoError(t, (&DiffCmd{Paste: "last", File: local}).Run(cli))
+	assert.Equal(t, "abc", compared)
+}
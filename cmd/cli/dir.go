@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/Gandalf-Le-Dev/quip/pkg/client"
+)
+
+// Ways of sharing a directory
+const (
+	dirAsTarGz  = "tar.gz"
+	dirAsZip    = "zip"
+	dirAsBundle = "bundle"
+)
+
+// maxBundleFiles is the most files the server accepts in a bundle
+const maxBundleFiles = 100
+
+// maxListedFiles bounds the files named in the summary of a shared directory
+const maxListedFiles = 20
+
+// uploadDir shares a directory as an archive streamed while it is built, or
+// as a bundle of its files
+func (c *UpCmd) uploadDir(cli *CLI, dir string) error {
+	t, err := walkTree(dir, walkOptions{
+		FollowSymlinks: c.FollowSymlinks,
+		NoIgnore:       c.NoIgnore,
+		Exclude:        c.Exclude,
+	})
+	if err != nil {
+		return err
+	}
+	if len(t.Files()) == 0 {
+		return fmt.Errorf("nothing to share in %s", dir)
+	}
+
+	if c.As == dirAsBundle {
+		err = c.uploadTreeBundle(cli, t)
+	} else {
+		err = c.uploadTreeArchive(cli, t)
+	}
+	if err != nil {
+		return err
+	}
+	printTreeSummary(cli, t, c.As == dirAsBundle)
+	return nil
+}
+
+// uploadTreeArchive streams the tree into an archive uploaded as one file.
+// The archive is never held in memory or written to disk.
+func (c *UpCmd) uploadTreeArchive(cli *CLI, t *tree) error {
+	name, contentType, write := t.Entries[0].Name+".tar.gz", "application/gzip", writeTarGz
+	if c.As == dirAsZip {
+		name, contentType, write = t.Entries[0].Name+".zip", "application/zip", writeZip
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(write(pw, t))
+	}()
+
+	bar := cli.newProgress("⬆️  Uploading "+name, 0)
+	result, err := cli.client().UploadFile(cli.context(), client.Upload{Name: name, Body: pr, Size: -1, ContentType: contentType}, client.FileOptions{
+		TTL:      c.TTL,
+		Progress: bar.report,
+	})
+	bar.finish()
+	// Unblock the archive if the upload ended before reading it all
+	pr.Close()
+	<-done
+	if err != nil {
+		return err
+	}
+	return printFileUploaded(cli, *result)
+}
+
+// uploadTreeBundle uploads the files of the tree as one bundle. Bundles hold
+// files only, so directories are flattened and symlinks left out unless
+// followed.
+func (c *UpCmd) uploadTreeBundle(cli *CLI, t *tree) error {
+	for _, e := range t.Entries {
+		if e.Link != "" {
+			t.Skipped = append(t.Skipped, skippedEntry{e.Name, "symlink, bundles only hold files (see --follow-symlinks)"})
+		}
+	}
+
+	files := t.Files()
+	if len(files) > maxBundleFiles {
+		return fmt.Errorf("bundles hold up to %d files and %s has %d, share it as an archive", maxBundleFiles, t.Entries[0].Name, len(files))
+	}
+	var uploads []client.Upload
+	for _, e := range files {
+		file, err := os.Open(e.Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		uploads = append(uploads, client.Upload{Name: path.Base(e.Name), Body: file, Size: e.Size})
+	}
+
+	bar := cli.newProgress("⬆️  Uploading "+t.Entries[0].Name, t.Size())
+	result, err := cli.client().UploadBundle(cli.context(), uploads, client.BundleOptions{
+		TTL:          c.TTL,
+		Title:        c.bundleTitle(t),
+		MaxDownloads: c.MaxDownloads,
+		Progress:     bar.report,
+	})
+	bar.finish()
+	if err != nil {
+		return err
+	}
+	return printBundle(cli, *result)
+}
+
+// bundleTitle defaults the title of a directory's bundle to its name
+func (c *UpCmd) bundleTitle(t *tree) string {
+	if c.Title != "" {
+		return c.Title
+	}
+	return t.Entries[0].Name
+}
+
+// printTreeSummary tells what was shared of a directory and what was not,
+// in the plain output format
+func printTreeSummary(cli *CLI, t *tree, flattened bool) {
+	files := t.Files()
+	cli.printf("📁 Included %d files (%s)\n", len(files), formatSize(t.Size()))
+	for i, e := range files {
+		if i == maxListedFiles {
+			cli.printf("   … and %d more\n", len(files)-maxListedFiles)
+			break
+		}
+		name := e.Name
+		if flattened {
+			name = path.Base(name)
+		}
+		cli.printf("   %s (%s)\n", name, formatSize(e.Size))
+	}
+	if t.Ignored > 0 {
+		cli.printf("🙈 Ignored %d entries matching ignore patterns\n", t.Ignored)
+	}
+	for _, s := range t.Skipped {
+		fmt.Fprintf(os.Stderr, "⚠️  Skipped %s: %s\n", s.Name, s.Reason)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+type EditCmd struct {
+	Target      string `arg:"" predictor:"paste" help:"ID, link or history alias of the paste to edit"`
+	ManageToken string `env:"QUIP_MANAGE_TOKEN" help:"Manage token of the paste, unless it was created with your API key or is in the history"`
+}
+
+// Run opens the latest revision of the paste in the editor and saves the
+// result as a new revision
+func (c *EditCmd) Run(cli *CLI) error {
+	if err := cli.defaults().checkEncryption(); err != nil {
+		return err
+	}
+	id, err := cli.resolve(c.Target)
+	if err != nil {
+		return err
+	}
+	token := c.ManageToken
+	if h, err := cli.loadHistory(); err == nil && token == "" {
+		if e := h.find(cli.Server, id); e != nil {
+			token = e.ManageToken
+		}
+	}
+
+	// Read the paste through its latest revision, which does not count as a
+	// view for those who manage it
+	api := cli.client()
+	list, err := api.Revisions(cli.context(), id)
+	if err != nil {
+		return err
+	}
+	if len(list.Revisions) == 0 {
+		return fmt.Errorf("paste %s has no revisions", id)
+	}
+	latest := list.Revisions[len(list.Revisions)-1]
+
+	raw, err := api.RawRevision(cli.context(), id, latest.Number, token)
+	if err != nil {
+		return err
+	}
+	defer raw.Close()
+	content, err := io.ReadAll(raw)
+	if err != nil {
+		return err
+	}
+
+	original := pasteDraft{Title: latest.Title, Language: latest.Language, Content: string(content), NoTTL: true}
+	draft, err := editDraft(original)
+	if err != nil {
+		return err
+	}
+	draft.NoTTL = true
+	if draft.TTL != "" {
+		return keepDraft(draft, fmt.Errorf("the TTL of a paste cannot be changed"))
+	}
+
+	// Only send what changed, so that an unchanged language is not detected
+	// again. The revision makes the edit fail rather than overwrite one made
+	// while the editor was open.
+	edit := apiv1.EditPasteRequest{Revision: &latest.Number}
+	if draft.Content != original.Content {
+		edit.Content = &draft.Content
+	}
+	if draft.Title != original.Title {
+		edit.Title = &draft.Title
+	}
+	if draft.Language != original.Language {
+		edit.Language = &draft.Language
+	}
+
+	paste, err := api.EditPaste(cli.context(), id, edit, token)
+	if err != nil {
+		return keepDraft(draft, err)
+	}
+	link := cli.Server + paste.View
+	return cli.print(link, paste, func() {
+		if paste.Revision == latest.Number {
+			fmt.Println("✏️  Nothing changed")
+			return
+		}
+		fmt.Printf("✏️  Saved revision %d\n", paste.Revision)
+		fmt.Printf("👀 View: %s\n", link)
+	})
+}
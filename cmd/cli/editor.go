@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// errAborted is returned when the editor buffer is left empty or unchanged
+var errAborted = errors.New("aborted")
+
+// draftSeparator ends the header of a draft
+const draftSeparator = "---"
+
+// draftHelp opens every draft, and is dropped when it is parsed
+const draftHelp = `# Write the paste below the --- line. Empty fields take the defaults.
+# Leaving the paste empty, or unchanged, aborts.
+`
+
+// pasteDraft is a paste being written in the editor
+type pasteDraft struct {
+	Title    string
+	Language string
+	TTL      string
+	Content  string
+	// NoTTL leaves the ttl field out, for edits which cannot change it
+	NoTTL bool
+}
+
+// render formats the draft for the editor, its fields in a header above the
+// content
+func (d pasteDraft) render() string {
+	var b strings.Builder
+	b.WriteString(draftHelp)
+	fmt.Fprintf(&b, "title: %s\n", d.Title)
+	fmt.Fprintf(&b, "language: %s\n", d.Language)
+	if !d.NoTTL {
+		fmt.Fprintf(&b, "ttl: %s\n", d.TTL)
+	}
+	b.WriteString(draftSeparator + "\n")
+	b.WriteString(d.Content)
+	return b.String()
+}
+
+// parseDraft reads a draft back from the editor. The header is optional:
+// without a --- line, or with one preceded by anything but known fields and
+// # comments, such as a YAML document, the whole buffer is the content.
+func parseDraft(buf string) pasteDraft {
+	header, content, found := strings.Cut(buf, draftSeparator+"\n")
+	if !found {
+		return pasteDraft{Content: buf}
+	}
+
+	d := pasteDraft{Content: content}
+	for _, line := range strings.Split(header, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, ":")
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "title":
+			d.Title = value
+		case "language":
+			d.Language = value
+		case "ttl":
+			d.TTL = value
+		default:
+			return pasteDraft{Content: buf}
+		}
+	}
+	return d
+}
+
+// editDraft lets the user edit the draft and returns the result, or
+// errAborted if the content was left empty or nothing changed
+func editDraft(d pasteDraft) (pasteDraft, error) {
+	initial := d.render()
+	edited, err := editText(initial)
+	if err != nil {
+		return pasteDraft{}, err
+	}
+	if edited == initial {
+		return pasteDraft{}, fmt.Errorf("%w: nothing changed", errAborted)
+	}
+
+	result := parseDraft(edited)
+	if strings.TrimSpace(result.Content) == "" {
+		return pasteDraft{}, fmt.Errorf("%w: the paste is empty", errAborted)
+	}
+	return result, nil
+}
+
+// keepDraft saves a draft that could not be submitted, so that it is not
+// lost, and adds where to find it to err
+func keepDraft(d pasteDraft, err error) error {
+	f, createErr := os.CreateTemp("", "quip-draft-*.txt")
+	if createErr != nil {
+		return err
+	}
+	defer f.Close()
+	if _, writeErr := f.WriteString(d.render()); writeErr != nil {
+		return err
+	}
+	return fmt.Errorf("%w (your draft was saved to %s)", err, f.Name())
+}
+
+// editText opens text in the user's editor and returns what they saved
+func editText(text string) (string, error) {
+	f, err := os.CreateTemp("", "quip-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	args := append(editorCommand(), f.Name())
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running editor %s: %w", args[0], err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	return string(edited), err
+}
+
+// editorCommand returns the user's editor, which may come with arguments
+// such as "code --wait"
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEditor makes $VISUAL a script that replaces the edited file with
+// content, or leaves it alone if content is empty
+func fakeEditor(t *testing.T, content string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake editor is a shell script")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\n"
+	if content != "" {
+		src := filepath.Join(dir, "content")
+		require.NoError(t, os.WriteFile(src, []byte(content), 0o644))
+		script += "cp '" + src + "' \"$1\"\n"
+	}
+	editor := filepath.Join(dir, "editor.sh")
+	require.NoError(t, os.WriteFile(editor, []byte(script), 0o755))
+	t.Setenv("VISUAL", editor)
+}
+
+func TestParseDraft(t *testing.T) {
+	d := parseDraft(pasteDraft{Title: "notes", Language: "Go", TTL: "1h", Content: "package main\n"}.render())
+	assert.Equal(t, pasteDraft{Title: "notes", Language: "Go", TTL: "1h", Content: "package main\n"}, d, "round trip")
+
+	assert.Equal(t, pasteDraft{Content: "just text\n"}, parseDraft("just text\n"), "the header is optional")
+
+	yaml := "a: b\n---\nc: d\n"
+	assert.Equal(t, pasteDraft{Content: yaml}, parseDraft(yaml), "unknown fields are content")
+}
+
+func TestEditDraft(t *testing.T) {
+	fakeEditor(t, "")
+	_, err := editDraft(pasteDraft{Title: "untouched"})
+	assert.ErrorIs(t, err, errAborted)
+
+	fakeEditor(t, "title: x\n---\n  \n")
+	_, err = editDraft(pasteDraft{})
+	assert.ErrorIs(t, err, errAborted, "empty content")
+
+	fakeEditor(t, "title: Hello\nttl: 1d\n---\nhi there\n")
+	d, err := editDraft(pasteDraft{})
+	require.NoError(t, err)
+	assert.Equal(t, pasteDraft{Title: "Hello", TTL: "1d", Content: "hi there\n"}, d)
+}
+
+func TestCreatePasteWithEditor(t *testing.T) {
+	var got apiv1.CreatePasteRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, apiv1.Prefix+"/paste", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		assert.NoError(t, json.NewEncoder(w).Encode(apiv1.PasteCreated{ID: "abc"}))
+	}))
+	defer srv.Close()
+
+	fakeEditor(t, "# comment\ntitle: From the editor\nlanguage: Go\nttl: 2h\n---\npackage main\n")
+	cmd := &PasteCmd{Edit: true, Title: "from flags", TTL: "1h"}
+	require.NoError(t, cmd.Run(&CLI{Server: srv.URL}))
+	assert.Equal(t, apiv1.CreatePasteRequest{Title: "From the editor", Language: "Go", TTL: "2h", Content: "package main\n"}, got)
+}
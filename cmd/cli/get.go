@@ -0,0 +1,229 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/Gandalf-Le-Dev/quip/pkg/client"
+)
+
+// partSuffix marks a download in progress, which the next attempt resumes.
+// Its resume token, which keeps the server from counting the resume as
+// another download, is kept next to it under tokenSuffix.
+const (
+	partSuffix  = ".part"
+	tokenSuffix = ".token"
+)
+
+type GetCmd struct {
+	Target string `arg:"" predictor:"content" help:"ID, link or history alias of the file, paste or bundle"`
+	Output string `short:"o" name:"output-file" help:"File or directory to save to, or - for stdout (defaults to the original name in the current directory)"`
+	Force  bool   `short:"f" help:"Overwrite the output file if it exists"`
+	Format string `enum:"zip,tar.gz" default:"zip" help:"Archive format of bundles and multi-file pastes"`
+}
+
+// Run downloads the content an ID names: a file under its original name, a
+// bundle or multi-file paste as an archive, and a paste as text
+func (c *GetCmd) Run(cli *CLI) error {
+	id, err := cli.resolve(c.Target)
+	if err != nil {
+		return err
+	}
+	api, ctx := cli.client(), cli.context()
+	content, err := api.Content(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case content.File != nil:
+		return c.download(cli, content.File.Filename, content.File.Size, func(opts client.DownloadOptions) (*client.Download, error) {
+			return api.DownloadFile(ctx, id, opts)
+		})
+	case content.Bundle != nil:
+		name := orDefault(content.Bundle.Title, id) + "." + c.Format
+		return c.download(cli, name, -1, func(opts client.DownloadOptions) (*client.Download, error) {
+			return api.DownloadBundle(ctx, id, c.Format, opts)
+		})
+	case content.Paste != nil && len(content.Paste.Files) > 0:
+		if c.Format != client.ArchiveZip {
+			return fmt.Errorf("multi-file pastes are only downloaded as zip")
+		}
+		// The lookup returned the files and counted the view, so the archive
+		// is built here rather than downloaded, which would count another
+		archive, err := pasteZip(content.Paste)
+		if err != nil {
+			return err
+		}
+		return c.save(cli, orDefault(content.Paste.Title, id)+".zip", archive)
+	case content.Paste != nil:
+		// The lookup already returned, and counted, the paste
+		return c.save(cli, id+".txt", strings.NewReader(content.Paste.Content))
+	}
+	return fmt.Errorf("the server answered with unknown content %q", content.Kind)
+}
+
+// pasteZip archives the files of a multi-file paste as the server would
+func pasteZip(paste *apiv1.Paste) (io.Reader, error) {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, f := range paste.Files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: paste.CreatedAt})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(fw, f.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// destination returns where to save content named name
+func (c *GetCmd) destination(name string) (string, error) {
+	// The name comes from the server, and must not lead outside the directory
+	name = filepath.Base(filepath.FromSlash(name))
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return "", fmt.Errorf("the server gave an invalid file name %q, use --output-file", name)
+	}
+	dest := c.Output
+	if info, err := os.Stat(dest); dest == "" || (err == nil && info.IsDir()) {
+		dest = filepath.Join(dest, name)
+	}
+	if _, err := os.Lstat(dest); err == nil && !c.Force {
+		return "", fmt.Errorf("%s already exists, use --force to overwrite it", dest)
+	}
+	return dest, nil
+}
+
+// save writes content that was already fetched
+func (c *GetCmd) save(cli *CLI, name string, r io.Reader) error {
+	if c.Output == "-" {
+		_, err := io.Copy(os.Stdout, r)
+		return err
+	}
+	dest, err := c.destination(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return printSaved(cli, dest, n)
+}
+
+// download streams what open returns into a file named name, or the
+// output, through a .part file that a later attempt resumes with a range
+// request. size is the expected size, or -1 when it is not known upfront.
+func (c *GetCmd) download(cli *CLI, name string, size int64, open func(client.DownloadOptions) (*client.Download, error)) error {
+	if c.Output == "-" {
+		d, err := open(client.DownloadOptions{})
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+		_, err = io.Copy(os.Stdout, d)
+		return err
+	}
+
+	dest, err := c.destination(name)
+	if err != nil {
+		return err
+	}
+	part := dest + partSuffix
+	var offset int64
+	var token string
+	if info, err := os.Stat(part); err == nil && size >= 0 {
+		// Archives are built on the fly and may differ from one request to
+		// the next, so only files resume
+		offset = info.Size()
+		if data, err := os.ReadFile(part + tokenSuffix); err == nil {
+			token = string(data)
+		}
+	}
+	if offset > 0 && offset == size {
+		return c.finish(cli, part, dest, size)
+	}
+
+	bar := cli.newProgress("📥 "+filepath.Base(dest), size)
+	d, err := open(client.DownloadOptions{Offset: offset, ResumeToken: token, Progress: bar.report})
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if size >= 0 && d.ResumeToken != "" && d.ResumeToken != token {
+		if err := os.WriteFile(part+tokenSuffix, []byte(d.ResumeToken), 0o600); err != nil {
+			return err
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if d.Offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+		cli.printf("⏯️  Resuming %s at %s\n", dest, formatSize(d.Offset))
+	}
+	f, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f, d)
+	bar.finish()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size >= 0 && d.Offset+n != size {
+		err = errIncomplete
+	}
+	if err != nil {
+		if size >= 0 {
+			return fmt.Errorf("%w, run the same command again to resume the download", err)
+		}
+		os.Remove(part)
+		return err
+	}
+
+	return c.finish(cli, part, dest, d.Offset+n)
+}
+
+// finish moves a complete download into place
+func (c *GetCmd) finish(cli *CLI, part, dest string, size int64) error {
+	if err := os.Rename(part, dest); err != nil {
+		return err
+	}
+	os.Remove(part + tokenSuffix)
+	return printSaved(cli, dest, size)
+}
+
+// saved is what get prints in the json output format
+type saved struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+func printSaved(cli *CLI, dest string, size int64) error {
+	return cli.print("", saved{Path: dest, Size: size}, func() {
+		fmt.Printf("📥 Saved %s (%s)\n", dest, formatSize(size))
+	})
+}
+
+// errIncomplete is returned when the server closed a download early
+var errIncomplete = errors.New("download incomplete")
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	for _, c := range []struct {
+		target, id, server string
+	}{
+		{"abc123", "abc123", "http://default"},
+		{"https://quip.example/api/v1/view/abc123", "abc123", "https://quip.example"},
+		{"https://quip.example/api/v1/file/abc123/info", "abc123", "https://quip.example"},
+		{"http://host:8080/api/paste/abc123/raw", "abc123", "http://host:8080"},
+		{"https://example.com/quip/api/v1/bundle/abc123/zip", "abc123", "https://example.com/quip"},
+	} {
+		cli := &CLI{Server: "http://default"}
+		id, err := cli.resolve(c.target)
+		require.NoError(t, err, c.target)
+		assert.Equal(t, c.id, id, c.target)
+		assert.Equal(t, c.server, cli.Server, c.target)
+	}
+
+	for _, target := range []string{"", "ftp://host/api/v1/file/abc", "https://host/elsewhere", "a/b"} {
+		_, err := (&CLI{}).resolve(target)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestGetResumes(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	var ranges, tokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case apiv1.ContentPath("abc"):
+			assert.NoError(t, json.NewEncoder(w).Encode(apiv1.Content{Kind: apiv1.KindFile, File: &apiv1.File{
+				ID: "abc", Filename: "../report.bin", Size: int64(len(data)),
+			}}))
+		case apiv1.FilePath("abc"):
+			ranges = append(ranges, r.Header.Get("Range"))
+			tokens = append(tokens, r.Header.Get(apiv1.HeaderResumeToken))
+			w.Header().Set(apiv1.HeaderResumeToken, "next")
+			http.ServeContent(w, r, "report.bin", time.Time{}, bytes.NewReader(data))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "report.bin")
+	require.NoError(t, os.WriteFile(dest+partSuffix, data[:300], 0o644))
+	require.NoError(t, os.WriteFile(dest+partSuffix+tokenSuffix, []byte("counted"), 0o600))
+
+	cmd := &GetCmd{Target: srv.URL + apiv1.ViewPath("abc"), Output: dir}
+	require.NoError(t, cmd.Run(&CLI{}))
+	got, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, data, got, "saved under its base name, resumed from the part")
+	assert.Equal(t, []string{"bytes=300-"}, ranges)
+	assert.Equal(t, []string{"counted"}, tokens, "the resume is tied to the counted download")
+	assert.NoFileExists(t, dest+partSuffix)
+	assert.NoFileExists(t, dest+partSuffix+tokenSuffix)
+
+	assert.ErrorContains(t, cmd.Run(&CLI{}), "already exists")
+
+	// A part longer than the file is started over
+	require.NoError(t, os.WriteFile(dest+partSuffix, bytes.Repeat([]byte("x"), 2000), 0o644))
+	cmd.Force = true
+	require.NoError(t, cmd.Run(&CLI{}))
+	got, err = os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestGetMultiFilePaste(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		assert.NoError(t, json.NewEncoder(w).Encode(apiv1.Content{Kind: apiv1.KindPaste, Paste: &apiv1.Paste{
+			ID: "abc", Title: "demo", Content: "package main\n",
+			Files: []apiv1.PasteFile{{Name: "main.go", Content: "package main\n"}, {Name: "go.mod", Content: "module demo\n"}},
+		}}))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cmd := &GetCmd{Target: srv.URL + apiv1.ViewPath("abc"), Output: dir, Format: "zip"}
+	require.NoError(t, cmd.Run(&CLI{}))
+	assert.Equal(t, []string{apiv1.ContentPath("abc")}, requests, "the lookup is the only view counted")
+
+	zr, err := zip.OpenReader(filepath.Join(dir, "demo.zip"))
+	require.NoError(t, err)
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"main.go", "go.mod"}, names)
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// maxHistory bounds the entries kept, the oldest going first
+const maxHistory = 1000
+
+// historyEntry is something shared from this machine
+type historyEntry struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind"`
+	Name        string     `json:"name,omitempty"`
+	Server      string     `json:"server"`
+	View        string     `json:"view"`
+	Download    string     `json:"download,omitempty"` // the raw text of pastes
+	ManageToken string     `json:"manage_token,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+func (e *historyEntry) expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !e.ExpiresAt.After(now)
+}
+
+// history is the local record of what was shared, oldest first, kept as
+// one JSON object per line
+type history struct {
+	path    string
+	Entries []historyEntry
+}
+
+// defaultHistoryPath returns ~/.local/state/quip/history.jsonl, or where the
+// platform keeps such files
+func defaultHistoryPath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "quip", "history.jsonl")
+	}
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ".local", "state", "quip", "history.jsonl")
+		}
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "quip", "history.jsonl")
+	}
+	return ""
+}
+
+// loadHistory reads the history at path, which may not exist yet. Lines that
+// do not parse are dropped rather than making the whole history unusable.
+func loadHistory(path string) (*history, error) {
+	h := &history{path: path}
+	if path == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e historyEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil && e.ID != "" {
+			h.Entries = append(h.Entries, e)
+		}
+	}
+	return h, scanner.Err()
+}
+
+// save writes the history, readable by the user only since it holds manage
+// tokens
+func (h *history) save() error {
+	if h.path == "" {
+		return fmt.Errorf("no history file, set --history-file or QUIP_HISTORY")
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, e := range h.Entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(h.path), ".history-*.jsonl")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(b.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), h.path)
+}
+
+// prune drops the expired entries and returns how many there were
+func (h *history) prune(now time.Time) int {
+	kept := h.Entries[:0]
+	for _, e := range h.Entries {
+		if !e.expired(now) {
+			kept = append(kept, e)
+		}
+	}
+	pruned := len(h.Entries) - len(kept)
+	h.Entries = kept
+	return pruned
+}
+
+// alias returns the entry named by "last", the latest one, or "~n", the n-th
+// latest one, or nil if target is not an alias
+func (h *history) alias(target string) (*historyEntry, error) {
+	n := 0
+	switch {
+	case target == "last":
+		n = 1
+	case strings.HasPrefix(target, "~"):
+		var err error
+		if n, err = strconv.Atoi(target[1:]); err != nil || n < 1 {
+			return nil, fmt.Errorf("%q is not a history alias, use last, ~1, ~2…", target)
+		}
+	default:
+		return nil, nil
+	}
+	if n > len(h.Entries) {
+		return nil, fmt.Errorf("%s: the history only has %d entries", target, len(h.Entries))
+	}
+	return &h.Entries[len(h.Entries)-n], nil
+}
+
+// find returns the entry of id on server, if any
+func (h *history) find(server, id string) *historyEntry {
+	for i := len(h.Entries) - 1; i >= 0; i-- {
+		if e := &h.Entries[i]; e.ID == id && e.Server == server {
+			return e
+		}
+	}
+	return nil
+}
+
+// remove drops the entry of id on server and tells whether there was one
+func (h *history) remove(server, id string) bool {
+	for i, e := range h.Entries {
+		if e.ID == id && e.Server == server {
+			h.Entries = append(h.Entries[:i], h.Entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// loadHistory reads the history file of the CLI
+func (c *CLI) loadHistory() (*history, error) {
+	return loadHistory(c.HistoryFile)
+}
+
+// remember records something just shared. The history is a convenience, so
+// failing to record it only warns.
+func (c *CLI) remember(e historyEntry) {
+	if c.NoHistory || c.HistoryFile == "" {
+		return
+	}
+	e.Server = c.Server
+	e.CreatedAt = time.Now()
+	err := func() error {
+		h, err := c.loadHistory()
+		if err != nil {
+			return err
+		}
+		h.prune(time.Now())
+		h.Entries = append(h.Entries, e)
+		if len(h.Entries) > maxHistory {
+			h.Entries = h.Entries[len(h.Entries)-maxHistory:]
+		}
+		return h.save()
+	}()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not record %s in the history: %v\n", e.ID, err)
+	}
+}
+
+// forget removes an entry from the history, after it was deleted
+func (c *CLI) forget(id string) {
+	if c.HistoryFile == "" {
+		return
+	}
+	h, err := c.loadHistory()
+	if err != nil || !h.remove(c.Server, id) {
+		return
+	}
+	if err := h.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not remove %s from the history: %v\n", id, err)
+	}
+}
+
+type HistoryCmd struct {
+	Kind  string `enum:"file,paste,bundle," default:"" help:"Only list files, pastes or bundles"`
+	Grep  string `short:"g" help:"Only list entries whose name or ID contains this"`
+	Host  string `help:"Only list entries of servers whose URL contains this"`
+	Limit int    `short:"n" default:"20" help:"Maximum number of entries, 0 for all"`
+	JSON  bool   `help:"Print the entries as JSON, manage tokens included"`
+	Prune bool   `help:"Remove expired entries from the history"`
+	Clear bool   `help:"Remove every entry from the history"`
+}
+
+func (c *HistoryCmd) Run(cli *CLI) error {
+	h, err := cli.loadHistory()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	if c.Clear || c.Prune {
+		n := len(h.Entries)
+		if c.Prune {
+			n = h.prune(now)
+		} else {
+			h.Entries = nil
+		}
+		if err := h.save(); err != nil {
+			return err
+		}
+		cli.printf("🧹 Removed %d entries from the history\n", n)
+		return nil
+	}
+
+	// Newest first, numbered as the ~n aliases
+	type listed struct {
+		Alias string `json:"alias"`
+		historyEntry
+	}
+	var entries []listed
+	for i := len(h.Entries) - 1; i >= 0; i-- {
+		e := h.Entries[i]
+		switch {
+		case e.expired(now),
+			c.Kind != "" && e.Kind != c.Kind,
+			c.Grep != "" && !strings.Contains(strings.ToLower(e.Name+" "+e.ID), strings.ToLower(c.Grep)),
+			c.Host != "" && !strings.Contains(e.Server, c.Host):
+			continue
+		}
+		entries = append(entries, listed{"~" + strconv.Itoa(len(h.Entries)-i), e})
+		if c.Limit > 0 && len(entries) == c.Limit {
+			break
+		}
+	}
+
+	if cli.format() == outputURL {
+		for _, e := range entries {
+			fmt.Println(e.View)
+		}
+		return nil
+	}
+	if c.JSON || cli.format() == outputJSON {
+		if entries == nil {
+			entries = []listed{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing in the history")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, e := range entries {
+		expires := "never expires"
+		if e.ExpiresAt != nil {
+			expires = "expires in " + e.ExpiresAt.Sub(now).Round(time.Minute).String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Alias, e.ID, e.Kind, orDefault(e.Name, "-"), expires, e.View)
+	}
+	return w.Flush()
+}
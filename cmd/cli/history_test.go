@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quip", "history.jsonl")
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	cli := &CLI{Server: "https://quip.example", HistoryFile: path}
+	cli.remember(historyEntry{ID: "old", Kind: "file", ExpiresAt: &past})
+	cli.remember(historyEntry{ID: "abc", Kind: "file", Name: "notes.txt", ManageToken: "token"})
+	cli.remember(historyEntry{ID: "def", Kind: "paste", ExpiresAt: &future})
+	(&CLI{Server: "https://quip.example", HistoryFile: path, NoHistory: true}).remember(historyEntry{ID: "nope", Kind: "file"})
+
+	// A line that does not parse does not lose the others
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString("not json\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	h, err := cli.loadHistory()
+	require.NoError(t, err)
+	require.Len(t, h.Entries, 2, "expired entries are pruned when recording")
+	assert.Equal(t, "https://quip.example", h.Entries[0].Server)
+	assert.Equal(t, 1, h.prune(future.Add(time.Minute)))
+	h.Entries = append(h.Entries, historyEntry{ID: "def", Kind: "paste", Server: "https://quip.example"})
+
+	last, err := h.alias("last")
+	require.NoError(t, err)
+	assert.Equal(t, "def", last.ID)
+	second, err := h.alias("~2")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", second.ID)
+	_, err = h.alias("~3")
+	assert.Error(t, err)
+	_, err = h.alias("~x")
+	assert.Error(t, err)
+	none, err := h.alias("abc")
+	assert.NoError(t, err)
+	assert.Nil(t, none)
+
+	// Aliases point the CLI at the server of the entry
+	other := &CLI{Server: "http://localhost:8080", Token: "key", HistoryFile: path}
+	id, err := other.resolve("~2")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", id)
+	assert.Equal(t, "https://quip.example", other.Server)
+	assert.Empty(t, other.Token)
+}
+
+func TestRmFromHistory(t *testing.T) {
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get(apiv1.HeaderManageToken))
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	cli := &CLI{Server: srv.URL, HistoryFile: path}
+	cli.remember(historyEntry{ID: "abc", Kind: "paste", ManageToken: "token"})
+
+	require.NoError(t, (&RmCmd{Target: "last"}).Run(cli))
+	assert.Equal(t, []string{apiv1.PastePath("abc")}, deleted, "the kind is known, no other is tried")
+
+	h, err := cli.loadHistory()
+	require.NoError(t, err)
+	assert.Empty(t, h.Entries, "deleted content leaves the history")
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFiles are read in every directory of an uploaded tree
+var ignoreFiles = []string{".gitignore", ".quipignore"}
+
+// ignoreRule is one line of an ignore file, following .gitignore syntax
+type ignoreRule struct {
+	base     string // directory of the ignore file, relative to the root
+	pattern  string
+	negate   bool // "!pattern" re-includes what earlier rules excluded
+	dirOnly  bool // "pattern/" only matches directories
+	anchored bool // patterns with a slash match from base, others any name
+}
+
+// ignoreMatcher holds the rules that apply while walking a tree. As in git,
+// the last rule matching a path decides.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// add parses patterns found in the directory base, relative to the root
+func (m *ignoreMatcher) add(base string, lines []string) {
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`) // escapes a leading # or !
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		m.rules = append(m.rules, rule)
+	}
+}
+
+// load adds the rules of the ignore files found in dir, relative to the root
+func (m *ignoreMatcher) load(root, dir string) error {
+	for _, name := range ignoreFiles {
+		f, err := os.Open(filepath.Join(root, filepath.FromSlash(dir), name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var lines []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		m.add(dir, lines)
+	}
+	return nil
+}
+
+// ignored reports whether the slash-separated path rel, relative to the
+// root, is excluded
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.negate != ignored || (rule.dirOnly && !isDir) {
+			// Only a rule that would flip the outcome needs matching
+			continue
+		}
+		name, ok := relativeTo(rule.base, rel)
+		if !ok {
+			continue
+		}
+		if !rule.anchored {
+			name = path.Base(name)
+		}
+		if matchGlob(rule.pattern, name) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// relativeTo returns rel relative to the directory base, if it lies below it
+func relativeTo(base, rel string) (string, bool) {
+	if base == "" || base == "." {
+		return rel, true
+	}
+	if !strings.HasPrefix(rel, base+"/") {
+		return "", false
+	}
+	return rel[len(base)+1:], true
+}
+
+// matchGlob matches a slash-separated name against a pattern whose "**"
+// segments match any number of directories
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
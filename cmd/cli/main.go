@@ -1,167 +1,97 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"mime/multipart"
-	"net/http"
+	"context"
 	"os"
-	"path/filepath"
-	"time"
+	"os/signal"
+	"strings"
 
+	"github.com/Gandalf-Le-Dev/quip/pkg/client"
 	"github.com/alecthomas/kong"
 )
 
 type CLI struct {
-	File     string        `arg:"" optional:"" help:"File to share"`
-	Language string        `short:"l" help:"Language for syntax highlighting"`
-	TTL      time.Duration `short:"t" default:"24h" help:"Time to live"`
-	Edit     bool          `short:"e" help:"Open editor for text"`
-	Server   string        `default:"http://localhost:8080" help:"Server URL"`
+	Server      string `help:"Server URL (defaults to the profile's, or http://localhost:8080)"`
+	Token       string `env:"QUIP_TOKEN" help:"API key, required when the server disallows anonymous uploads (defaults to the profile's)"`
+	Profile     string `short:"p" env:"QUIP_PROFILE" predictor:"profile" help:"Profile of the configuration file to use (defaults to its default profile)"`
+	Config      string `env:"QUIP_CONFIG" type:"path" help:"Configuration file" default:"${config_path}"`
+	HistoryFile string `env:"QUIP_HISTORY" type:"path" help:"History of what was shared from this machine" default:"${history_path}"`
+	NoHistory   bool   `help:"Do not record what is shared in the history"`
+	Output      string `enum:"plain,json,url" default:"plain" env:"QUIP_OUTPUT" help:"Format of the results: plain, json or url"`
+	Quiet       bool   `short:"q" help:"Only print the URL of the result, without progress"`
+	Copy        bool   `short:"c" help:"Copy the URL of the result to the clipboard, through the terminal over SSH"`
+	QR          bool   `help:"Show the URL of the result as a QR code, to open it from a phone"`
+
+	Up      UpCmd      `cmd:"" default:"withargs" help:"Share files or a directory, or stdin as a paste (default)"`
+	Paste   PasteCmd   `cmd:"" help:"Create a paste from text files, stdin or your editor"`
+	Get     GetCmd     `cmd:"" help:"Download a file, bundle or paste"`
+	Info    InfoCmd    `cmd:"" help:"Show what an ID or link points to"`
+	Rm      RmCmd      `cmd:"" help:"Delete a file, bundle or paste"`
+	Cat     CatCmd     `cmd:"" help:"Print a paste"`
+	Open    OpenCmd    `cmd:"" help:"Open a file, bundle or paste in the browser"`
+	Search  SearchCmd  `cmd:"" help:"Search your pastes"`
+	Diff    DiffCmd    `cmd:"" help:"Compare a local file with a paste"`
+	Edit    EditCmd    `cmd:"" help:"Edit a paste in your editor"`
+	History HistoryCmd `cmd:"" help:"List what was shared from this machine"`
+	Login   LoginCmd   `cmd:"" help:"Save a server and API key as a profile"`
+	Conf    ConfigCmd  `cmd:"" name:"config" help:"Show or change the configuration file"`
+
+	Completion CompletionCmd `cmd:"" help:"Print the completion script of bash, zsh or fish"`
+	Man        ManCmd        `cmd:"" help:"Print the manual page"`
+	Complete   CompleteCmd   `cmd:"" name:"__complete" hidden:"" help:"Complete a command line, for the completion scripts"`
+
+	ctx     context.Context
+	config  *cliConfig
+	profile *profile
+	given   given
 }
 
-func (c *CLI) Run() error {
-	// Check if we have piped input
-	stat, _ := os.Stdin.Stat()
-	isPiped := (stat.Mode() & os.ModeCharDevice) == 0
-
-	if c.Edit {
-		return c.createPasteWithEditor()
-	}
-
-	if isPiped && c.File == "" {
-		// Handle piped input as paste
-		return c.createPasteFromStdin()
-	}
-
-	if c.File != "" {
-		// Check if file exists
-		if _, err := os.Stat(c.File); os.IsNotExist(err) {
-			return fmt.Errorf("file not found: %s", c.File)
-		}
-		// Upload file
-		return c.uploadFile()
-	}
-
-	return fmt.Errorf("no input provided")
+// client returns an API client for the server, authenticated when a token
+// is set
+func (c *CLI) client() *client.Client {
+	return client.New(c.Server, client.WithToken(c.Token), client.WithUserAgent("quip-cli"))
 }
 
-func (c *CLI) uploadFile() error {
-	file, err := os.Open(c.File)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Create multipart form
-	var b bytes.Buffer
-	w := multipart.NewWriter(&b)
-
-	// Add file
-	fw, err := w.CreateFormFile("file", filepath.Base(c.File))
-	if err != nil {
-		return err
-	}
-
-	if _, err := io.Copy(fw, file); err != nil {
-		return err
-	}
-
-	// Add TTL
-	if err := w.WriteField("ttl", c.TTL.String()); err != nil {
-		return err
-	}
-
-	w.Close()
-
-	// Make request
-	resp, err := http.Post(c.Server+"/api/file", w.FormDataContentType(), &b)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	var result struct {
-		ID       string `json:"id"`
-		Filename string `json:"filename"`
-		Size     int64  `json:"size"`
-		Download string `json:"download"`
-		View     string `json:"view"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return err
+// defaults returns the profile in use, empty if there is none
+func (c *CLI) defaults() *profile {
+	if c.profile == nil {
+		return &profile{}
 	}
-
-	// Print results
-	fmt.Printf("📤 Uploaded: %s\n", result.Filename)
-	fmt.Printf("🔗 Download: curl -J -O %s/api/file/%s\n", c.Server, result.ID)
-	fmt.Printf("👀 View: %s%s\n", c.Server, result.View)
-
-	return nil
-}
-
-func (c *CLI) createPasteFromStdin() error {
-	content, err := io.ReadAll(os.Stdin)
-	if err != nil {
-		return err
-	}
-
-	return c.createPaste(string(content))
-}
-
-func (c *CLI) createPasteFromText(text string) error {
-	return c.createPaste(text)
+	return c.profile
 }
 
-func (c *CLI) createPaste(content string) error {
-	payload := map[string]string{
-		"content":  content,
-		"language": c.Language,
-		"ttl":      c.TTL.String(),
-	}
-
-	body, _ := json.Marshal(payload)
-	resp, err := http.Post(c.Server+"/api/paste", "application/json", bytes.NewReader(body))
-	if err != nil {
-		return err
+// context is cancelled when the user interrupts the CLI
+func (c *CLI) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
 	}
-	defer resp.Body.Close()
-
-	var result struct {
-		ID       string `json:"id"`
-		Language string `json:"language"`
-		Raw      string `json:"raw"`
-		View     string `json:"view"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return err
-	}
-
-	// Print results
-	fmt.Printf("📋 Created paste\n")
-	fmt.Printf("🔗 Raw: curl %s%s\n", c.Server, result.Raw)
-	fmt.Printf("👀 View: %s%s\n", c.Server, result.View)
-
-	return nil
-}
-
-func (c *CLI) createPasteWithEditor() error {
-	// Would open $EDITOR here
-	fmt.Println("Editor mode not implemented in this example")
-	return nil
+	return c.ctx
 }
 
 func main() {
 	var cli CLI
-	ctx := kong.Parse(&cli,
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	cli.ctx = ctx
+
+	kctx := kong.Parse(&cli,
 		kong.Name("quip"),
 		kong.Description("Simple file sharing and pastebin"),
 		kong.UsageOnError(),
+		kong.Vars{"config_path": defaultConfigPath(), "history_path": defaultHistoryPath()},
 	)
+	command := kctx.Command()
+	creating := strings.HasPrefix(command, "login") || strings.HasPrefix(command, "config")
+	// Completion loads the profile once it has read the flags of the line it
+	// completes, and the completion script and manual need none
+	local := strings.HasPrefix(command, "completion") || strings.HasPrefix(command, "__complete") || command == "man"
+	if !local {
+		if err := cli.loadProfile(creating); err != nil {
+			kctx.FatalIfErrorf(err)
+		}
+	}
 
-	if err := cli.Run(); err != nil {
-		ctx.FatalIfErrorf(err)
+	if err := kctx.Run(&cli); err != nil {
+		kctx.FatalIfErrorf(exitStatus{err, exitCode(err)})
 	}
 }
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandLine(t *testing.T) {
+	// kong rejects flags that clash across commands when it builds the parser
+	_, err := kong.New(&CLI{}, kong.Vars{"config_path": "", "history_path": ""})
+	assert.NoError(t, err)
+}
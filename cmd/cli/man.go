@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+// ManCmd prints the manual page, written from the same definitions as --help
+type ManCmd struct{}
+
+func (cmd *ManCmd) Run(kctx *kong.Context) error {
+	return writeMan(os.Stdout, kctx.Model)
+}
+
+// exitCodes documents the exit codes in the manual page
+var exitCodes = []struct {
+	code    int
+	meaning string
+}{
+	{0, "Success."},
+	{exitError, "Any other error."},
+	{exitNotFound, "Not found."},
+	{exitExpired, "Expired."},
+	{exitLimitExceeded, "Out of downloads or views."},
+	{exitTooLarge, "Too large, over quota, or the server is out of storage."},
+	{exitAuth, "Missing, invalid or insufficient API key."},
+	{exitNetwork, "The server could not be reached."},
+	{80, "Invalid command line."},
+	{exitInterrupted, "Interrupted."},
+}
+
+// writeMan writes the manual page of app in roff
+func writeMan(w io.Writer, app *kong.Application) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s 1 \"\" \"%s\" \"User Commands\"\n", strings.ToUpper(app.Name), app.Name)
+	fmt.Fprintf(&b, ".SH NAME\n%s \\- %s\n", app.Name, roff(app.Help))
+	fmt.Fprintf(&b, ".SH SYNOPSIS\n.B %s\n[\\fIflags\\fR] [\\fIcommand\\fR] [\\fIargs\\fR ...]\n", app.Name)
+	b.WriteString(".SH DESCRIPTION\n")
+	fmt.Fprintf(&b, "%s shares files, directories and pastes through a quip server.", roff(app.Name))
+	if app.DefaultCmd != nil {
+		fmt.Fprintf(&b, " Without a command, it runs \\fB%s\\fR.", app.DefaultCmd.Name)
+	}
+	b.WriteString("\n")
+
+	b.WriteString(".SH OPTIONS\n")
+	writeManFlags(&b, app.Node)
+
+	b.WriteString(".SH COMMANDS\n")
+	var commands func(n *kong.Node)
+	commands = func(n *kong.Node) {
+		for _, child := range n.Children {
+			if child.Hidden || child.Type != kong.CommandNode {
+				continue
+			}
+			fmt.Fprintf(&b, ".SS \"%s %s\"\n%s\n", app.Name, roff(child.Summary()), roff(child.Help))
+			for _, arg := range child.Positional {
+				fmt.Fprintf(&b, ".TP\n\\fI<%s>\\fR\n%s\n", roff(arg.Name), roff(arg.Help))
+			}
+			writeManFlags(&b, child)
+			commands(child)
+		}
+	}
+	commands(app.Node)
+
+	b.WriteString(".SH EXIT STATUS\n")
+	for _, e := range exitCodes {
+		fmt.Fprintf(&b, ".TP\n.B %d\n%s\n", e.code, e.meaning)
+	}
+
+	b.WriteString(".SH ENVIRONMENT\n")
+	seen := map[string]bool{} // commands may share a variable
+	_ = kong.Visit(app, func(v kong.Visitable, next kong.Next) error {
+		if flag, ok := v.(*kong.Flag); ok && !flag.Hidden {
+			for _, env := range flag.Envs {
+				if !seen[env] {
+					seen[env] = true
+					fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", env, roff(flag.Help))
+				}
+			}
+		}
+		return next(nil)
+	})
+
+	b.WriteString(".SH FILES\n")
+	fmt.Fprintf(&b, ".TP\n.I %s\nProfiles, see \\fB%s config\\fR.\n", roff(defaultConfigPath()), app.Name)
+	fmt.Fprintf(&b, ".TP\n.I %s\nHistory, see \\fB%s history\\fR.\n", roff(defaultHistoryPath()), app.Name)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeManFlags lists the visible flags of a node, without those of its
+// parents
+func writeManFlags(b *strings.Builder, n *kong.Node) {
+	for _, flag := range n.Flags {
+		if flag.Hidden {
+			continue
+		}
+		b.WriteString(".TP\n")
+		if flag.Short != 0 {
+			fmt.Fprintf(b, "\\fB\\-%c\\fR, ", flag.Short)
+		}
+		fmt.Fprintf(b, "\\fB\\-\\-%s\\fR", roff(flag.Name))
+		if !flag.IsBool() && !flag.IsCounter() {
+			placeholder := flag.PlaceHolder
+			if placeholder == "" {
+				placeholder = strings.ToUpper(strings.ReplaceAll(flag.Name, "-", "_"))
+			}
+			fmt.Fprintf(b, "=\\fI%s\\fR", roff(placeholder))
+		}
+		b.WriteString("\n" + roff(flag.Help))
+		if flag.HasDefault && flag.Default != "" && !flag.IsBool() {
+			fmt.Fprintf(b, " (default: %s)", roff(flag.Default))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// roff escapes text for a manual page
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/Gandalf-Le-Dev/quip/pkg/client"
+)
+
+// Formats of what commands print on stdout, see --output
+const (
+	outputPlain = "plain"
+	outputJSON  = "json"
+	outputURL   = "url"
+)
+
+// format returns the output format, -q standing for url
+func (c *CLI) format() string {
+	if c.Quiet {
+		return outputURL
+	}
+	return orDefault(c.Output, outputPlain)
+}
+
+// print prints the result of a command in the output format: plain calls
+// plain, json prints result with link added to its fields as "url", and url
+// prints link alone, if any. The link is copied too with --copy, and shown
+// as a QR code with --qr.
+func (c *CLI) print(link string, result any, plain func()) error {
+	c.copyLink(link)
+	defer c.showQR(link)
+	switch c.format() {
+	case outputJSON:
+		return printJSON(result, link)
+	case outputURL:
+		if link != "" {
+			fmt.Println(link)
+		}
+		return nil
+	default:
+		plain()
+		return nil
+	}
+}
+
+// printf prints a message in the plain output format only, leaving stdout to
+// the results otherwise
+func (c *CLI) printf(format string, args ...any) {
+	if c.format() == outputPlain {
+		fmt.Printf(format, args...)
+	}
+}
+
+// printJSON prints v indented, with a "url" field added to objects when link
+// is set
+func printJSON(v any, link string) error {
+	if link != "" {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(data, &fields) == nil {
+			fields["url"], _ = json.Marshal(link)
+			v = fields
+		}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Exit codes, so that scripts can tell failures apart. Command line errors
+// exit with 80, as kong does.
+const (
+	exitError         = 1
+	exitNotFound      = 3
+	exitExpired       = 4
+	exitLimitExceeded = 5
+	exitTooLarge      = 6
+	exitAuth          = 7
+	exitNetwork       = 8
+	exitInterrupted   = 130
+)
+
+// exitCode returns the exit code of a command that failed with err
+func exitCode(err error) int {
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, client.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, client.ErrExpired):
+		return exitExpired
+	case errors.Is(err, client.ErrLimitExceeded):
+		return exitLimitExceeded
+	case errors.Is(err, client.ErrTooLarge), errors.Is(err, client.ErrQuotaExceeded), errors.Is(err, client.ErrStorageFull):
+		return exitTooLarge
+	case errors.Is(err, client.ErrUnauthorized), errors.Is(err, client.ErrForbidden):
+		return exitAuth
+	case errors.As(err, &urlErr):
+		// The client only returns these when the server could not be reached
+		return exitNetwork
+	}
+	return exitError
+}
+
+// exitStatus carries the exit code of an error to kong
+type exitStatus struct {
+	error
+	code int
+}
+
+func (e exitStatus) Unwrap() error { return e.error }
+func (e exitStatus) ExitCode() int { return e.code }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/Gandalf-Le-Dev/quip/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureStdout returns what f prints on stdout
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- string(b)
+	}()
+	f()
+	w.Close()
+	return <-done
+}
+
+func TestOutput(t *testing.T) {
+	result := apiv1.FileUploaded{ID: "abc", Filename: "notes.txt", View: apiv1.ViewPath("abc"), ManageToken: "token"}
+	link := "https://quip.example" + apiv1.ViewPath("abc")
+
+	cli := &CLI{Server: "https://quip.example", Output: outputJSON}
+	out := captureStdout(t, func() { require.NoError(t, printFileUploaded(cli, result)) })
+	var printed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &printed))
+	assert.Equal(t, "abc", printed["id"])
+	assert.Equal(t, "token", printed["manage_token"])
+	assert.Equal(t, link, printed["url"])
+
+	cli = &CLI{Server: "https://quip.example", Quiet: true}
+	out = captureStdout(t, func() { require.NoError(t, printFileUploaded(cli, result)) })
+	assert.Equal(t, link+"\n", out, "-q only prints the URL")
+
+	cli = &CLI{Server: "https://quip.example"}
+	out = captureStdout(t, func() { require.NoError(t, printFileUploaded(cli, result)) })
+	assert.True(t, strings.HasPrefix(out, "📤 Uploaded: notes.txt\n"))
+}
+
+func TestExitCode(t *testing.T) {
+	apiErr := func(code string) error {
+		return fmt.Errorf("uploading: %w", &client.Error{StatusCode: http.StatusGone, Code: code})
+	}
+	for want, err := range map[int]error{
+		exitError:         fmt.Errorf("something else"),
+		exitNotFound:      apiErr(apiv1.CodeNotFound),
+		exitExpired:       apiErr(apiv1.CodeExpired),
+		exitLimitExceeded: apiErr(apiv1.CodeLimitExceeded),
+		exitTooLarge:      &client.Error{StatusCode: http.StatusRequestEntityTooLarge},
+		exitAuth:          &client.Error{StatusCode: http.StatusUnauthorized},
+		exitNetwork:       &url.Error{Op: "Post", URL: "http://localhost:1", Err: fmt.Errorf("connection refused")},
+		exitInterrupted:   &url.Error{Op: "Get", URL: "http://localhost:1", Err: context.Canceled},
+	} {
+		assert.Equal(t, want, exitCode(err), "%v", err)
+	}
+	assert.Equal(t, exitTooLarge, exitCode(&client.Error{StatusCode: http.StatusInsufficientStorage}), "a full server is out of room too")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+type PasteCmd struct {
+	Files    []string `arg:"" optional:"" name:"file" help:"Text files to paste, several make one multi-file paste (defaults to stdin)"`
+	Title    string   `help:"Title of the paste"`
+	Language string   `short:"l" predictor:"language" help:"Language for syntax highlighting (defaults to the profile's, or detected)"`
+	TTL      string   `short:"t" help:"Time to live, e.g. 1h, 7d or never (defaults to the profile's, then the server's)"`
+	Edit     bool     `short:"e" help:"Write the paste in your editor, starting from the file if one is given"`
+
+	FromClipboard bool `help:"Paste the content of the clipboard, or start from it with --edit"`
+}
+
+func (c *PasteCmd) Run(cli *CLI) error {
+	if err := cli.defaults().checkEncryption(); err != nil {
+		return err
+	}
+	c.TTL = orDefault(c.TTL, cli.defaults().TTL)
+	if len(c.Files) < 2 {
+		// Several files have their languages detected one by one
+		c.Language = orDefault(c.Language, cli.defaults().Language)
+	}
+	switch {
+	case c.FromClipboard && len(c.Files) > 0:
+		return fmt.Errorf("--from-clipboard takes no files")
+	case c.Edit:
+		if len(c.Files) > 1 {
+			return fmt.Errorf("--edit takes at most one file")
+		}
+		return c.createPasteWithEditor(cli)
+	case c.FromClipboard:
+		content, err := readClipboard()
+		if err != nil {
+			return err
+		}
+		return c.createPaste(cli, content)
+	case len(c.Files) > 1:
+		return c.createGist(cli)
+	case len(c.Files) == 1:
+		content, err := os.ReadFile(c.Files[0])
+		if err != nil {
+			return err
+		}
+		return c.createPaste(cli, string(content))
+	case stdinIsPiped():
+		return c.createPasteFromStdin(cli)
+	}
+	return fmt.Errorf("nothing to paste: give files, pipe text in or use --edit")
+}
+
+// readClipboard returns the text on the clipboard, which must not be empty
+func readClipboard() (string, error) {
+	content, err := clipboard.Paste()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("the clipboard is empty")
+	}
+	return content, nil
+}
+
+func (c *PasteCmd) createPasteFromStdin(cli *CLI) error {
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	return c.createPaste(cli, string(content))
+}
+
+func (c *PasteCmd) createPaste(cli *CLI, content string) error {
+	result, err := cli.client().CreatePaste(cli.context(), apiv1.CreatePasteRequest{
+		Content:  content,
+		Language: c.Language,
+		Title:    c.Title,
+		TTL:      c.TTL,
+	})
+	if err != nil {
+		return err
+	}
+
+	entry := historyEntry{
+		ID: result.ID, Kind: "paste", Name: c.Title,
+		View: cli.Server + result.View, Download: cli.Server + result.Raw,
+		ManageToken: result.ManageToken, ExpiresAt: result.ExpiresAt,
+	}
+	cli.remember(entry)
+
+	return cli.print(entry.View, result, func() {
+		fmt.Printf("📋 Created paste\n")
+		fmt.Printf("🔗 Raw: curl %s\n", entry.Download)
+		fmt.Printf("👀 View: %s\n", entry.View)
+		fmt.Printf("🔑 Manage token: %s\n", result.ManageToken)
+	})
+}
+
+// createGist shares text files as one multi-file paste, named after their
+// base names
+func (c *PasteCmd) createGist(cli *CLI) error {
+	if c.Language != "" {
+		return fmt.Errorf("--language does not apply to several files, languages are detected per file")
+	}
+
+	payload := apiv1.CreatePasteRequest{Title: c.Title, TTL: c.TTL}
+	for _, path := range c.Files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		payload.Files = append(payload.Files, apiv1.PasteFile{Name: filepath.Base(path), Content: string(content)})
+	}
+
+	result, err := cli.client().CreatePaste(cli.context(), payload)
+	if err != nil {
+		return err
+	}
+
+	entry := historyEntry{
+		ID: result.ID, Kind: "paste", Name: c.Title,
+		View: cli.Server + result.View, Download: cli.Server + apiv1.PasteZipPath(result.ID),
+		ManageToken: result.ManageToken, ExpiresAt: result.ExpiresAt,
+	}
+	cli.remember(entry)
+
+	return cli.print(entry.View, result, func() {
+		fmt.Printf("📋 Created paste with %d files\n", len(result.Files))
+		for _, f := range result.Files {
+			fmt.Printf("📄 %s (%s): curl %s%s\n", f.Name, f.Language, cli.Server, f.Raw)
+		}
+		fmt.Printf("📦 Zip: quip get %s\n", entry.View)
+		fmt.Printf("👀 View: %s\n", entry.View)
+		fmt.Printf("🔑 Manage token: %s\n", result.ManageToken)
+	})
+}
+
+// createPasteWithEditor writes a paste in the user's editor, starting from
+// the title, language and TTL given on the command line
+func (c *PasteCmd) createPasteWithEditor(cli *CLI) error {
+	initial := pasteDraft{Title: c.Title, Language: c.Language, TTL: c.TTL}
+	switch {
+	case c.FromClipboard:
+		content, err := readClipboard()
+		if err != nil {
+			return err
+		}
+		initial.Content = content
+	case len(c.Files) == 1:
+		content, err := os.ReadFile(c.Files[0])
+		if err != nil {
+			return err
+		}
+		initial.Content = string(content)
+	}
+	draft, err := editDraft(initial)
+	if err != nil {
+		return err
+	}
+	c.Title, c.Language, c.TTL = draft.Title, draft.Language, draft.TTL
+	if err := c.createPaste(cli, draft.Content); err != nil {
+		return keepDraft(draft, err)
+	}
+	return nil
+}
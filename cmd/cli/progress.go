@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+)
+
+// progress reports a transfer on a terminal as a single line redrawn at most
+// every tick, with a bar when the total is known, the rate and the time left
+type progress struct {
+	out   *termenv.Output // nil when not reporting
+	width func() int      // columns of the terminal
+	label string
+	total int64 // 0 when unknown
+	done  int64
+	last  time.Time
+
+	// The rate is measured from the first report, so that the part of a
+	// resumed download that was already there does not count
+	start time.Time
+	base  int64
+}
+
+const (
+	progressTick = 100 * time.Millisecond
+	// the rate and time left are only shown once they mean something
+	progressSettle = 500 * time.Millisecond
+	maxBarWidth    = 30
+	minBarWidth    = 10
+)
+
+// newProgress reports on stderr, if it is a terminal and -q is not given
+func (c *CLI) newProgress(label string, total int64) *progress {
+	p := &progress{label: label, total: total}
+	if !c.Quiet && term.IsTerminal(os.Stderr.Fd()) {
+		p.out = termenv.NewOutput(os.Stderr)
+		p.width = func() int {
+			w, _, err := term.GetSize(os.Stderr.Fd())
+			if err != nil || w <= 0 {
+				return 80
+			}
+			return w
+		}
+	}
+	return p
+}
+
+// report is a client.ProgressFunc, for transfers the client counts
+func (p *progress) report(done, total int64) {
+	now := time.Now()
+	if p.start.IsZero() || done < p.base {
+		// First report, or a retried upload starting over
+		p.start, p.base = now, done
+	}
+	p.done = done
+	if total > 0 {
+		p.total = total
+	}
+	if now.Sub(p.last) >= progressTick {
+		p.print(now)
+	}
+}
+
+func (p *progress) print(now time.Time) {
+	p.last = now
+	if p.out == nil {
+		return
+	}
+	fmt.Fprint(p.out, "\r")
+	p.out.ClearLine()
+	fmt.Fprint(p.out, p.render(now, p.width()))
+}
+
+// render formats the progress line to fit in width columns, e.g.
+//
+//	⬆️  Uploading report.pdf ███████░░░░░░░  48%  12.0 MiB / 25.0 MiB  4.1 MiB/s  ETA 3s
+func (p *progress) render(now time.Time, width int) string {
+	var stats []string
+	if p.total > 0 {
+		stats = append(stats, fmt.Sprintf("%3d%%", min(p.done*100/p.total, 100)), formatSize(p.done)+" / "+formatSize(p.total))
+	} else {
+		stats = append(stats, formatSize(p.done))
+	}
+	if rate := p.rate(now); rate > 0 {
+		stats = append(stats, formatSize(int64(rate))+"/s")
+		if p.total > p.done {
+			stats = append(stats, "ETA "+formatETA(time.Duration(float64(p.total-p.done)/rate*float64(time.Second))))
+		}
+	}
+	line := strings.Join(stats, "  ")
+
+	// The bar takes what is left of the line, within bounds
+	if p.total > 0 {
+		if n := min(width-termenv.String(p.label+"  "+line).Width()-2, maxBarWidth); n >= minBarWidth {
+			filled := int(min(p.done, p.total) * int64(n) / p.total)
+			bar := p.style(strings.Repeat("█", filled)).Foreground(termenv.ANSICyan).String() + p.style(strings.Repeat("░", n-filled)).Faint().String()
+			return p.label + " " + bar + " " + line
+		}
+	}
+	return p.label + "  " + line
+}
+
+// style styles s for the terminal, if there is one
+func (p *progress) style(s string) termenv.Style {
+	if p.out == nil {
+		return termenv.String(s)
+	}
+	return p.out.String(s)
+}
+
+// rate returns the bytes per second since the first report, or 0 until it
+// settles
+func (p *progress) rate(now time.Time) float64 {
+	elapsed := now.Sub(p.start)
+	if p.start.IsZero() || elapsed < progressSettle {
+		return 0
+	}
+	return float64(p.done-p.base) / elapsed.Seconds()
+}
+
+// finish draws the final count, with the average rate, and ends the line
+func (p *progress) finish() {
+	if p.out == nil {
+		return
+	}
+	now := time.Now()
+	fmt.Fprint(p.out, "\r")
+	p.out.ClearLine()
+	line := p.label + "  " + formatSize(p.done)
+	if rate := p.rate(now); rate > 0 {
+		line += fmt.Sprintf(" in %s (%s/s)", formatETA(now.Sub(p.start)), formatSize(int64(rate)))
+	}
+	fmt.Fprintln(p.out, line)
+}
+
+// formatETA formats a duration for a progress line, e.g. "1m05s" or "1h02m"
+func formatETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", d/time.Second)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", d/time.Minute, d%time.Minute/time.Second)
+	default:
+		return fmt.Sprintf("%dh%02dm", d/time.Hour, d%time.Hour/time.Minute)
+	}
+}
+
+// formatSize formats a byte count for humans, e.g. "1.5 MiB"
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
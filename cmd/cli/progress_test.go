@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressRender(t *testing.T) {
+	var out bytes.Buffer
+	p := &progress{out: termenv.NewOutput(&out, termenv.WithProfile(termenv.Ascii)), label: "upload", total: 100 << 20}
+	start := time.Now()
+	p.start, p.base = start, 10<<20
+	p.done = 10 << 20
+	assert.Equal(t, "upload   10%  10.0 MiB / 100.0 MiB", p.render(start, 30), "no bar when it does not fit, no rate yet")
+
+	p.done = 30 << 20
+	line := p.render(start.Add(10*time.Second), 120)
+	assert.Equal(t, "upload █████████░░░░░░░░░░░░░░░░░░░░░  30%  30.0 MiB / 100.0 MiB  2.0 MiB/s  ETA 35s", line, "the rate leaves out what was already there")
+
+	p.total = 0
+	assert.Equal(t, "upload  30.0 MiB  2.0 MiB/s", p.render(start.Add(10*time.Second), 120))
+}
+
+func TestFormatETA(t *testing.T) {
+	assert.Equal(t, "42s", formatETA(42*time.Second))
+	assert.Equal(t, "1m05s", formatETA(65*time.Second))
+	assert.Equal(t, "2h03m", formatETA(2*time.Hour+3*time.Minute+10*time.Second))
+}
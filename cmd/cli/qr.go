@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/qr"
+)
+
+// showQR prints link as a QR code with --qr: on stdout in the plain output
+// format, on stderr otherwise so that stdout stays parseable
+func (c *CLI) showQR(link string) {
+	if !c.QR || link == "" {
+		return
+	}
+	out := io.Writer(os.Stdout)
+	if c.format() != outputPlain {
+		out = os.Stderr
+	}
+	if err := writeQR(out, link); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not show a QR code: %v\n", err)
+	}
+}
+
+// writeQR draws text as a QR code with half blocks, two rows of modules per
+// line of text. Light modules are drawn and dark ones left blank, which
+// scans on the dark background of most terminals.
+func writeQR(w io.Writer, text string) error {
+	code, err := qr.Encode(text, qr.Medium)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for y := -qr.QuietZone; y < code.Size+qr.QuietZone; y += 2 {
+		for x := -qr.QuietZone; x < code.Size+qr.QuietZone; x++ {
+			top, bottom := !code.Dark(x, y), !code.Dark(x, y+1)
+			if y+1 >= code.Size+qr.QuietZone {
+				bottom = false // past the quiet zone of odd sized codes
+			}
+			switch {
+			case top && bottom:
+				b.WriteRune('█')
+			case top:
+				b.WriteRune('▀')
+			case bottom:
+				b.WriteRune('▄')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
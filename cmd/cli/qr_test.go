@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/qr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteQR(t *testing.T) {
+	const link = "https://quip.example/api/v1/view/V1StGXR8_Z5jdHi6B-myT"
+	var b bytes.Buffer
+	require.NoError(t, writeQR(&b, link))
+	code, err := qr.Encode(link, qr.Medium)
+	require.NoError(t, err)
+
+	// Read the half blocks back as modules
+	side := code.Size + 2*qr.QuietZone
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	require.Len(t, lines, (side+1)/2)
+	for i, line := range lines {
+		runes := []rune(line)
+		require.Len(t, runes, side)
+		for j, r := range runes {
+			x, y := j-qr.QuietZone, 2*i-qr.QuietZone
+			top, bottom := strings.ContainsRune("█▀", r), strings.ContainsRune("█▄", r)
+			assert.Equal(t, !code.Dark(x, y), top, "module %d,%d", x, y)
+			if y+1 < code.Size+qr.QuietZone {
+				assert.Equal(t, !code.Dark(x, y+1), bottom, "module %d,%d", x, y+1)
+			}
+		}
+	}
+}
+
+func TestShowQR(t *testing.T) {
+	cli := &CLI{QR: true, Output: outputJSON}
+	out := captureStdout(t, func() {
+		require.NoError(t, cli.print("https://quip.example/api/v1/view/abc", map[string]string{"id": "abc"}, nil))
+	})
+	assert.NotContains(t, out, "█", "the code goes to stderr when stdout is parsed")
+
+	cli.Output = outputPlain
+	out = captureStdout(t, func() {
+		require.NoError(t, cli.print("https://quip.example/api/v1/view/abc", nil, func() {}))
+	})
+	assert.Contains(t, out, "█")
+}
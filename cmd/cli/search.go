@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/muesli/termenv"
+)
+
+type SearchCmd struct {
+	Query []string `arg:"" help:"Words to look for in paste titles and content; quote phrases"`
+	Limit int      `short:"n" default:"20" help:"Maximum number of results"`
+}
+
+func (c *SearchCmd) Run(cli *CLI) error {
+	if cli.Token == "" {
+		return fmt.Errorf("search needs an API key, pass --token or set QUIP_TOKEN")
+	}
+
+	results, err := cli.client().SearchPastes(cli.context(), strings.Join(c.Query, " "), c.Limit)
+	if err != nil {
+		return err
+	}
+
+	switch cli.format() {
+	case outputJSON:
+		return printJSON(results, "")
+	case outputURL:
+		for _, r := range results.Results {
+			fmt.Println(cli.Server + r.View)
+		}
+		return nil
+	}
+	if len(results.Results) == 0 {
+		fmt.Fprintln(os.Stderr, "No paste matches")
+		return nil
+	}
+	printSearchResults(termenv.NewOutput(os.Stdout), cli.Server, results.Results)
+	return nil
+}
+
+// printSearchResults lists results with their matches in bold, when the
+// output supports it
+func printSearchResults(out *termenv.Output, server string, results []apiv1.SearchResult) {
+	for i, r := range results {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		title := r.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Fprintf(out, "%s  %s  %s%s\n", out.String(title).Bold(), r.Language, server, r.View)
+		for _, line := range strings.Split(highlight(out, r.Snippet, r.Highlights), "\n") {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+}
+
+func highlight(out *termenv.Output, snippet string, highlights [][2]int) string {
+	var b strings.Builder
+	last := 0
+	for _, h := range highlights {
+		start, end := h[0], h[1]
+		if start < last || end > len(snippet) || start > end {
+			continue
+		}
+		b.WriteString(snippet[last:start])
+		b.WriteString(out.String(snippet[start:end]).Bold().Underline().String())
+		last = end
+	}
+	b.WriteString(snippet[last:])
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	snippet := "location / { proxy_pass http://app; }"
+	spans := [][2]int{{13, 23}, {5, 3}, {30, 99}}
+
+	plain := termenv.NewOutput(&bytes.Buffer{}, termenv.WithProfile(termenv.Ascii))
+	assert.Equal(t, snippet, highlight(plain, snippet, spans), "no styling without a terminal, bad spans ignored")
+
+	ansi := termenv.NewOutput(&bytes.Buffer{}, termenv.WithProfile(termenv.ANSI))
+	assert.Equal(t, "location / { \x1b[1;4mproxy_pass\x1b[0m http://app; }", highlight(ansi, snippet, spans))
+}
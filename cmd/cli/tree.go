@@ -0,0 +1,250 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// treeEntry is a directory, file or symlink of a tree being shared
+type treeEntry struct {
+	Path    string      // on disk
+	Name    string      // slash-separated, starting with the tree's own name
+	Mode    fs.FileMode // type and permission bits only, setuid and the like are dropped
+	Size    int64
+	ModTime time.Time
+	Link    string // target of a symlink kept as such
+}
+
+// skippedEntry is something in a tree that is not shared, and why
+type skippedEntry struct {
+	Name   string
+	Reason string
+}
+
+type walkOptions struct {
+	// FollowSymlinks shares the files symlinks point to instead of the links
+	FollowSymlinks bool
+	// NoIgnore includes what ignore files exclude
+	NoIgnore bool
+	// Exclude adds ignore patterns, as if from an ignore file at the root
+	Exclude []string
+}
+
+// tree is what walkTree found in a directory
+type tree struct {
+	Entries []treeEntry
+	Skipped []skippedEntry
+	Ignored int // entries excluded by ignore patterns
+}
+
+// Files returns the regular files of the tree
+func (t *tree) Files() []treeEntry {
+	var files []treeEntry
+	for _, e := range t.Entries {
+		if e.Mode.IsRegular() {
+			files = append(files, e)
+		}
+	}
+	return files
+}
+
+// Size returns the size of the regular files of the tree
+func (t *tree) Size() int64 {
+	var size int64
+	for _, e := range t.Files() {
+		size += e.Size
+	}
+	return size
+}
+
+// walkTree lists what to share of the directory root. Symlinks are kept as
+// links unless followed, symlinks to directories are never followed, and
+// devices, sockets and pipes are skipped. Unreadable files are an error
+// rather than silently left out.
+func walkTree(root string, opts walkOptions) (*tree, error) {
+	root = filepath.Clean(root)
+	top := filepath.Base(root)
+	if abs, err := filepath.Abs(root); err == nil {
+		top = filepath.Base(abs)
+	}
+
+	t := &tree{}
+	ignore := &ignoreMatcher{}
+	ignore.add("", opts.Exclude)
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		name := path.Join(top, rel)
+
+		if rel != "." {
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if !opts.NoIgnore && ignore.ignored(rel, d.IsDir()) {
+				t.Ignored++
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := treeEntry{Path: p, Name: name, Mode: info.Mode() & (fs.ModeType | fs.ModePerm), ModTime: info.ModTime()}
+
+		switch {
+		case d.IsDir():
+			if !opts.NoIgnore {
+				dir := rel
+				if dir == "." {
+					dir = ""
+				}
+				if err := ignore.load(root, dir); err != nil {
+					return err
+				}
+			}
+
+		case d.Type()&fs.ModeSymlink != 0:
+			if !opts.FollowSymlinks {
+				if entry.Link, err = os.Readlink(p); err != nil {
+					return err
+				}
+				break
+			}
+			target, err := os.Stat(p)
+			switch {
+			case err != nil:
+				t.Skipped = append(t.Skipped, skippedEntry{name, "broken symlink"})
+				return nil
+			case target.IsDir():
+				t.Skipped = append(t.Skipped, skippedEntry{name, "symlink to a directory, not followed"})
+				return nil
+			case !target.Mode().IsRegular():
+				t.Skipped = append(t.Skipped, skippedEntry{name, "symlink to something other than a file"})
+				return nil
+			}
+			entry.Mode, entry.Size, entry.ModTime = target.Mode().Perm(), target.Size(), target.ModTime()
+			if err := checkReadable(p); err != nil {
+				return err
+			}
+
+		case d.Type().IsRegular():
+			entry.Size = info.Size()
+			if err := checkReadable(p); err != nil {
+				return err
+			}
+
+		default:
+			t.Skipped = append(t.Skipped, skippedEntry{name, "not a regular file"})
+			return nil
+		}
+
+		t.Entries = append(t.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func checkReadable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return fmt.Errorf("%w, exclude it with --exclude to share the rest", err)
+		}
+		return err
+	}
+	return f.Close()
+}
+
+// writeTarGz writes the tree as a gzipped tarball, keeping permissions and
+// symlinks
+func writeTarGz(w io.Writer, t *tree) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, e := range t.Entries {
+		header := &tar.Header{Name: e.Name, Mode: int64(e.Mode.Perm()), ModTime: e.ModTime}
+		switch {
+		case e.Mode.IsDir():
+			header.Typeflag, header.Name = tar.TypeDir, e.Name+"/"
+		case e.Link != "":
+			header.Typeflag, header.Linkname = tar.TypeSymlink, e.Link
+		default:
+			header.Typeflag, header.Size = tar.TypeReg, e.Size
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeReg {
+			if err := copyFile(tw, e.Path); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeZip writes the tree as a zip archive, keeping permissions and
+// symlinks as unzip understands them
+func writeZip(w io.Writer, t *tree) error {
+	zw := zip.NewWriter(w)
+	for _, e := range t.Entries {
+		header := &zip.FileHeader{Name: e.Name, Method: zip.Deflate, Modified: e.ModTime}
+		header.SetMode(e.Mode)
+		switch {
+		case e.Mode.IsDir():
+			header.Name, header.Method = e.Name+"/", zip.Store
+		case e.Link != "":
+			header.Method = zip.Store
+		}
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		switch {
+		case e.Link != "":
+			// A zip symlink holds its target as content
+			_, err = io.WriteString(fw, e.Link)
+		case e.Mode.IsRegular():
+			err = copyFile(fw, e.Path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
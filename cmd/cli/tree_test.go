@@ -0,0 +1,112 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	m := &ignoreMatcher{}
+	m.add("", []string{"# comment", "*.log", "!keep.log", "build/", "/secret.txt", "docs/**/*.tmp"})
+	m.add("sub", []string{"local"})
+
+	for _, c := range []struct {
+		rel     string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"deep/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"secret.txt", false, true},
+		{"sub/secret.txt", false, false},
+		{"docs/a/b/x.tmp", false, true},
+		{"docs/x.tmp", false, true},
+		{"sub/local", false, true},
+		{"local", false, false},
+	} {
+		assert.Equal(t, c.ignored, m.ignored(c.rel, c.isDir), c.rel)
+	}
+}
+
+func TestWalkTree(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "project")
+	write := func(name, content string, mode os.FileMode) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), mode))
+		require.NoError(t, os.Chmod(path, mode)) // whatever the umask
+	}
+	write(".gitignore", "*.log\nvendor/\n", 0o644)
+	write("main.go", "package main", 0o644)
+	write("run.sh", "#!/bin/sh", 0o755)
+	write("debug.log", "noise", 0o644)
+	write("vendor/dep.go", "package dep", 0o644)
+	write("src/.quipignore", "generated.go\n", 0o644)
+	write("src/generated.go", "package src", 0o644)
+	write("src/lib.go", "package src", 0o644)
+	write(".git/HEAD", "ref: refs/heads/main", 0o644)
+	require.NoError(t, os.Symlink("main.go", filepath.Join(root, "link.go")))
+	require.NoError(t, os.Symlink("src", filepath.Join(root, "srclink")))
+
+	names := func(tr *tree) map[string]string {
+		out := map[string]string{}
+		for _, e := range tr.Entries {
+			out[e.Name] = e.Mode.String()
+		}
+		return out
+	}
+
+	tr, err := walkTree(root, walkOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"project":                 "drwxr-xr-x",
+		"project/.gitignore":      "-rw-r--r--",
+		"project/link.go":         "Lrwxrwxrwx",
+		"project/main.go":         "-rw-r--r--",
+		"project/run.sh":          "-rwxr-xr-x",
+		"project/src":             "drwxr-xr-x",
+		"project/src/.quipignore": "-rw-r--r--",
+		"project/src/lib.go":      "-rw-r--r--",
+		"project/srclink":         "Lrwxrwxrwx",
+	}, names(tr))
+	assert.Equal(t, 3, tr.Ignored)
+
+	tr, err = walkTree(root, walkOptions{FollowSymlinks: true, Exclude: []string{"*.sh"}})
+	require.NoError(t, err)
+	assert.Equal(t, "-rw-r--r--", names(tr)["project/link.go"], "followed to main.go")
+	assert.NotContains(t, names(tr), "project/run.sh")
+	assert.Equal(t, []skippedEntry{{"project/srclink", "symlink to a directory, not followed"}}, tr.Skipped)
+
+	// The tarball keeps permissions and symlinks
+	var b bytes.Buffer
+	tr, err = walkTree(root, walkOptions{})
+	require.NoError(t, err)
+	require.NoError(t, writeTarGz(&b, tr))
+	gz, err := gzip.NewReader(&b)
+	require.NoError(t, err)
+	headers := map[string]*tar.Header{}
+	r := tar.NewReader(gz)
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		headers[h.Name] = h
+	}
+	assert.Equal(t, int64(0o755), headers["project/run.sh"].Mode)
+	assert.Equal(t, byte(tar.TypeSymlink), headers["project/link.go"].Typeflag)
+	assert.Equal(t, "main.go", headers["project/link.go"].Linkname)
+	assert.Equal(t, byte(tar.TypeDir), headers["project/src/"].Typeflag)
+}
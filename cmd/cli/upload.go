@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/Gandalf-Le-Dev/quip/pkg/client"
+)
+
+type UpCmd struct {
+	Files        []string `arg:"" optional:"" name:"file" help:"Files to share, several are shared as one bundle, or a directory"`
+	Title        string   `help:"Title of the bundle, or of the paste read from stdin"`
+	TTL          string   `short:"t" help:"Time to live, e.g. 1h, 7d or never (defaults to the profile's, then the server's)"`
+	MaxDownloads int      `help:"Number of downloads after which a bundle stops being served (0 for unlimited)"`
+
+	As             string   `enum:"tar.gz,zip,bundle" default:"tar.gz" help:"How to share a directory: as a tar.gz or zip archive, or as a bundle of its files"`
+	Exclude        []string `short:"x" help:"Patterns to leave out of a directory, in .gitignore syntax"`
+	NoIgnore       bool     `help:"Include what .gitignore and .quipignore files exclude"`
+	FollowSymlinks bool     `short:"L" help:"Share the files symlinks point to rather than the links"`
+}
+
+func (c *UpCmd) Run(cli *CLI) error {
+	if err := cli.defaults().checkEncryption(); err != nil {
+		return err
+	}
+	c.TTL = orDefault(c.TTL, cli.defaults().TTL)
+	if len(c.Files) == 0 {
+		if !stdinIsPiped() {
+			return fmt.Errorf("no input provided")
+		}
+		// Handle piped input as paste
+		paste := &PasteCmd{Title: c.Title, TTL: c.TTL}
+		return paste.Run(cli)
+	}
+
+	for _, path := range c.Files {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("file not found: %s", path)
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if len(c.Files) > 1 {
+				return fmt.Errorf("%s is a directory, directories are shared one at a time", path)
+			}
+			return c.uploadDir(cli, path)
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", path)
+		}
+	}
+
+	if len(c.Files) > 1 {
+		return c.uploadBundle(cli)
+	}
+	return c.uploadFile(cli, c.Files[0])
+}
+
+func (c *UpCmd) uploadFile(cli *CLI, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(path)
+	bar := cli.newProgress("⬆️  Uploading "+name, info.Size())
+	result, err := cli.client().UploadFile(cli.context(), client.Upload{Name: name, Body: file, Size: info.Size()}, client.FileOptions{
+		TTL:      c.TTL,
+		Progress: bar.report,
+	})
+	bar.finish()
+	if err != nil {
+		return err
+	}
+
+	return printFileUploaded(cli, *result)
+}
+
+func printFileUploaded(cli *CLI, result apiv1.FileUploaded) error {
+	entry := historyEntry{
+		ID: result.ID, Kind: "file", Name: result.Filename,
+		View: cli.Server + result.View, Download: cli.Server + result.Download,
+		ManageToken: result.ManageToken, ExpiresAt: result.ExpiresAt,
+	}
+	cli.remember(entry)
+	return cli.print(entry.View, result, func() {
+		fmt.Printf("📤 Uploaded: %s\n", result.Filename)
+		fmt.Printf("🔗 Download: quip get %s\n", entry.View)
+		fmt.Printf("👀 View: %s\n", entry.View)
+		fmt.Printf("🔑 Manage token: %s\n", result.ManageToken)
+	})
+}
+
+// uploadBundle shares files as one bundle behind a single link
+func (c *UpCmd) uploadBundle(cli *CLI) error {
+	var uploads []client.Upload
+	var size int64
+	for _, path := range c.Files {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		uploads = append(uploads, client.Upload{Name: filepath.Base(path), Body: file, Size: info.Size()})
+		size += info.Size()
+	}
+
+	bar := cli.newProgress(fmt.Sprintf("⬆️  Uploading %d files", len(uploads)), size)
+	result, err := cli.client().UploadBundle(cli.context(), uploads, client.BundleOptions{
+		TTL:          c.TTL,
+		Title:        c.Title,
+		MaxDownloads: c.MaxDownloads,
+		Progress:     bar.report,
+	})
+	bar.finish()
+	if err != nil {
+		return err
+	}
+
+	return printBundle(cli, *result)
+}
+
+func printBundle(cli *CLI, result apiv1.Bundle) error {
+	entry := historyEntry{
+		ID: result.ID, Kind: "bundle", Name: result.Title,
+		View: cli.Server + result.View, Download: cli.Server + apiv1.BundleZipPath(result.ID),
+		ManageToken: result.ManageToken, ExpiresAt: result.ExpiresAt,
+	}
+	cli.remember(entry)
+	return cli.print(entry.View, result, func() {
+		fmt.Printf("📤 Uploaded bundle of %d files (%d bytes)\n", len(result.Files), result.Size)
+		for _, f := range result.Files {
+			fmt.Printf("📄 %s: quip get %s%s\n", f.Filename, cli.Server, f.Download)
+		}
+		fmt.Printf("📦 Download: quip get %s\n", entry.View)
+		fmt.Printf("👀 View: %s\n", entry.View)
+		fmt.Printf("🔑 Manage token: %s\n", result.ManageToken)
+	})
+}
+
+// stdinIsPiped reports whether stdin is a pipe or a file rather than a
+// terminal
+func stdinIsPiped() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice == 0
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+)
+
+type ConfigCmd struct {
+	Print ConfigPrintCmd `cmd:"" help:"Print the effective configuration with secrets redacted."`
+}
+
+type ConfigPrintCmd struct {
+	Format string `enum:"yaml,toml" default:"yaml" help:"Output format (yaml, toml)."`
+}
+
+func (c *ConfigPrintCmd) Run(cli *CLI) error {
+	cfg, err := cli.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Print(os.Stdout, c.Format); err != nil {
+		return err
+	}
+
+	// Still show the configuration when it is invalid, then report why
+	return cfg.Validate()
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/adapters/api"
+	"github.com/Gandalf-Le-Dev/quip/internal/adapters/repository/postgres"
+	"github.com/Gandalf-Le-Dev/quip/internal/adapters/repository/storage/minio"
+	"github.com/Gandalf-Le-Dev/quip/internal/config"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/logger"
+)
+
+type ServeCmd struct{}
+
+func (s *ServeCmd) Run(cli *CLI) error {
+	// The root context is cancelled on SIGINT/SIGTERM, which stops the HTTP
+	// server and every background job. A second signal kills the process.
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(sigCtx, stop)
+	ctx, cancel := context.WithCancel(sigCtx)
+	defer cancel()
+
+	// Load configuration
+	cfg, err := cli.loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	// Initialize logger
+	log := newLogger(cfg.Log)
+
+	// Connect to database and run migrations
+	db, err := openDB(ctx, cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	log.Info("Connected to the database and applied migrations")
+
+	// Initialize repositories
+	fileRepo := postgres.NewRepository(db)
+	pasteRepo := postgres.NewPasteRepository(db)
+	userRepo := postgres.NewUserRepository(db)
+	usageRepo := postgres.NewUsageRepository(db)
+
+	// Initialize storage
+	minioCfg := cfg.Storage.Minio
+	storage, err := minio.NewMinioStorage(
+		minioCfg.Endpoint,
+		minioCfg.AccessKey,
+		minioCfg.SecretKey,
+		minioCfg.Bucket,
+		minioCfg.UseSSL,
+		log,
+	)
+	if err != nil {
+		return fmt.Errorf("initializing storage: %w", err)
+	}
+	log.Info("Object storage initialized successfully")
+
+	// Initialize services
+	quotaService := services.NewQuotaService(usageRepo, cfg.Quota.Policy(), log)
+	fileService := services.NewFileService(fileRepo, fileRepo, storage, quotaService, log)
+	pasteService := services.NewPasteService(pasteRepo, quotaService, log)
+	authService := services.NewAuthService(userRepo, log)
+
+	// Start cleanup goroutine
+	var jobs sync.WaitGroup
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		startCleanupTask(ctx, log, cfg.Cleanup.Interval, fileService, pasteService)
+	}()
+
+	// Initialize HTTP handlers
+	handlers := api.NewHandlers(fileService, pasteService, authService, quotaService, log, api.Options{
+		MaxFileSize:    cfg.Limits.MaxFileSize.Bytes(),
+		MaxPasteSize:   cfg.Limits.MaxPasteSize.Bytes(),
+		MaxBundleSize:  cfg.Limits.MaxBundleSize.Bytes(),
+		TTL:            cfg.TTL.Policy(),
+		CORSOrigins:    cfg.CORS.AllowedOrigins,
+		AllowAnonymous: cfg.Auth.AllowAnonymous,
+		PublicURL:      cfg.Server.PublicURL,
+	})
+	router := api.NewRouter(handlers)
+
+	// Start server. Requests deliberately do not derive from ctx: a shutdown
+	// lets in-flight uploads and downloads finish instead of cancelling them.
+	srv := newHTTPServer(cfg.Server, cfg.Server.Listen, router, log)
+	servers := []*http.Server{srv}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, reloader, err := newTLSConfig(cfg.TLS, log)
+		if err != nil {
+			return fmt.Errorf("initializing TLS: %w", err)
+		}
+		srv.TLSConfig = tlsConfig
+
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			reloader.Watch(ctx, cfg.TLS.ReloadInterval)
+		}()
+
+		if cfg.TLS.RedirectListen != "" {
+			servers = append(servers, newHTTPServer(cfg.Server, cfg.TLS.RedirectListen, redirectToHTTPS(cfg.Server.Listen), log))
+		}
+	}
+
+	err = listenAndServe(ctx, log, cfg.Server.ShutdownTimeout, servers...)
+
+	// Stop background jobs before the deferred db.Close
+	cancel()
+	jobs.Wait()
+	log.Info("Background jobs stopped, closing database")
+	return err
+}
+
+func newHTTPServer(cfg config.ServerConfig, addr string, handler http.Handler, log *slog.Logger) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(log.Handler(), slog.LevelWarn),
+	}
+}
+
+// listenAndServe runs the servers until one fails or ctx is cancelled, then
+// gives in-flight requests until the shutdown timeout to complete. Servers
+// with a TLSConfig serve HTTPS.
+func listenAndServe(ctx context.Context, log *slog.Logger, shutdownTimeout time.Duration, servers ...*http.Server) error {
+	serveErr := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() {
+			log.Info("Server starting", "address", srv.Addr, "tls", srv.TLSConfig != nil)
+			if srv.TLSConfig != nil {
+				serveErr <- srv.ListenAndServeTLS("", "")
+			} else {
+				serveErr <- srv.ListenAndServe()
+			}
+		}()
+	}
+
+	var failure error
+	running := len(servers)
+	select {
+	case failure = <-serveErr:
+		running--
+	case <-ctx.Done():
+	}
+
+	log.Info("Shutting down, draining connections", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Warn("Connections still open after shutdown timeout, closing them", "address", srv.Addr, "error", err)
+			srv.Close()
+		}
+	}
+
+	for range running {
+		if err := <-serveErr; failure == nil && !errors.Is(err, http.ErrServerClosed) {
+			failure = err
+		}
+	}
+	if failure != nil {
+		return fmt.Errorf("server failed: %w", failure)
+	}
+	log.Info("Server stopped")
+	return nil
+}
+
+func newLogger(cfg config.LogConfig) *slog.Logger {
+	return logger.NewLogger(logger.Config{
+		Level:     logger.LogLevel(cfg.Level),
+		Format:    cfg.Format,
+		Output:    cfg.Output,
+		File:      cfg.File,
+		Timestamp: true,
+		Caller:    cfg.Caller,
+		Colors:    cfg.Colors,
+	})
+}
+
+func startCleanupTask(ctx context.Context, log *slog.Logger, interval time.Duration, fileService *services.FileService, pasteService *services.PasteService) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		log.Info("Running cleanup task for expired content")
+
+		if err := fileService.CleanupExpired(ctx); err != nil {
+			log.Error("Error cleaning up expired files", "error", err)
+		}
+
+		if err := pasteService.CleanupExpired(ctx); err != nil {
+			log.Error("Error cleaning up expired pastes", "error", err)
+		}
+		log.Info("Cleanup task finished")
+	}
+}
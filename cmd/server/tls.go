@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/config"
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/certreload"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newTLSConfig loads the certificate into a reloader and builds the listener
+// configuration around it, advertising HTTP/2
+func newTLSConfig(cfg config.TLSConfig, log *slog.Logger) (*tls.Config, *certreload.Reloader, error) {
+	reloader, err := certreload.New(cfg.CertFile, cfg.KeyFile, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:     tlsVersions[cfg.MinVersion],
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}, reloader, nil
+}
+
+// redirectToHTTPS sends plain HTTP clients to the same URL on the HTTPS listener
+func redirectToHTTPS(httpsListen string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsListen)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			// No port, but IPv6 addresses still come bracketed
+			host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		target := url.URL{Scheme: "https", Host: host, Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+		http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectToHTTPS(t *testing.T) {
+	for _, tc := range []struct {
+		listen, host, target, want string
+	}{
+		{":443", "quip.lan", "/api/v1/view/abc?x=1", "https://quip.lan/api/v1/view/abc?x=1"},
+		{":443", "quip.lan:80", "/", "https://quip.lan/"},
+		{":8443", "quip.lan:8080", "/health", "https://quip.lan:8443/health"},
+		{":443", "[::1]:80", "/", "https://[::1]/"},
+		{":443", "[::1]", "/", "https://[::1]/"},
+		{":8443", "[::1]", "/", "https://[::1]:8443/"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		req.Host = tc.host
+		rec := httptest.NewRecorder()
+
+		redirectToHTTPS(tc.listen).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+		assert.Equal(t, tc.want, rec.Header().Get("Location"))
+	}
+}
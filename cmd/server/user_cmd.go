@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/adapters/repository/postgres"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+)
+
+type UserCmd struct {
+	Create UserCreateCmd `cmd:"" help:"Create a user."`
+	List   UserListCmd   `cmd:"" help:"List users."`
+}
+
+type UserCreateCmd struct {
+	Name string `arg:"" help:"User name."`
+}
+
+func (c *UserCreateCmd) Run(cli *CLI) error {
+	return cli.withAuthService(func(ctx context.Context, auth *services.AuthService) error {
+		user, err := auth.CreateUser(ctx, c.Name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created user %s (%s)\n", user.Name, user.ID)
+		return nil
+	})
+}
+
+type UserListCmd struct{}
+
+func (c *UserListCmd) Run(cli *CLI) error {
+	return cli.withAuthService(func(ctx context.Context, auth *services.AuthService) error {
+		users, err := auth.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tCREATED")
+		for _, u := range users {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", u.ID, u.Name, u.CreatedAt.Format(time.DateTime))
+		}
+		return w.Flush()
+	})
+}
+
+type KeyCmd struct {
+	Create KeyCreateCmd `cmd:"" help:"Create an API key for a user."`
+	List   KeyListCmd   `cmd:"" help:"List a user's API keys."`
+	Revoke KeyRevokeCmd `cmd:"" help:"Revoke an API key."`
+}
+
+type KeyCreateCmd struct {
+	User   string   `arg:"" help:"Name of the user the key authenticates."`
+	Name   string   `short:"n" default:"default" help:"Label telling the user's keys apart."`
+	Scopes []string `short:"s" default:"read,write" help:"Scopes granted to the key (read, write, admin)."`
+}
+
+func (c *KeyCreateCmd) Run(cli *CLI) error {
+	scopes, err := domain.ParseScopes(c.Scopes)
+	if err != nil {
+		return err
+	}
+
+	return cli.withAuthService(func(ctx context.Context, auth *services.AuthService) error {
+		key, plaintext, err := auth.CreateAPIKey(ctx, c.User, c.Name, scopes)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Created key %s for %s. It is shown only once:\n", key.ID, c.User)
+		fmt.Println(plaintext)
+		return nil
+	})
+}
+
+type KeyListCmd struct {
+	User string `arg:"" help:"User name."`
+}
+
+func (c *KeyListCmd) Run(cli *CLI) error {
+	return cli.withAuthService(func(ctx context.Context, auth *services.AuthService) error {
+		keys, err := auth.ListAPIKeys(ctx, c.User)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tSCOPES\tCREATED\tLAST USED\tSTATUS")
+		for _, k := range keys {
+			scopes := make([]string, len(k.Scopes))
+			for i, s := range k.Scopes {
+				scopes[i] = string(s)
+			}
+			lastUsed, status := "never", "active"
+			if !k.LastUsedAt.IsZero() {
+				lastUsed = k.LastUsedAt.Format(time.DateTime)
+			}
+			if k.IsRevoked() {
+				status = "revoked"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, strings.Join(scopes, ","), k.CreatedAt.Format(time.DateTime), lastUsed, status)
+		}
+		return w.Flush()
+	})
+}
+
+type KeyRevokeCmd struct {
+	ID string `arg:"" help:"Key ID, as shown by 'key list'."`
+}
+
+func (c *KeyRevokeCmd) Run(cli *CLI) error {
+	return cli.withAuthService(func(ctx context.Context, auth *services.AuthService) error {
+		if err := auth.RevokeAPIKey(ctx, c.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked key %s\n", c.ID)
+		return nil
+	})
+}
+
+// withAuthService connects to the configured database for an administrative
+// command. Only warnings are logged so they do not mix with the output.
+func (c *CLI) withAuthService(run func(ctx context.Context, auth *services.AuthService) error) error {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	db, err := openDB(ctx, cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	return run(ctx, services.NewAuthService(postgres.NewUserRepository(db), log))
+}
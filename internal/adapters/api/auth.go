@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+// access says who may call a route
+type access int
+
+const (
+	// accessPublic routes are open to anyone, like the links people share
+	accessPublic access = iota
+	// accessCreate routes need an API key with the write scope, unless
+	// anonymous uploads are allowed
+	accessCreate
+	// accessManage routes are open to anyone, the service then checks for
+	// ownership or a manage token. API keys need the write scope.
+	accessManage
+	// accessPrivate routes need an API key
+	accessPrivate
+)
+
+type principalKey struct{}
+
+// principalFrom returns the principal attached by authenticate, or nil for
+// anonymous requests
+func principalFrom(ctx context.Context) *domain.Principal {
+	p, _ := ctx.Value(principalKey{}).(*domain.Principal)
+	return p
+}
+
+// isAuthenticated reports whether the request carries a verified identity
+func isAuthenticated(r *http.Request) bool {
+	return principalFrom(r.Context()) != nil
+}
+
+// ownerID is the user new content from this request belongs to, empty when anonymous
+func ownerID(r *http.Request) string {
+	if p := principalFrom(r.Context()); p != nil {
+		return p.UserID
+	}
+	return ""
+}
+
+// apiKey extracts the API key from the Authorization or X-API-Key header
+func apiKey(r *http.Request) string {
+	if scheme, key, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(key)
+	}
+	return r.Header.Get(apiv1.HeaderAPIKey)
+}
+
+// authenticate attaches the principal behind the request's API key to its
+// context. Requests without a key go through anonymously; requests with a
+// bad one are rejected rather than silently downgraded.
+func authenticate(auth *services.AuthService, log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := apiKey(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := auth.Authenticate(r.Context(), key)
+		if err != nil {
+			writeError(w, log.With("remote_addr", r.RemoteAddr), err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// authorize enforces a route's access level before calling its handler
+func (h *Handlers) authorize(a access, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := principalFrom(r.Context())
+
+		var err error
+		switch {
+		case a == accessCreate && p == nil && !h.opts.AllowAnonymous:
+			err = fmt.Errorf("%w: anonymous uploads are disabled on this server", domain.ErrUnauthorized)
+		case a == accessPrivate && p == nil:
+			err = domain.ErrUnauthorized
+		case (a == accessCreate || a == accessManage) && p != nil && !p.Allows(domain.ScopeWrite):
+			err = fmt.Errorf("%w: API key lacks the write scope", domain.ErrForbidden)
+		case p != nil && !p.Allows(domain.ScopeRead):
+			err = fmt.Errorf("%w: API key lacks the read scope", domain.ErrForbidden)
+		}
+		if err != nil {
+			writeError(w, h.log.With("remote_addr", r.RemoteAddr), err)
+			return
+		}
+		next(w, r)
+	}
+}
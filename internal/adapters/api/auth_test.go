@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUsers is an in-memory ports.UserRepository
+type memoryUsers struct {
+	users map[string]*domain.User
+	keys  map[string]*domain.APIKey
+}
+
+func newMemoryUsers() *memoryUsers {
+	return &memoryUsers{users: map[string]*domain.User{}, keys: map[string]*domain.APIKey{}}
+}
+
+func (m *memoryUsers) StoreUser(_ context.Context, u *domain.User) error {
+	m.users[u.ID] = u
+	return nil
+}
+
+func (m *memoryUsers) FindUserByID(_ context.Context, id string) (*domain.User, error) {
+	if u, ok := m.users[id]; ok {
+		return u, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *memoryUsers) FindUserByName(_ context.Context, name string) (*domain.User, error) {
+	for _, u := range m.users {
+		if u.Name == name {
+			return u, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *memoryUsers) ListUsers(context.Context) ([]*domain.User, error) {
+	return nil, nil
+}
+
+func (m *memoryUsers) StoreAPIKey(_ context.Context, k *domain.APIKey) error {
+	m.keys[k.ID] = k
+	return nil
+}
+
+func (m *memoryUsers) FindAPIKeyByID(_ context.Context, id string) (*domain.APIKey, error) {
+	if k, ok := m.keys[id]; ok {
+		return k, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *memoryUsers) ListAPIKeys(context.Context, string) ([]*domain.APIKey, error) {
+	return nil, nil
+}
+
+func (m *memoryUsers) TouchAPIKey(_ context.Context, id string) error {
+	m.keys[id].LastUsedAt = time.Now()
+	return nil
+}
+
+func (m *memoryUsers) RevokeAPIKey(_ context.Context, id string) error {
+	m.keys[id].RevokedAt = time.Now()
+	return nil
+}
+
+func TestAuthentication(t *testing.T) {
+	ctx := context.Background()
+	auth := services.NewAuthService(newMemoryUsers(), testLog)
+	_, err := auth.CreateUser(ctx, "alice")
+	require.NoError(t, err)
+	_, writer, err := auth.CreateAPIKey(ctx, "alice", "ci", []domain.Scope{domain.ScopeWrite})
+	require.NoError(t, err)
+	_, reader, err := auth.CreateAPIKey(ctx, "alice", "dashboard", []domain.Scope{domain.ScopeRead})
+	require.NoError(t, err)
+	revokedKey, revoked, err := auth.CreateAPIKey(ctx, "alice", "old", []domain.Scope{domain.ScopeWrite})
+	require.NoError(t, err)
+	require.NoError(t, auth.RevokeAPIKey(ctx, revokedKey.ID))
+
+	opts := testOptions()
+	opts.AllowAnonymous = false
+	router := newTestRouter(opts, auth)
+
+	do := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	errorCode := func(rec *httptest.ResponseRecorder) string {
+		var body apiv1.Error
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		return body.Code
+	}
+
+	t.Run("current user", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/v1/me", writer)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var me apiv1.User
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&me))
+		assert.Equal(t, "alice", me.Name)
+		assert.Equal(t, []string{"write"}, me.Scopes)
+	})
+
+	t.Run("X-API-Key header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		req.Header.Set(apiv1.HeaderAPIKey, reader)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("private route without a key", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/v1/me", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, `Bearer realm="quip"`, rec.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, apiv1.CodeUnauthorized, errorCode(rec))
+	})
+
+	t.Run("invalid keys are rejected even on public routes", func(t *testing.T) {
+		for _, key := range []string{"garbage", "quip_000000000000_nope", revoked} {
+			rec := do(http.MethodGet, "/api/v1/config", key)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, key)
+		}
+	})
+
+	t.Run("anonymous uploads disabled", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/v1/paste", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, apiv1.CodeUnauthorized, errorCode(rec))
+	})
+
+	t.Run("read-only key cannot upload, edit or delete", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/v1/paste", reader)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, apiv1.CodeForbidden, errorCode(rec))
+
+		rec = do(http.MethodPatch, "/api/v1/paste/abc", reader)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = do(http.MethodDelete, "/api/v1/file/abc", reader)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("listing other users' content needs admin", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/v1/files?owner=someone-else", reader)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = do(http.MethodGet, "/api/v1/pastes", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("listing rejects filters of the other kind", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/v1/pastes?kind=image", reader)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = do(http.MethodGet, "/api/v1/files?sort=name", reader)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("write key reaches the handler", func(t *testing.T) {
+		// A request without a multipart body fails in the handler, past authorization
+		rec := do(http.MethodPost, "/api/v1/file", writer)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
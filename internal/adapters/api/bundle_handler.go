@@ -0,0 +1,252 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+type BundleHandler struct {
+	fileService *services.FileService
+	opts        Options
+	log         *slog.Logger
+}
+
+// Bundle upload handler. The form holds one "file" part per file, and
+// optionally a ttl, a title and max_downloads.
+func (h *BundleHandler) UploadBundle(w http.ResponseWriter, r *http.Request) {
+	logger := h.log.With("remote_addr", r.RemoteAddr)
+	logger.Debug("Attempting to upload a bundle")
+
+	tooLarge := fmt.Errorf("%w: bundles are limited to %d bytes", domain.ErrTooLarge, h.opts.MaxBundleSize)
+	r.Body = http.MaxBytesReader(w, r.Body, h.opts.MaxBundleSize+maxMultipartOverhead)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			logger.Warn("Bundle too large", "error", err)
+			writeError(w, logger, tooLarge)
+			return
+		}
+		logger.Warn("Malformed multipart form", "error", err)
+		writeError(w, logger, fmt.Errorf("%w: malformed multipart form", domain.ErrInvalidInput))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		writeError(w, logger, fmt.Errorf("%w: missing file", domain.ErrInvalidInput))
+		return
+	}
+	if len(headers) > domain.MaxBundleFiles {
+		writeError(w, logger, fmt.Errorf("%w: bundles hold at most %d files", domain.ErrInvalidInput, domain.MaxBundleFiles))
+		return
+	}
+
+	var total int64
+	for _, header := range headers {
+		if header.Size > h.opts.MaxFileSize {
+			logger.Warn("File too large", "filename", header.Filename, "size", header.Size)
+			writeError(w, logger, fmt.Errorf("%w: files are limited to %d bytes", domain.ErrTooLarge, h.opts.MaxFileSize))
+			return
+		}
+		total += header.Size
+	}
+	if total > h.opts.MaxBundleSize {
+		logger.Warn("Bundle too large", "size", total)
+		writeError(w, logger, tooLarge)
+		return
+	}
+
+	ttl, err := h.opts.TTL.Resolve(r.FormValue("ttl"), isAuthenticated(r))
+	if err != nil {
+		logger.Warn("Rejected TTL", "ttl_provided", r.FormValue("ttl"), "error", err)
+		writeError(w, logger, err)
+		return
+	}
+
+	var maxDownloads int
+	if v := r.FormValue("max_downloads"); v != "" {
+		maxDownloads, err = strconv.Atoi(v)
+		if err != nil || maxDownloads < 0 {
+			writeError(w, logger, fmt.Errorf("%w: max_downloads must be a positive number, or 0 for unlimited", domain.ErrInvalidInput))
+			return
+		}
+	}
+
+	uploads := make([]services.FileUpload, len(headers))
+	for i, header := range headers {
+		file, err := header.Open()
+		if err != nil {
+			logger.Error("Failed to open uploaded file", "filename", header.Filename, "error", err)
+			writeError(w, logger, err)
+			return
+		}
+		defer file.Close()
+		uploads[i] = services.FileUpload{
+			Reader:      file,
+			Name:        header.Filename,
+			Size:        header.Size,
+			ContentType: contentType(header),
+		}
+	}
+
+	bundle, err := h.fileService.UploadBundle(r.Context(), r.FormValue("title"), uploads, ttl, maxDownloads, ownerID(r))
+	if err != nil {
+		logger.Error("Failed to upload bundle", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+
+	dto := toBundleDTO(bundle)
+	dto.ManageToken = bundle.ManageToken
+	writeJSON(w, logger, http.StatusOK, dto)
+	logger.Info("Bundle uploaded successfully", "bundle_id", bundle.ID)
+}
+
+// Bundle listing handler
+func (h *BundleHandler) GetBundle(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	logger := h.log.With("bundle_id", id, "remote_addr", r.RemoteAddr)
+
+	bundle, err := h.fileService.GetBundle(r.Context(), id)
+	if err != nil {
+		logger.Warn("Failed to get bundle", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+	writeJSON(w, logger, http.StatusOK, toBundleDTO(bundle))
+}
+
+// Bundle zip download handler
+func (h *BundleHandler) DownloadBundleZip(w http.ResponseWriter, r *http.Request) {
+	h.downloadArchive(w, r, "application/zip", ".zip", func(w io.Writer) archiveWriter {
+		return zipArchive{zip.NewWriter(w)}
+	})
+}
+
+// Bundle tarball download handler
+func (h *BundleHandler) DownloadBundleTarGz(w http.ResponseWriter, r *http.Request) {
+	h.downloadArchive(w, r, "application/gzip", ".tar.gz", func(w io.Writer) archiveWriter {
+		gz := gzip.NewWriter(w)
+		return tarGzArchive{tar.NewWriter(gz), gz}
+	})
+}
+
+// Bundle deletion handler
+func (h *BundleHandler) DeleteBundle(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	logger := h.log.With("bundle_id", id, "remote_addr", r.RemoteAddr)
+	logger.Debug("Attempting to delete a bundle")
+
+	err := h.fileService.DeleteBundle(r.Context(), id, principalFrom(r.Context()), r.Header.Get(apiv1.HeaderManageToken))
+	if err != nil {
+		logger.Error("Failed to delete bundle", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("Bundle deleted successfully")
+}
+
+// downloadArchive streams every file of the bundle from storage into an
+// archive written straight to the response, one file at a time
+func (h *BundleHandler) downloadArchive(w http.ResponseWriter, r *http.Request, contentType, ext string, newArchive func(io.Writer) archiveWriter) {
+	id := r.PathValue("id")
+	logger := h.log.With("bundle_id", id, "remote_addr", r.RemoteAddr)
+
+	bundle, err := h.fileService.OpenBundle(r.Context(), id)
+	if err != nil {
+		logger.Warn("Failed to open bundle", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": bundle.ID + ext}))
+
+	// Headers are sent by now, so a failure can only cut the archive short
+	archive := newArchive(w)
+	names := bundle.ArchiveNames()
+	i := 0
+	err = h.fileService.ReadBundle(r.Context(), bundle, func(file *domain.File, content io.Reader) error {
+		err := archive.add(names[i], file, content)
+		i++
+		return err
+	})
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		logger.Error("Failed to write bundle archive", "error", err)
+		return
+	}
+	logger.Info("Bundle downloaded successfully", "files", len(bundle.Files))
+}
+
+// archiveWriter writes files into an archive as they are streamed in
+type archiveWriter interface {
+	add(name string, file *domain.File, content io.Reader) error
+	Close() error
+}
+
+type zipArchive struct {
+	*zip.Writer
+}
+
+func (a zipArchive) add(name string, file *domain.File, content io.Reader) error {
+	w, err := a.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: file.CreatedAt})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, content)
+	return err
+}
+
+type tarGzArchive struct {
+	tw *tar.Writer
+	gz *gzip.Writer
+}
+
+func (a tarGzArchive) add(name string, file *domain.File, content io.Reader) error {
+	err := a.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     file.Size,
+		Mode:     0o644,
+		ModTime:  file.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	// Tar entries must hold exactly the announced size
+	_, err = io.CopyN(a.tw, content, file.Size)
+	return err
+}
+
+func (a tarGzArchive) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+// contentType returns the declared type of an uploaded file
+func contentType(header *multipart.FileHeader) string {
+	if ct := header.Header.Get("Content-Type"); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
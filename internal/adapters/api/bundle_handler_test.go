@@ -0,0 +1,38 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarGzArchive(t *testing.T) {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	archive := tarGzArchive{tar.NewWriter(gz), gz}
+
+	require.NoError(t, archive.add("a.txt", &domain.File{Size: 5}, strings.NewReader("hello")))
+	require.NoError(t, archive.Close())
+
+	r, err := gzip.NewReader(&b)
+	require.NoError(t, err)
+	tr := tar.NewReader(r)
+	header, err := tr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", header.Name)
+	content, err := io.ReadAll(tr)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+
+	// Content shorter than its recorded size must not pass for a whole file
+	gz = gzip.NewWriter(io.Discard)
+	archive = tarGzArchive{tar.NewWriter(gz), gz}
+	assert.Error(t, archive.add("b.txt", &domain.File{Size: 5}, strings.NewReader("hi")))
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/duration"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+type ConfigHandler struct {
+	opts Options
+	log  *slog.Logger
+}
+
+// Public server configuration handler
+func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	ttl := h.opts.TTL
+	presets := ttl.Presets
+	if presets == nil {
+		presets = []string{}
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, h.log, http.StatusOK, apiv1.ServerConfig{
+		TTL: apiv1.TTLConfig{
+			Default:        duration.Format(ttl.Default),
+			Min:            duration.Format(ttl.Min),
+			Max:            duration.Format(ttl.Max),
+			Presets:        presets,
+			AllowPermanent: ttl.AllowPermanent,
+		},
+		Limits: apiv1.LimitsConfig{
+			MaxFileSize:   h.opts.MaxFileSize,
+			MaxPasteSize:  h.opts.MaxPasteSize,
+			MaxBundleSize: h.opts.MaxBundleSize,
+		},
+		AllowAnonymous: h.opts.AllowAnonymous,
+	})
+}
+
+// Paste languages handler, for completion and language pickers
+func (h *ConfigHandler) GetLanguages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	writeJSON(w, h.log, http.StatusOK, apiv1.LanguageList{Languages: domain.Languages()})
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+type DiffHandler struct {
+	pasteService *services.PasteService
+	opts         Options
+	log          *slog.Logger
+}
+
+// Diff two pastes handler
+func (h *DiffHandler) DiffPastes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	a, b := q.Get("a"), q.Get("b")
+	logger := h.log.With("a", a, "b", b, "remote_addr", r.RemoteAddr)
+
+	format, err := diffFormat(r)
+	if err != nil {
+		writeError(w, logger, err)
+		return
+	}
+	if a == "" || b == "" {
+		writeError(w, logger, fmt.Errorf("%w: a and b must name the pastes to compare", domain.ErrInvalidInput))
+		return
+	}
+
+	diff, err := h.pasteService.DiffPastes(r.Context(), a, b)
+	if err != nil {
+		logger.Warn("Failed to diff pastes", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+	writeDiff(w, logger, format, diff)
+}
+
+// Diff a paste against posted text handler
+func (h *DiffHandler) DiffText(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	a := q.Get("a")
+	logger := h.log.With("a", a, "remote_addr", r.RemoteAddr)
+
+	format, err := diffFormat(r)
+	if err != nil {
+		writeError(w, logger, err)
+		return
+	}
+	if a == "" {
+		writeError(w, logger, fmt.Errorf("%w: a must name the paste to compare with", domain.ErrInvalidInput))
+		return
+	}
+	name := q.Get("name")
+	if name == "" {
+		name = "text"
+	}
+
+	// The posted text is compared, not stored, but is bounded like a paste
+	text, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.opts.MaxPasteSize))
+	if err != nil {
+		logger.Warn("Failed to read posted text", "error", err)
+		writeError(w, logger, fmt.Errorf("%w: text is limited to %d bytes", domain.ErrTooLarge, h.opts.MaxPasteSize))
+		return
+	}
+
+	diff, err := h.pasteService.DiffText(r.Context(), a, name, string(text))
+	if err != nil {
+		logger.Warn("Failed to diff paste with text", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+	writeDiff(w, logger, format, diff)
+}
+
+// diffFormat reads the format query parameter of the diff endpoints
+func diffFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", apiv1.DiffFormatText:
+		return apiv1.DiffFormatText, nil
+	case apiv1.DiffFormatJSON, apiv1.DiffFormatHTML:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: format must be text, json or html, got %q", domain.ErrInvalidInput, format)
+	}
+}
+
+// writeDiff writes diff as a unified diff, JSON or a side-by-side HTML page
+func writeDiff(w http.ResponseWriter, log *slog.Logger, format string, diff *domain.Diff) {
+	switch format {
+	case apiv1.DiffFormatJSON:
+		writeJSON(w, log, http.StatusOK, toDiffDTO(diff))
+	case apiv1.DiffFormatHTML:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := diffViewTemplate.Execute(w, map[string]any{
+			"From": diff.From,
+			"To":   diff.To,
+			"Rows": sideBySide(diff),
+		})
+		if err != nil {
+			log.Error("Failed to render diff", "error", err)
+		}
+	default:
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		if _, err := io.WriteString(w, diff.Unified()); err != nil {
+			log.Error("Failed to write diff", "error", err)
+		}
+	}
+}
+
+func toDiffDTO(d *domain.Diff) apiv1.Diff {
+	dto := apiv1.Diff{From: d.From, To: d.To, Hunks: make([]apiv1.DiffHunk, len(d.Hunks))}
+	for i, h := range d.Hunks {
+		lines := make([]string, len(h.Lines))
+		for j, line := range h.Lines {
+			lines[j] = string(line.Op) + line.Text
+		}
+		dto.Hunks[i] = apiv1.DiffHunk{
+			FromLine: h.FromLine, FromCount: h.FromCount,
+			ToLine: h.ToLine, ToCount: h.ToCount,
+			Lines: lines,
+		}
+	}
+	return dto
+}
+
+// sideRow is a row of the side-by-side view. A nil side is left blank.
+type sideRow struct {
+	Header      string // the hunk header, on the row starting a hunk
+	Left, Right *sideLine
+}
+
+type sideLine struct {
+	Number  int
+	Text    string
+	Changed bool
+}
+
+// sideBySide lays the hunks out in two columns, pairing each run of deleted
+// lines with the inserted lines that replace it
+func sideBySide(d *domain.Diff) []sideRow {
+	var rows []sideRow
+	for _, h := range d.Hunks {
+		rows = append(rows, sideRow{Header: h.Header()})
+		from, to := h.FromLine, h.ToLine
+
+		for i := 0; i < len(h.Lines); {
+			if h.Lines[i].Op == domain.DiffEqual {
+				text := h.Lines[i].Text
+				rows = append(rows, sideRow{Left: &sideLine{from, text, false}, Right: &sideLine{to, text, false}})
+				from, to, i = from+1, to+1, i+1
+				continue
+			}
+
+			var deleted, inserted []string
+			for ; i < len(h.Lines) && h.Lines[i].Op == domain.DiffDelete; i++ {
+				deleted = append(deleted, h.Lines[i].Text)
+			}
+			for ; i < len(h.Lines) && h.Lines[i].Op == domain.DiffInsert; i++ {
+				inserted = append(inserted, h.Lines[i].Text)
+			}
+			for j := range max(len(deleted), len(inserted)) {
+				var row sideRow
+				if j < len(deleted) {
+					row.Left = &sideLine{from, deleted[j], true}
+					from++
+				}
+				if j < len(inserted) {
+					row.Right = &sideLine{to, inserted[j], true}
+					to++
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows
+}
+
+var diffViewTemplate = template.Must(template.New("diff").Funcs(template.FuncMap{
+	"side": func(l *sideLine, class string) template.HTML {
+		if l == nil {
+			return `<td class="n"></td><td class="empty"></td>`
+		}
+		cell := ""
+		if l.Changed {
+			cell = class
+		}
+		return template.HTML(fmt.Sprintf(`<td class="n">%d</td><td class="%s"><pre>%s</pre></td>`,
+			l.Number, cell, template.HTMLEscapeString(l.Text)))
+	},
+}).Parse(strings.TrimSpace(`
+<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.From}} → {{.To}}</title>
+<style>
+table { border-collapse: collapse; width: 100%; font-family: monospace; }
+td { vertical-align: top; padding: 0 .5em; }
+td pre { margin: 0; white-space: pre-wrap; }
+.n { color: #888; text-align: right; width: 1%; }
+.hunk { background: #eef; color: #555; }
+.del { background: #fdd; }
+.ins { background: #dfd; }
+.empty { background: #f4f4f4; }
+</style></head>
+<body>
+<table>
+<tr><th colspan="2">{{.From}}</th><th colspan="2">{{.To}}</th></tr>
+{{range .Rows}}{{if .Header}}<tr><td class="hunk" colspan="4">{{.Header}}</td></tr>
+{{else}}<tr>{{side .Left "del"}}{{side .Right "ins"}}</tr>
+{{end}}{{else}}<tr><td colspan="4">No differences</td></tr>
+{{end}}</table>
+</body></html>
+`)))
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSideBySide(t *testing.T) {
+	diff := domain.NewDiff("a", "keep\nold 1\nold 2\nkeep\n", "b", "keep\nnew\nkeep\nadded\n")
+
+	rows := sideBySide(diff)
+	assert.Equal(t, []sideRow{
+		{Header: "@@ -1,4 +1,4 @@"},
+		{Left: &sideLine{1, "keep", false}, Right: &sideLine{1, "keep", false}},
+		{Left: &sideLine{2, "old 1", true}, Right: &sideLine{2, "new", true}},
+		{Left: &sideLine{3, "old 2", true}},
+		{Left: &sideLine{4, "keep", false}, Right: &sideLine{3, "keep", false}},
+		{Right: &sideLine{4, "added", true}},
+	}, rows)
+
+	rec := httptest.NewRecorder()
+	writeDiff(rec, testLog, apiv1.DiffFormatHTML, domain.NewDiff("a", "<b>\n", "b", "<i>\n"))
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `<td class="del"><pre>&lt;b&gt;</pre></td>`)
+	assert.Contains(t, rec.Body.String(), `<td class="ins"><pre>&lt;i&gt;</pre></td>`)
+}
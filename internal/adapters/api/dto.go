@@ -0,0 +1,154 @@
+package api
+
+import (
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+func toFileDTO(f *domain.File) *apiv1.File {
+	return &apiv1.File{
+		ID:           f.ID,
+		Filename:     f.OriginalName,
+		Size:         f.Size,
+		ContentType:  f.ContentType,
+		Downloads:    f.Downloads,
+		MaxDownloads: f.MaxDownloads,
+		CreatedAt:    f.CreatedAt,
+		ExpiresAt:    optionalTime(f.ExpiresAt),
+		Download:     apiv1.FilePath(f.ID),
+		View:         apiv1.ViewPath(f.ID),
+		BundleID:     f.BundleID,
+	}
+}
+
+func toBundleDTO(b *domain.Bundle) *apiv1.Bundle {
+	files := make([]apiv1.File, len(b.Files))
+	for i, f := range b.Files {
+		files[i] = *toFileDTO(f)
+	}
+	return &apiv1.Bundle{
+		ID:           b.ID,
+		Title:        b.Title,
+		Files:        files,
+		Size:         b.Size(),
+		Downloads:    b.Downloads,
+		MaxDownloads: b.MaxDownloads,
+		CreatedAt:    b.CreatedAt,
+		ExpiresAt:    optionalTime(b.ExpiresAt),
+		View:         apiv1.ViewPath(b.ID),
+		Zip:          apiv1.BundleZipPath(b.ID),
+		TarGz:        apiv1.BundleTarGzPath(b.ID),
+	}
+}
+
+func toPasteDTO(p *domain.Paste) *apiv1.Paste {
+	return &apiv1.Paste{
+		ID:        p.ID,
+		Content:   p.Content,
+		Language:  p.Language,
+		Title:     p.Title,
+		Views:     p.Views,
+		MaxViews:  p.MaxViews,
+		CreatedAt: p.CreatedAt,
+		ExpiresAt: optionalTime(p.ExpiresAt),
+		Revision:  p.Revision,
+		UpdatedAt: optionalTime(p.UpdatedAt),
+		ParentID:  p.ParentID,
+		Raw:       apiv1.PasteRawPath(p.ID),
+		View:      apiv1.ViewPath(p.ID),
+		Files:     toPasteFileDTOs(p, true),
+		Zip:       apiv1.PasteZipPath(p.ID),
+	}
+}
+
+// toPasteSummaryDTO describes a listed paste, leaving its content to the
+// endpoints that count a view
+func toPasteSummaryDTO(p *domain.Paste) apiv1.PasteSummary {
+	return apiv1.PasteSummary{
+		ID:        p.ID,
+		Language:  p.Language,
+		Title:     p.Title,
+		Size:      p.Size(),
+		Views:     p.Views,
+		MaxViews:  p.MaxViews,
+		CreatedAt: p.CreatedAt,
+		ExpiresAt: optionalTime(p.ExpiresAt),
+		Revision:  p.Revision,
+		UpdatedAt: optionalTime(p.UpdatedAt),
+		ParentID:  p.ParentID,
+		Raw:       apiv1.PasteRawPath(p.ID),
+		View:      apiv1.ViewPath(p.ID),
+		Files:     toPasteFileDTOs(p, false),
+		Zip:       apiv1.PasteZipPath(p.ID),
+	}
+}
+
+// toPasteFileDTOs describes the files of a multi-file paste, nil for other pastes
+func toPasteFileDTOs(p *domain.Paste, withContent bool) []apiv1.PasteFile {
+	if !p.IsMultiFile() {
+		return nil
+	}
+	files := make([]apiv1.PasteFile, len(p.Files))
+	for i, f := range p.Files {
+		files[i] = apiv1.PasteFile{
+			Name:     f.Name,
+			Language: f.Language,
+			Size:     int64(len(f.Content)),
+			Raw:      apiv1.PasteFileRawPath(p.ID, f.Name),
+		}
+		if withContent {
+			files[i].Content = f.Content
+		}
+	}
+	return files
+}
+
+func toRevisionDTO(r *domain.Revision) apiv1.Revision {
+	return apiv1.Revision{
+		Number:    r.Number,
+		Language:  r.Language,
+		Title:     r.Title,
+		Size:      r.Size,
+		CreatedAt: r.CreatedAt,
+		Raw:       apiv1.PasteRevisionRawPath(r.PasteID, r.Number),
+	}
+}
+
+func toSearchResultDTO(r domain.SearchResult) apiv1.SearchResult {
+	highlights := make([][2]int, len(r.Highlights))
+	for i, h := range r.Highlights {
+		highlights[i] = [2]int{h.Start, h.End}
+	}
+
+	return apiv1.SearchResult{
+		ID:         r.Paste.ID,
+		Title:      r.Paste.Title,
+		Language:   r.Paste.Language,
+		CreatedAt:  r.Paste.CreatedAt,
+		ExpiresAt:  optionalTime(r.Paste.ExpiresAt),
+		Raw:        apiv1.PasteRawPath(r.Paste.ID),
+		View:       apiv1.ViewPath(r.Paste.ID),
+		Rank:       r.Rank,
+		Snippet:    r.Snippet,
+		Highlights: highlights,
+	}
+}
+
+func toUsageDTO(u domain.Usage, q domain.Quota) apiv1.UsageStats {
+	return apiv1.UsageStats{
+		Items:    u.Items,
+		Bytes:    u.Bytes,
+		MaxItems: q.MaxItems,
+		MaxBytes: q.MaxBytes,
+	}
+}
+
+// optionalTime maps the zero time, such as the expiry of permanent content, to nil
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
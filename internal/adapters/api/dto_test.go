@@ -0,0 +1,26 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasteSummaryDTO(t *testing.T) {
+	paste := &domain.Paste{ID: "abc", Content: "package main\n", Language: "Go", Revision: 1, Files: []domain.PasteFile{
+		{Name: "main.go", Content: "package main\n", Language: "Go"},
+		{Name: "go.mod", Content: "module example\n", Language: "Text"},
+	}}
+	summary := toPasteSummaryDTO(paste)
+	assert.Equal(t, int64(28), summary.Size)
+	require.Len(t, summary.Files, 2)
+	assert.Equal(t, int64(15), summary.Files[1].Size)
+
+	body, err := json.Marshal(summary)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "content", "listing a paste does not read it")
+	assert.NotContains(t, string(body), "module example")
+}
@@ -1,51 +1,68 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
 	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
 )
 
 type FileHandler struct {
 	fileService *services.FileService
+	opts        Options
 	log         *slog.Logger
 }
 
+// maxMultipartOverhead is the room left for multipart headers and form fields on top of the file itself
+const maxMultipartOverhead = 1 << 20
+
 // File upload handler
 func (h *FileHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 	logger := h.log.With("remote_addr", r.RemoteAddr)
 	logger.Debug("Attempting to upload a file")
 
-	// Parse multipart form
-	if err := r.ParseMultipartForm(100 << 20); err != nil { // 100MB max
-		logger.Warn("File too large", "error", err)
-		http.Error(w, "File too large", http.StatusBadRequest)
+	// Parse multipart form, refusing to read past the size limit
+	r.Body = http.MaxBytesReader(w, r.Body, h.opts.MaxFileSize+maxMultipartOverhead)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			logger.Warn("File too large", "error", err)
+			writeError(w, logger, fmt.Errorf("%w: files are limited to %d bytes", domain.ErrTooLarge, h.opts.MaxFileSize))
+			return
+		}
+		logger.Warn("Malformed multipart form", "error", err)
+		writeError(w, logger, fmt.Errorf("%w: malformed multipart form", domain.ErrInvalidInput))
 		return
 	}
 
 	file, header, err := r.FormFile("file")
 	if err != nil {
 		logger.Warn("Missing file in form", "error", err)
-		http.Error(w, "Missing file", http.StatusBadRequest)
+		writeError(w, logger, fmt.Errorf("%w: missing file", domain.ErrInvalidInput))
 		return
 	}
 	defer file.Close()
 
+	if header.Size > h.opts.MaxFileSize {
+		logger.Warn("File too large", "size", header.Size)
+		writeError(w, logger, fmt.Errorf("%w: files are limited to %d bytes", domain.ErrTooLarge, h.opts.MaxFileSize))
+		return
+	}
+
 	// Parse TTL
-	ttl := 24 * time.Hour // default
-	if ttlStr := r.FormValue("ttl"); ttlStr != "" {
-		if parsed, err := time.ParseDuration(ttlStr); err == nil {
-			ttl = parsed
-		} else {
-			logger.Debug("Invalid TTL format, using default", "ttl_provided", ttlStr)
-		}
+	ttl, err := h.opts.TTL.Resolve(r.FormValue("ttl"), isAuthenticated(r))
+	if err != nil {
+		logger.Warn("Rejected TTL", "ttl_provided", r.FormValue("ttl"), "error", err)
+		writeError(w, logger, err)
+		return
 	}
 
 	// Upload file
@@ -56,28 +73,25 @@ func (h *FileHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 		header.Size,
 		header.Header.Get("Content-Type"),
 		ttl,
+		ownerID(r),
 	)
 	if err != nil {
 		logger.Error("Failed to upload file", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, logger, err)
 		return
 	}
 
 	// Return response
-	response := map[string]any{
-		"id":       uploadedFile.ID,
-		"filename": uploadedFile.OriginalName,
-		"size":     uploadedFile.Size,
-		"download": fmt.Sprintf("/api/file/%s", uploadedFile.ID),
-		"view":     fmt.Sprintf("/api/view/%s", uploadedFile.ID),
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(response)
-	if err != nil {
-		logger.Error("Failed to encode response", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	writeJSON(w, logger, http.StatusOK, apiv1.FileUploaded{
+		ID:        uploadedFile.ID,
+		Filename:  uploadedFile.OriginalName,
+		Size:      uploadedFile.Size,
+		Download:  apiv1.FilePath(uploadedFile.ID),
+		View:      apiv1.ViewPath(uploadedFile.ID),
+		ExpiresAt: optionalTime(uploadedFile.ExpiresAt),
+
+		ManageToken: uploadedFile.ManageToken,
+	})
 	logger.Info("File uploaded successfully", "file_id", uploadedFile.ID)
 }
 
@@ -87,28 +101,57 @@ func (h *FileHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
 	logger := h.log.With("file_id", id, "remote_addr", r.RemoteAddr)
 	logger.Debug("Attempting to download a file")
 
-	reader, file, err := h.fileService.Download(r.Context(), id)
+	// A range request carrying the resume token of a counted transfer does
+	// not count it twice. Any other request counts, ranges included, so that
+	// they cannot get around the download limit.
+	offset := rangeStart(r)
+	var resume string
+	if offset > 0 {
+		resume = r.Header.Get(apiv1.HeaderResumeToken)
+	}
+	reader, file, token, err := h.fileService.Download(r.Context(), id, resume)
 	if err != nil {
 		logger.Warn("Failed to download file", "error", err)
-		switch err {
-		case domain.ErrNotFound:
-			http.Error(w, "File not found", http.StatusNotFound)
-		case domain.ErrExpired:
-			http.Error(w, "File has expired", http.StatusGone)
-		default:
-			logger.Error("Internal server error during file download", "error", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+		writeError(w, logger, err)
 		return
 	}
 	defer reader.Close()
 
-	// Set headers
 	w.Header().Set("Content-Type", file.ContentType)
-	w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.OriginalName))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.OriginalName}))
+	w.Header().Set(apiv1.HeaderResumeToken, token)
+
+	// Serve ranges when storage can seek, so that interrupted downloads can
+	// be resumed
+	if rs, ok := reader.(io.ReadSeeker); ok {
+		if offset > 0 {
+			// Files never change, and a resume that was not counted must not
+			// get the whole file when If-Range does not match
+			r.Header.Del("If-Range")
+		}
+		http.ServeContent(w, r, file.OriginalName, file.CreatedAt, rs)
+		logger.Info("File downloaded successfully", "range", r.Header.Get("Range"))
+		return
+	}
 
-	// Stream file to response
+	if offset > 0 {
+		// Without seeking, skip what the resume already has
+		if offset >= file.Size {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", file.Size))
+			http.Error(w, "invalid range: failed to overlap", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if _, err := io.CopyN(io.Discard, reader, offset); err != nil {
+			logger.Error("Failed to skip to the requested range", "error", err)
+			http.Error(w, "Failed to stream file", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, file.Size-1, file.Size))
+		w.Header().Set("Content-Length", strconv.FormatInt(file.Size-offset, 10))
+		w.WriteHeader(http.StatusPartialContent)
+	} else {
+		w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	}
 	_, err = io.Copy(w, reader)
 	if err != nil {
 		logger.Error("Failed to stream file to response", "error", err)
@@ -117,6 +160,22 @@ func (h *FileHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
 	logger.Info("File downloaded successfully")
 }
 
+// rangeStart returns the first byte of the single range a request asks
+// for, or 0 for whole files, suffix ranges and multiple ranges, which may
+// all include the start of the file
+func rangeStart(r *http.Request) int64 {
+	spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0
+	}
+	first, _, _ := strings.Cut(spec, "-")
+	start, err := strconv.ParseInt(strings.TrimSpace(first), 10, 64)
+	if err != nil || start < 0 {
+		return 0
+	}
+	return start
+}
+
 // Get file info handler
 func (h *FileHandler) GetFileInfo(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -126,34 +185,50 @@ func (h *FileHandler) GetFileInfo(w http.ResponseWriter, r *http.Request) {
 	file, err := h.fileService.GetInfo(r.Context(), id)
 	if err != nil {
 		logger.Warn("Failed to get file info", "error", err)
-		switch err {
-		case domain.ErrNotFound:
-			http.Error(w, "File not found", http.StatusNotFound)
-		default:
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+		writeError(w, logger, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(file)
+	writeJSON(w, logger, http.StatusOK, toFileDTO(file))
 	logger.Debug("File info sent successfully")
 }
 
+// List files handler
+func (h *FileHandler) ListFiles(w http.ResponseWriter, r *http.Request) {
+	logger := h.log.With("remote_addr", r.RemoteAddr)
+
+	filter, page, err := parseListQuery(r, "kind", "content_type")
+	if err != nil {
+		logger.Warn("Rejected listing query", "query", r.URL.RawQuery, "error", err)
+		writeError(w, logger, err)
+		return
+	}
+
+	files, next, err := h.fileService.List(r.Context(), filter, page)
+	if err != nil {
+		writeError(w, logger, err)
+		return
+	}
+
+	list := apiv1.FileList{Files: make([]apiv1.File, len(files))}
+	for i, f := range files {
+		list.Files[i] = *toFileDTO(f)
+	}
+	if next != nil {
+		list.NextCursor = next.String()
+	}
+	writeJSON(w, logger, http.StatusOK, list)
+}
+
 // Delete file handler
 func (h *FileHandler) DeleteFile(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	logger := h.log.With("file_id", id, "remote_addr", r.RemoteAddr)
 	logger.Debug("Attempting to delete a file")
 
-	err := h.fileService.Delete(r.Context(), id)
+	err := h.fileService.Delete(r.Context(), id, principalFrom(r.Context()), r.Header.Get(apiv1.HeaderManageToken))
 	if err != nil {
 		logger.Error("Failed to delete file", "error", err)
-		switch err {
-		case domain.ErrNotFound:
-			http.Error(w, "File not found", http.StatusNotFound)
-		default:
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+		writeError(w, logger, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeStart(t *testing.T) {
+	for header, want := range map[string]int64{
+		"":                0,
+		"bytes=0-":        0,
+		"bytes=100-":      100,
+		"bytes=100-199":   100,
+		"bytes=-100":      0,
+		"bytes=100-,0-99": 0,
+		"items=100-":      0,
+		"bytes=x-":        0,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/file/abc", nil)
+		if header != "" {
+			r.Header.Set("Range", header)
+		}
+		assert.Equal(t, want, rangeStart(r), "Range: %s", header)
+	}
+}
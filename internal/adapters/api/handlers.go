@@ -3,21 +3,47 @@ package api
 import (
 	"log/slog"
 
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
 	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
 )
 
+// Options tunes the HTTP adapter
+type Options struct {
+	MaxFileSize   int64            // largest accepted upload, in bytes
+	MaxPasteSize  int64            // largest accepted paste content, in bytes
+	MaxBundleSize int64            // largest accepted bundle, all files together, in bytes
+	TTL           domain.TTLPolicy // accepted lifetimes of new content
+	CORSOrigins   []string         // origins allowed to call the API, "*" for any
+	// AllowAnonymous lets requests without an API key upload files and create pastes
+	AllowAnonymous bool
+	// PublicURL is the base of absolute links, derived from requests when empty
+	PublicURL string
+}
+
 type Handlers struct {
-	fileHandler  *FileHandler
-	pasteHandler *PasteHandler
-	viewHandler  *ViewHandler
-	log          *slog.Logger
+	fileHandler   *FileHandler
+	bundleHandler *BundleHandler
+	pasteHandler  *PasteHandler
+	viewHandler   *ViewHandler
+	diffHandler   *DiffHandler
+	configHandler *ConfigHandler
+	meHandler     *MeHandler
+	authService   *services.AuthService
+	opts          Options
+	log           *slog.Logger
 }
 
-func NewHandlers(fileService *services.FileService, pasteService *services.PasteService, log *slog.Logger) *Handlers {
+func NewHandlers(fileService *services.FileService, pasteService *services.PasteService, authService *services.AuthService, quotaService *services.QuotaService, log *slog.Logger, opts Options) *Handlers {
 	return &Handlers{
-		fileHandler:  &FileHandler{fileService: fileService, log: log.With("handler", "file")},
-		pasteHandler: &PasteHandler{pasteService: pasteService, log: log.With("handler", "paste")},
-		viewHandler:  &ViewHandler{pasteService: pasteService, fileService: fileService, log: log.With("handler", "view")},
-		log:          log,
+		fileHandler:   &FileHandler{fileService: fileService, opts: opts, log: log.With("handler", "file")},
+		bundleHandler: &BundleHandler{fileService: fileService, opts: opts, log: log.With("handler", "bundle")},
+		pasteHandler:  &PasteHandler{pasteService: pasteService, opts: opts, log: log.With("handler", "paste")},
+		viewHandler:   &ViewHandler{pasteService: pasteService, fileService: fileService, opts: opts, log: log.With("handler", "view")},
+		diffHandler:   &DiffHandler{pasteService: pasteService, opts: opts, log: log.With("handler", "diff")},
+		configHandler: &ConfigHandler{opts: opts, log: log.With("handler", "config")},
+		meHandler:     &MeHandler{authService: authService, quotaService: quotaService, log: log.With("handler", "me")},
+		authService:   authService,
+		opts:          opts,
+		log:           log,
 	}
 }
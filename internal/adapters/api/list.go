@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/duration"
+)
+
+// parseListQuery reads the filters and page of a listing request. Only the
+// filters named in allowed are accepted, besides the common ones.
+func parseListQuery(r *http.Request, allowed ...string) (domain.ListFilter, domain.Page, error) {
+	q := r.URL.Query()
+	var filter domain.ListFilter
+	var page domain.Page
+
+	for _, name := range []string{"kind", "content_type", "language"} {
+		if q.Has(name) && !slices.Contains(allowed, name) {
+			return filter, page, fmt.Errorf("%w: unsupported filter %q", domain.ErrInvalidInput, name)
+		}
+	}
+	filter.Kind = q.Get("kind")
+	filter.ContentType = q.Get("content_type")
+	filter.Language = q.Get("language")
+
+	owner, err := listOwner(r, q.Get("owner"))
+	if err != nil {
+		return filter, page, err
+	}
+	filter.OwnerID = owner
+
+	if filter.CreatedAfter, err = queryTime(q, "created_after"); err != nil {
+		return filter, page, err
+	}
+	if filter.CreatedBefore, err = queryTime(q, "created_before"); err != nil {
+		return filter, page, err
+	}
+	if within := q.Get("expiring_within"); within != "" {
+		d, err := duration.Parse(within)
+		if err != nil {
+			return filter, page, fmt.Errorf("%w: expiring_within: %v", domain.ErrInvalidInput, err)
+		}
+		filter.ExpiresBefore = time.Now().Add(d)
+	}
+
+	if page.Sort, err = domain.ParseSortKey(q.Get("sort")); err != nil {
+		return filter, page, err
+	}
+	if cursor := q.Get("cursor"); cursor != "" {
+		if page.After, err = domain.ParseCursor(cursor); err != nil {
+			return filter, page, err
+		}
+	}
+	if limit := q.Get("limit"); limit != "" {
+		if page.Limit, err = strconv.Atoi(limit); err != nil || page.Limit <= 0 {
+			return filter, page, fmt.Errorf("%w: limit must be a positive number", domain.ErrInvalidInput)
+		}
+	}
+	return filter, page, nil
+}
+
+// listOwner restricts listings to the caller's own content. Admins may list
+// any owner's content, or everyone's when no owner is requested.
+func listOwner(r *http.Request, requested string) (string, error) {
+	principal := principalFrom(r.Context())
+	if principal.Allows(domain.ScopeAdmin) {
+		return requested, nil
+	}
+	if requested != "" && requested != principal.UserID {
+		return "", fmt.Errorf("%w: only admins may list other users' content", domain.ErrForbidden)
+	}
+	return principal.UserID, nil
+}
+
+func queryTime(q url.Values, name string) (time.Time, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be an RFC 3339 timestamp", domain.ErrInvalidInput, name)
+	}
+	// Timestamps are stored as the server's wall-clock time
+	return t.Local(), nil
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+// MeHandler serves the authenticated caller's account
+type MeHandler struct {
+	authService  *services.AuthService
+	quotaService *services.QuotaService
+	log          *slog.Logger
+}
+
+// Current user handler
+func (h *MeHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	principal := principalFrom(r.Context())
+	logger := h.log.With("user_id", principal.UserID, "remote_addr", r.RemoteAddr)
+
+	user, err := h.authService.GetUser(r.Context(), principal.UserName)
+	if err != nil {
+		logger.Error("Failed to get user", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+
+	scopes := make([]string, len(principal.Scopes))
+	for i, s := range principal.Scopes {
+		scopes[i] = string(s)
+	}
+	writeJSON(w, logger, http.StatusOK, apiv1.User{
+		ID:        user.ID,
+		Name:      user.Name,
+		Scopes:    scopes,
+		CreatedAt: user.CreatedAt,
+	})
+}
+
+// Storage usage handler
+func (h *MeHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	principal := principalFrom(r.Context())
+	logger := h.log.With("user_id", principal.UserID, "remote_addr", r.RemoteAddr)
+
+	usage, quota, err := h.quotaService.Usage(r.Context(), principal.UserID)
+	if err != nil {
+		logger.Error("Failed to get usage", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+	resp := apiv1.Usage{User: toUsageDTO(usage, quota)}
+
+	if principal.Allows(domain.ScopeAdmin) {
+		usage, quota, err := h.quotaService.TotalUsage(r.Context())
+		if err != nil {
+			logger.Error("Failed to get total usage", "error", err)
+			writeError(w, logger, err)
+			return
+		}
+		global := toUsageDTO(usage, quota)
+		resp.Global = &global
+	}
+	writeJSON(w, logger, http.StatusOK, resp)
+}
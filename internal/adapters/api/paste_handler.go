@@ -1,18 +1,24 @@
 package api
 
 import (
+	"archive/zip"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
 	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
 )
 
 type PasteHandler struct {
 	pasteService *services.PasteService
+	opts         Options
 	log          *slog.Logger
 }
 
@@ -21,57 +27,123 @@ func (h *PasteHandler) CreatePaste(w http.ResponseWriter, r *http.Request) {
 	logger := h.log.With("remote_addr", r.RemoteAddr)
 	logger.Debug("Attempting to create a new paste")
 
-	var req struct {
-		Content  string `json:"content"`
-		Language string `json:"language"`
-		Title    string `json:"title"`
-		TTL      string `json:"ttl"`
-	}
+	// Leave room for JSON escaping on top of the content itself
+	r.Body = http.MaxBytesReader(w, r.Body, 2*h.opts.MaxPasteSize+64<<10)
 
+	var req apiv1.CreatePasteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		logger.Error("Failed to decode request body", "error", err)
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, logger, fmt.Errorf("%w: pastes are limited to %d bytes", domain.ErrTooLarge, h.opts.MaxPasteSize))
+			return
+		}
+		writeError(w, logger, fmt.Errorf("%w: malformed request body", domain.ErrInvalidInput))
+		return
+	}
+
+	size := len(req.Content)
+	for _, f := range req.Files {
+		size += len(f.Content)
+	}
+	if int64(size) > h.opts.MaxPasteSize {
+		logger.Warn("Paste too large", "size", size)
+		writeError(w, logger, fmt.Errorf("%w: pastes are limited to %d bytes", domain.ErrTooLarge, h.opts.MaxPasteSize))
+		return
+	}
+	if len(req.Files) > 0 && (req.Content != "" || req.Language != "") {
+		writeError(w, logger, fmt.Errorf("%w: give either content or files, not both", domain.ErrInvalidInput))
 		return
 	}
 
 	// Parse TTL
-	ttl := 24 * time.Hour // default
-	if req.TTL != "" {
-		if parsed, err := time.ParseDuration(req.TTL); err == nil {
-			ttl = parsed
-		}
+	ttl, err := h.opts.TTL.Resolve(req.TTL, isAuthenticated(r))
+	if err != nil {
+		logger.Warn("Rejected TTL", "ttl_provided", req.TTL, "error", err)
+		writeError(w, logger, err)
+		return
 	}
 
 	// Create paste
-	paste, err := h.pasteService.Create(
-		r.Context(),
-		req.Content,
-		req.Language,
-		req.Title,
-		ttl,
-	)
+	var paste *domain.Paste
+	if len(req.Files) > 0 {
+		files := make([]domain.PasteFile, len(req.Files))
+		for i, f := range req.Files {
+			files[i] = domain.PasteFile{Name: f.Name, Content: f.Content, Language: f.Language}
+		}
+		paste, err = h.pasteService.CreateMultiFile(r.Context(), files, req.Title, ttl, ownerID(r))
+	} else {
+		paste, err = h.pasteService.Create(
+			r.Context(),
+			req.Content,
+			req.Language,
+			req.Title,
+			ttl,
+			ownerID(r),
+		)
+	}
 	if err != nil {
 		logger.Error("Failed to create paste", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, logger, err)
 		return
 	}
 
 	// Return response
-	response := map[string]any{
-		"id":       paste.ID,
-		"language": paste.Language,
-		"raw":      fmt.Sprintf("/api/paste/%s/raw", paste.ID),
-		"view":     fmt.Sprintf("/api/view/%s", paste.ID),
+	writeJSON(w, logger, http.StatusOK, apiv1.PasteCreated{
+		ID:        paste.ID,
+		Language:  paste.Language,
+		Raw:       apiv1.PasteRawPath(paste.ID),
+		View:      apiv1.ViewPath(paste.ID),
+		ExpiresAt: optionalTime(paste.ExpiresAt),
+		Files:     toPasteFileDTOs(paste, false),
+
+		ManageToken: paste.ManageToken,
+	})
+
+	logger.Info("Paste created successfully", "paste_id", paste.ID)
+}
+
+// Fork paste handler
+func (h *PasteHandler) ForkPaste(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	logger := h.log.With("paste_id", id, "remote_addr", r.RemoteAddr)
+	logger.Debug("Attempting to fork paste")
+
+	// The body is optional, an empty one forks with the default TTL
+	var req apiv1.ForkPasteRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.Warn("Failed to decode request body", "error", err)
+		writeError(w, logger, fmt.Errorf("%w: malformed request body", domain.ErrInvalidInput))
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(response)
+	ttl, err := h.opts.TTL.Resolve(req.TTL, isAuthenticated(r))
 	if err != nil {
-		logger.Error("Failed to encode response", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logger.Warn("Rejected TTL", "ttl_provided", req.TTL, "error", err)
+		writeError(w, logger, err)
+		return
 	}
 
-	logger.Info("Paste created successfully", "paste_id", paste.ID)
+	fork, err := h.pasteService.Fork(r.Context(), id, ttl, ownerID(r))
+	if err != nil {
+		logger.Warn("Failed to fork paste", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+
+	writeJSON(w, logger, http.StatusOK, apiv1.PasteCreated{
+		ID:        fork.ID,
+		Language:  fork.Language,
+		Raw:       apiv1.PasteRawPath(fork.ID),
+		View:      apiv1.ViewPath(fork.ID),
+		ExpiresAt: optionalTime(fork.ExpiresAt),
+		ParentID:  fork.ParentID,
+		Files:     toPasteFileDTOs(fork, false),
+
+		ManageToken: fork.ManageToken,
+	})
+	logger.Info("Paste forked successfully", "fork_id", fork.ID)
 }
 
 // Get paste handler
@@ -83,24 +155,11 @@ func (h *PasteHandler) GetPaste(w http.ResponseWriter, r *http.Request) {
 	paste, err := h.pasteService.Get(r.Context(), id)
 	if err != nil {
 		logger.Warn("Failed to get paste", "error", err)
-		switch err {
-		case domain.ErrNotFound:
-			http.Error(w, "Paste not found", http.StatusNotFound)
-		case domain.ErrExpired:
-			http.Error(w, "Paste has expired", http.StatusGone)
-		default:
-			logger.Error("Internal server error while getting paste", "error", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+		writeError(w, logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(paste)
-	if err != nil {
-		logger.Error("Failed to encode paste response", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	writeJSON(w, logger, http.StatusOK, toPasteDTO(paste))
 	logger.Info("Successfully retrieved paste")
 }
 
@@ -113,24 +172,242 @@ func (h *PasteHandler) GetRawPaste(w http.ResponseWriter, r *http.Request) {
 	content, err := h.pasteService.GetRaw(r.Context(), id)
 	if err != nil {
 		logger.Warn("Failed to retrieve raw paste", "error", err)
-		switch err {
-		case domain.ErrNotFound:
-			http.Error(w, "Paste not found", http.StatusNotFound)
-		case domain.ErrExpired:
-			http.Error(w, "Paste has expired", http.StatusGone)
-		default:
-			logger.Error("Internal server error while retrieving raw paste", "error", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write([]byte(content)); err != nil {
+		logger.Error("Failed to write raw paste", "error", err)
+		return
+	}
+	logger.Info("Successfully retrieved raw paste")
+}
+
+// Get raw paste file handler
+func (h *PasteHandler) GetRawPasteFile(w http.ResponseWriter, r *http.Request) {
+	id, name := r.PathValue("id"), r.PathValue("filename")
+	logger := h.log.With("paste_id", id, "filename", name, "remote_addr", r.RemoteAddr)
+
+	file, err := h.pasteService.GetFile(r.Context(), id, name)
+	if err != nil {
+		logger.Warn("Failed to retrieve paste file", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := io.WriteString(w, file.Content); err != nil {
+		logger.Error("Failed to write paste file", "error", err)
+	}
+}
+
+// Download paste as zip handler
+func (h *PasteHandler) DownloadPasteZip(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	logger := h.log.With("paste_id", id, "remote_addr", r.RemoteAddr)
+
+	paste, err := h.pasteService.Get(r.Context(), id)
+	if err != nil {
+		logger.Warn("Failed to get paste for zip", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": paste.ID + ".zip"}))
+
+	// Headers are sent by now, so a failure can only cut the archive short
+	zw := zip.NewWriter(w)
+	for _, f := range paste.AllFiles() {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: paste.CreatedAt})
+		if err == nil {
+			_, err = io.WriteString(fw, f.Content)
+		}
+		if err != nil {
+			logger.Error("Failed to write paste zip", "error", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		logger.Error("Failed to write paste zip", "error", err)
+	}
+}
+
+// List pastes handler
+func (h *PasteHandler) ListPastes(w http.ResponseWriter, r *http.Request) {
+	logger := h.log.With("remote_addr", r.RemoteAddr)
+
+	filter, page, err := parseListQuery(r, "language")
+	if err != nil {
+		logger.Warn("Rejected listing query", "query", r.URL.RawQuery, "error", err)
+		writeError(w, logger, err)
+		return
+	}
+
+	pastes, next, err := h.pasteService.List(r.Context(), filter, page)
+	if err != nil {
+		writeError(w, logger, err)
+		return
+	}
+
+	list := apiv1.PasteList{Pastes: make([]apiv1.PasteSummary, len(pastes))}
+	for i, p := range pastes {
+		list.Pastes[i] = toPasteSummaryDTO(p)
+	}
+	if next != nil {
+		list.NextCursor = next.String()
+	}
+	writeJSON(w, logger, http.StatusOK, list)
+}
+
+// Search pastes handler
+func (h *PasteHandler) SearchPastes(w http.ResponseWriter, r *http.Request) {
+	logger := h.log.With("remote_addr", r.RemoteAddr)
+	q := r.URL.Query()
+
+	owner, err := listOwner(r, q.Get("owner"))
+	if err != nil {
+		writeError(w, logger, err)
+		return
+	}
+	if owner == "" {
+		// Search is per owner, even for admins
+		owner = principalFrom(r.Context()).UserID
+	}
+
+	limit := 0
+	if raw := q.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 {
+			writeError(w, logger, fmt.Errorf("%w: limit must be a positive number", domain.ErrInvalidInput))
+			return
 		}
+	}
+
+	results, err := h.pasteService.Search(r.Context(), owner, q.Get("q"), limit)
+	if err != nil {
+		writeError(w, logger, err)
 		return
 	}
 
+	resp := apiv1.SearchResults{Results: make([]apiv1.SearchResult, len(results))}
+	for i, res := range results {
+		resp.Results[i] = toSearchResultDTO(res)
+	}
+	writeJSON(w, logger, http.StatusOK, resp)
+}
+
+// Edit paste handler
+func (h *PasteHandler) EditPaste(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	logger := h.log.With("paste_id", id, "remote_addr", r.RemoteAddr)
+	logger.Debug("Attempting to edit paste")
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*h.opts.MaxPasteSize+64<<10)
+
+	var req apiv1.EditPasteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("Failed to decode request body", "error", err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, logger, fmt.Errorf("%w: pastes are limited to %d bytes", domain.ErrTooLarge, h.opts.MaxPasteSize))
+			return
+		}
+		writeError(w, logger, fmt.Errorf("%w: malformed request body", domain.ErrInvalidInput))
+		return
+	}
+
+	if req.Content != nil && int64(len(*req.Content)) > h.opts.MaxPasteSize {
+		logger.Warn("Paste too large", "size", len(*req.Content))
+		writeError(w, logger, fmt.Errorf("%w: pastes are limited to %d bytes", domain.ErrTooLarge, h.opts.MaxPasteSize))
+		return
+	}
+
+	paste, err := h.pasteService.Edit(r.Context(), id, principalFrom(r.Context()), r.Header.Get(apiv1.HeaderManageToken),
+		domain.PasteEdit{Content: req.Content, Language: req.Language, Title: req.Title, Revision: req.Revision})
+	if err != nil {
+		writeError(w, logger, err)
+		return
+	}
+	writeJSON(w, logger, http.StatusOK, toPasteDTO(paste))
+}
+
+// List paste revisions handler
+func (h *PasteHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	logger := h.log.With("paste_id", id, "remote_addr", r.RemoteAddr)
+
+	revisions, err := h.pasteService.Revisions(r.Context(), id)
+	if err != nil {
+		writeError(w, logger, err)
+		return
+	}
+
+	list := apiv1.RevisionList{Revisions: make([]apiv1.Revision, len(revisions))}
+	for i, rev := range revisions {
+		list.Revisions[i] = toRevisionDTO(rev)
+	}
+	writeJSON(w, logger, http.StatusOK, list)
+}
+
+// Get raw paste revision handler
+func (h *PasteHandler) GetRawRevision(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	logger := h.log.With("paste_id", id, "remote_addr", r.RemoteAddr)
+
+	number, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil || number < 1 {
+		writeError(w, logger, fmt.Errorf("%w: revision numbers start at 1", domain.ErrInvalidInput))
+		return
+	}
+
+	revision, err := h.pasteService.Revision(r.Context(), id, number, principalFrom(r.Context()), r.Header.Get(apiv1.HeaderManageToken))
+	if err != nil {
+		writeError(w, logger, err)
+		return
+	}
+
+	// Revisions never change, but a cached copy would outlive the paste's
+	// expiry and view limit
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	_, err = w.Write([]byte(content))
+	w.Header().Set("Cache-Control", "private, no-store")
+	if _, err := w.Write([]byte(revision.Content)); err != nil {
+		logger.Error("Failed to write raw revision", "error", err)
+	}
+}
+
+// Diff paste revisions handler
+func (h *PasteHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	logger := h.log.With("paste_id", id, "remote_addr", r.RemoteAddr)
+	q := r.URL.Query()
+
+	format, err := diffFormat(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, logger, err)
+		return
 	}
-	logger.Info("Successfully retrieved raw paste")
+
+	var numbers [2]int
+	for i, key := range []string{"from", "to"} {
+		raw := q.Get(key)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeError(w, logger, fmt.Errorf("%w: %s must be a revision number", domain.ErrInvalidInput, key))
+			return
+		}
+		numbers[i] = n
+	}
+
+	diff, err := h.pasteService.Diff(r.Context(), id, numbers[0], numbers[1], principalFrom(r.Context()), r.Header.Get(apiv1.HeaderManageToken))
+	if err != nil {
+		writeError(w, logger, err)
+		return
+	}
+	writeDiff(w, logger, format, diff)
 }
 
 // Delete paste handler
@@ -139,18 +416,10 @@ func (h *PasteHandler) DeletePaste(w http.ResponseWriter, r *http.Request) {
 	logger := h.log.With("paste_id", id, "remote_addr", r.RemoteAddr)
 	logger.Debug("Attempting to delete paste")
 
-	err := h.pasteService.Delete(r.Context(), id)
+	err := h.pasteService.Delete(r.Context(), id, principalFrom(r.Context()), r.Header.Get(apiv1.HeaderManageToken))
 	if err != nil {
 		logger.Warn("Failed to delete paste", "error", err)
-		switch err {
-		case domain.ErrNotFound:
-			http.Error(w, "Paste not found", http.StatusNotFound)
-		case domain.ErrExpired:
-			http.Error(w, "Paste has expired", http.StatusGone)
-		default:
-			logger.Error("Internal server error while deleting paste", "error", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+		writeError(w, logger, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
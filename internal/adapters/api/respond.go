@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+// writeJSON encodes v as the response body with the given status code
+func writeJSON(w http.ResponseWriter, log *slog.Logger, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error("Failed to encode response", "error", err)
+	}
+}
+
+// writeError maps a service error to its HTTP status and writes it as a v1 error body
+func writeError(w http.ResponseWriter, log *slog.Logger, err error) {
+	status, code := http.StatusInternalServerError, apiv1.CodeInternal
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		status, code = http.StatusNotFound, apiv1.CodeNotFound
+	case errors.Is(err, domain.ErrExpired):
+		status, code = http.StatusGone, apiv1.CodeExpired
+	case errors.Is(err, domain.ErrLimitExceeded):
+		status, code = http.StatusGone, apiv1.CodeLimitExceeded
+	case errors.Is(err, domain.ErrInvalidInput):
+		status, code = http.StatusBadRequest, apiv1.CodeInvalidInput
+	case errors.Is(err, domain.ErrTooLarge):
+		status, code = http.StatusRequestEntityTooLarge, apiv1.CodeTooLarge
+	case errors.Is(err, domain.ErrUnauthorized):
+		status, code = http.StatusUnauthorized, apiv1.CodeUnauthorized
+		w.Header().Set("WWW-Authenticate", `Bearer realm="quip"`)
+	case errors.Is(err, domain.ErrForbidden):
+		status, code = http.StatusForbidden, apiv1.CodeForbidden
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		status, code = http.StatusRequestEntityTooLarge, apiv1.CodeQuotaExceeded
+	case errors.Is(err, domain.ErrStorageFull):
+		status, code = http.StatusInsufficientStorage, apiv1.CodeStorageFull
+	case errors.Is(err, domain.ErrConflict):
+		status, code = http.StatusConflict, apiv1.CodeConflict
+	}
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		log.Error("Internal server error", "error", err)
+		message = "internal server error"
+	}
+	writeJSON(w, log, status, apiv1.Error{Code: code, Message: message})
+}
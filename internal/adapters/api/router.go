@@ -1,32 +1,64 @@
 package api
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+// Dates announced for the unversioned /api/... aliases
+var (
+	legacyDeprecatedAt = time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	legacySunsetAt     = time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC)
 )
 
+// route is an API endpoint, with its path relative to the version prefix
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+	access  access
+	// legacy is the unversioned /api/... path still served for this route, if any
+	legacy string
+}
+
 func NewRouter(handlers *Handlers) http.Handler {
 	mux := http.NewServeMux()
 
-	// File routes
-	fileHandler := handlers.fileHandler
-	mux.HandleFunc("POST /api/file", fileHandler.UploadFile)
-	mux.HandleFunc("GET /api/file/{id}", fileHandler.DownloadFile)
-	mux.HandleFunc("GET /api/file/{id}/info", fileHandler.GetFileInfo)
-	mux.HandleFunc("DELETE /api/file/{id}", fileHandler.DeleteFile)
-
-	// Paste routes
-	pasteHandler := handlers.pasteHandler
-	mux.HandleFunc("POST /api/paste", pasteHandler.CreatePaste)
-	mux.HandleFunc("GET /api/paste/{id}", pasteHandler.GetPaste)
-	mux.HandleFunc("GET /api/paste/{id}/raw", pasteHandler.GetRawPaste)
-	mux.HandleFunc("DELETE /api/paste/{id}", pasteHandler.DeletePaste)
-
-	// Universal viewer
-	viewerHandler := handlers.viewHandler
-	mux.HandleFunc("GET /api/{id}", viewerHandler.GetContent)
-	mux.HandleFunc("GET /api/view/{id}", viewerHandler.ViewContent)
+	// Legacy paths below /api/{id}/ overlap /api/file/{id} and its siblings
+	// in a way ServeMux refuses, so they share one less specific pattern per
+	// method and are told apart by the name after the ID
+	belowID := map[string]map[string]http.Handler{}
+	for _, rt := range handlers.routes() {
+		handler := handlers.authorize(rt.access, rt.handler)
+		mux.HandleFunc(rt.method+" "+apiv1.Prefix+rt.path, handler)
+		if rt.legacy == "" {
+			continue
+		}
+		legacy := deprecated(apiv1.Prefix+rt.path, handler)
+		if name, ok := strings.CutPrefix(rt.legacy, "/api/{id}/"); ok {
+			if belowID[rt.method] == nil {
+				belowID[rt.method] = map[string]http.Handler{}
+			}
+			belowID[rt.method][name] = legacy
+			continue
+		}
+		mux.Handle(rt.method+" "+rt.legacy, legacy)
+	}
+	for method, byName := range belowID {
+		mux.HandleFunc(method+" /api/{id}/{name}", func(w http.ResponseWriter, r *http.Request) {
+			if handler, ok := byName[r.PathValue("name")]; ok {
+				handler.ServeHTTP(w, r)
+				return
+			}
+			http.NotFound(w, r)
+		})
+	}
 
 	// Health check
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
@@ -38,16 +70,108 @@ func NewRouter(handlers *Handlers) http.Handler {
 	})
 
 	// Apply middlewares
-	loggedMux := requestLogger(handlers.log, mux)
-	return corsMiddleware(loggedMux)
+	authenticated := authenticate(handlers.authService, handlers.log.With("handler", "auth"), mux)
+	loggedMux := requestLogger(handlers.log, authenticated)
+	return corsMiddleware(handlers.opts.CORSOrigins, loggedMux)
+}
+
+func (h *Handlers) routes() []route {
+	fileHandler := h.fileHandler
+	bundleHandler := h.bundleHandler
+	pasteHandler := h.pasteHandler
+	viewerHandler := h.viewHandler
+	diffHandler := h.diffHandler
+	configHandler := h.configHandler
+	meHandler := h.meHandler
+
+	return []route{
+		// File routes
+		{"GET", "/files", fileHandler.ListFiles, accessPrivate, ""},
+		{"POST", "/file", fileHandler.UploadFile, accessCreate, "/api/file"},
+		{"GET", "/file/{id}", fileHandler.DownloadFile, accessPublic, "/api/file/{id}"},
+		{"GET", "/file/{id}/info", fileHandler.GetFileInfo, accessPublic, "/api/file/{id}/info"},
+		{"DELETE", "/file/{id}", fileHandler.DeleteFile, accessManage, "/api/file/{id}"},
+
+		// Bundle routes
+		{"POST", "/bundle", bundleHandler.UploadBundle, accessCreate, ""},
+		{"GET", "/bundle/{id}", bundleHandler.GetBundle, accessPublic, ""},
+		{"GET", "/bundle/{id}/zip", bundleHandler.DownloadBundleZip, accessPublic, ""},
+		{"GET", "/bundle/{id}/tar.gz", bundleHandler.DownloadBundleTarGz, accessPublic, ""},
+		{"DELETE", "/bundle/{id}", bundleHandler.DeleteBundle, accessManage, ""},
+
+		// Paste routes
+		{"GET", "/pastes", pasteHandler.ListPastes, accessPrivate, ""},
+		{"GET", "/pastes/search", pasteHandler.SearchPastes, accessPrivate, ""},
+		{"POST", "/paste", pasteHandler.CreatePaste, accessCreate, "/api/paste"},
+		{"GET", "/paste/{id}", pasteHandler.GetPaste, accessPublic, "/api/paste/{id}"},
+		{"GET", "/paste/{id}/raw", pasteHandler.GetRawPaste, accessPublic, "/api/paste/{id}/raw"},
+		{"GET", "/paste/{id}/raw/{filename}", pasteHandler.GetRawPasteFile, accessPublic, ""},
+		{"GET", "/paste/{id}/zip", pasteHandler.DownloadPasteZip, accessPublic, ""},
+		{"PATCH", "/paste/{id}", pasteHandler.EditPaste, accessManage, ""},
+		{"POST", "/paste/{id}/fork", pasteHandler.ForkPaste, accessCreate, ""},
+		{"GET", "/paste/{id}/revisions", pasteHandler.ListRevisions, accessPublic, ""},
+		{"GET", "/paste/{id}/revisions/{n}/raw", pasteHandler.GetRawRevision, accessPublic, ""},
+		{"GET", "/paste/{id}/diff", pasteHandler.DiffRevisions, accessPublic, ""},
+		{"DELETE", "/paste/{id}", pasteHandler.DeletePaste, accessManage, "/api/paste/{id}"},
+
+		// Diffs
+		{"GET", "/diff", diffHandler.DiffPastes, accessPublic, ""},
+		{"POST", "/diff", diffHandler.DiffText, accessPublic, ""},
+
+		// Universal viewer
+		{"GET", "/content/{id}", viewerHandler.GetContent, accessPublic, "/api/{id}"},
+		{"GET", "/view/{id}", viewerHandler.ViewContent, accessPublic, "/api/view/{id}"},
+		{"GET", "/content/{id}/qr.png", viewerHandler.QRCodePNG, accessPublic, "/api/{id}/qr.png"},
+		{"GET", "/content/{id}/qr.svg", viewerHandler.QRCodeSVG, accessPublic, "/api/{id}/qr.svg"},
+
+		// Server configuration
+		{"GET", "/config", configHandler.GetConfig, accessPublic, ""},
+		{"GET", "/languages", configHandler.GetLanguages, accessPublic, ""},
+
+		// Authenticated user
+		{"GET", "/me", meHandler.GetMe, accessPrivate, ""},
+		{"GET", "/me/usage", meHandler.GetUsage, accessPrivate, ""},
+	}
+}
+
+// deprecated serves an unversioned alias, pointing clients at its v1 successor
+func deprecated(successor string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", fmt.Sprintf("@%d", legacyDeprecatedAt.Unix()))
+		w.Header().Set("Sunset", legacySunsetAt.Format(http.TimeFormat))
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, expandPath(successor, r)))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// expandPath fills the {wildcards} of a route path from the request
+func expandPath(path string, r *http.Request) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = r.PathValue(strings.Trim(segment, "{}"))
+		}
+	}
+	return strings.Join(segments, "/")
 }
 
 // Manual CORS implementation
-func corsMiddleware(next http.Handler) http.Handler {
+func corsMiddleware(allowedOrigins []string, next http.Handler) http.Handler {
+	allowAny := slices.Contains(allowedOrigins, "*")
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
+		origin := r.Header.Get("Origin")
+		switch {
+		case allowAny:
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		case origin != "" && slices.Contains(allowedOrigins, origin):
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+		// The "*" wildcard does not cover Authorization, so name it explicitly
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, *")
+		w.Header().Set("Access-Control-Expose-Headers", "Deprecation, Sunset, Link, "+apiv1.HeaderResumeToken)
 
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func testOptions() Options {
+	return Options{
+		MaxFileSize:    1 << 20,
+		MaxPasteSize:   1 << 10,
+		TTL:            domain.TTLPolicy{Default: time.Hour, Min: time.Minute, Max: 24 * time.Hour},
+		CORSOrigins:    []string{"*"},
+		AllowAnonymous: true,
+	}
+}
+
+// newTestRouter builds a router without file or paste services, enough to
+// exercise everything that happens before a request reaches them
+func newTestRouter(opts Options, auth *services.AuthService) http.Handler {
+	return NewRouter(NewHandlers(nil, nil, auth, nil, testLog, opts))
+}
+
+func TestLegacyRoutesAreDeprecated(t *testing.T) {
+	router := newTestRouter(testOptions(), nil)
+
+	t.Run("legacy alias", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/file", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "@1793491200", rec.Header().Get("Deprecation"))
+		assert.Equal(t, "Sat, 01 May 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+		assert.Equal(t, `</api/v1/file>; rel="successor-version"`, rec.Header().Get("Link"))
+
+		var body apiv1.Error
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, apiv1.CodeInvalidInput, body.Code)
+	})
+
+	t.Run("versioned route", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/file", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, rec.Header().Get("Deprecation"))
+		assert.Empty(t, rec.Header().Get("Sunset"))
+	})
+
+	t.Run("legacy alias below an ID", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/abc123/qr.png?scale=0", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, `</api/v1/content/abc123/qr.png>; rel="successor-version"`, rec.Header().Get("Link"))
+
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/abc123/qr.gif", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestExpandPath(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/abc123", nil)
+	req.SetPathValue("id", "abc123")
+
+	assert.Equal(t, "/api/v1/content/abc123", expandPath("/api/v1/content/{id}", req))
+	assert.Equal(t, "/api/v1/paste", expandPath("/api/v1/paste", req))
+}
+
+func TestLanguages(t *testing.T) {
+	router := newTestRouter(testOptions(), nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiv1.LanguagesPath(), nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var list apiv1.LanguageList
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&list))
+	assert.Contains(t, list.Languages, "Go")
+	assert.IsNonDecreasing(t, list.Languages)
+}
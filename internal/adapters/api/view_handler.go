@@ -1,26 +1,99 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"html/template"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
 	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/qr"
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
 )
 
 type ViewHandler struct {
 	pasteService *services.PasteService
 	fileService  *services.FileService
+	opts         Options
 	log          *slog.Logger
 }
 
-// Get content
+// Universal content lookup handler
 func (h *ViewHandler) GetContent(w http.ResponseWriter, r *http.Request) {
-	// [TODO] Implement
-	h.log.Warn("GetContent handler is not implemented")
-	fmt.Fprintf(w, "Not implemented yet") // Placeholder for now
+	id := r.PathValue("id")
+	logger := h.log.With("content_id", id, "remote_addr", r.RemoteAddr)
+	logger.Debug("Resolving content")
+
+	// Try as paste first
+	paste, err := h.pasteService.Get(r.Context(), id)
+	if err == nil {
+		writeJSON(w, logger, http.StatusOK, apiv1.Content{Kind: apiv1.KindPaste, Paste: toPasteDTO(paste)})
+		return
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		writeError(w, logger, err)
+		return
+	}
+
+	// Try as file
+	file, err := h.fileService.GetInfo(r.Context(), id)
+	if err == nil {
+		writeJSON(w, logger, http.StatusOK, apiv1.Content{Kind: apiv1.KindFile, File: toFileDTO(file)})
+		return
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		writeError(w, logger, err)
+		return
+	}
+
+	// Try as bundle
+	bundle, err := h.fileService.GetBundle(r.Context(), id)
+	if err != nil {
+		logger.Warn("Content not found", "error", err)
+		writeError(w, logger, err)
+		return
+	}
+	writeJSON(w, logger, http.StatusOK, apiv1.Content{Kind: apiv1.KindBundle, Bundle: toBundleDTO(bundle)})
 }
 
+// Viewer pages, escaped by html/template since they show user content
+var (
+	pasteViewTemplate = template.Must(template.New("paste").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{if .Title}}{{.Title}}{{else}}Paste {{.ID}}{{end}}</title></head>
+<body>
+{{if .Title}}<h1>{{.Title}}</h1>{{end}}
+<p>{{.Language}}{{if gt .Revision 1}} · revision {{.Revision}}{{end}}{{if .ParentID}} · forked from <a href="{{.ParentView}}">{{.ParentID}}</a>{{end}}</p>
+{{if .Files}}{{range .Files}}
+<h2>{{.Name}}</h2>
+<p>{{.Language}} · <a href="{{.Raw}}">Raw</a></p>
+<pre>{{.Content}}</pre>
+{{end}}<p><a href="{{.Zip}}">Download all as zip</a></p>
+{{else}}<pre>{{.Content}}</pre>
+<p><a href="{{.Raw}}">Raw</a></p>
+{{end}}</body></html>
+`))
+	fileViewTemplate = template.Must(template.New("file").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Name}}</title></head>
+<body><h1>{{.Name}}</h1><p>Size: {{.Size}} bytes</p><a href="{{.Download}}">Download</a></body></html>
+`))
+	bundleViewTemplate = template.Must(template.New("bundle").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{if .Title}}{{.Title}}{{else}}Bundle {{.ID}}{{end}}</title></head>
+<body>
+<h1>{{if .Title}}{{.Title}}{{else}}Bundle {{.ID}}{{end}}</h1>
+<p>{{len .Files}} files · {{.Size}} bytes{{if .ExpiresAt}} · expires {{.ExpiresAt.Format "2006-01-02 15:04 MST"}}{{end}}</p>
+<table>
+<tr><th>Name</th><th>Type</th><th>Size</th></tr>
+{{range .Files}}<tr><td><a href="{{.Download}}">{{.Filename}}</a></td><td>{{.ContentType}}</td><td>{{.Size}}</td></tr>
+{{end}}</table>
+<p>Download all: <a href="{{.Zip}}">zip</a> · <a href="{{.TarGz}}">tar.gz</a></p>
+</body></html>
+`))
+)
+
 // Universal content viewer handler
 func (h *ViewHandler) ViewContent(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -30,26 +103,157 @@ func (h *ViewHandler) ViewContent(w http.ResponseWriter, r *http.Request) {
 	// Try as paste first
 	paste, err := h.pasteService.Get(r.Context(), id)
 	if err == nil {
-		// Render paste view
-		// In real implementation, this would serve the React app
-		// with the paste data
-		w.Header().Set("Content-Type", "text/html")
-		fmt.Fprintf(w, "<html><body><pre>%s</pre></body></html>", paste.Content)
 		logger.Debug("Serving as paste")
+		var parentView string
+		if paste.ParentID != "" {
+			parentView = apiv1.ViewPath(paste.ParentID)
+		}
+		h.render(w, logger, pasteViewTemplate, map[string]any{
+			"ID":         paste.ID,
+			"Title":      paste.Title,
+			"Language":   paste.Language,
+			"Revision":   paste.Revision,
+			"Content":    paste.Content,
+			"Raw":        apiv1.PasteRawPath(paste.ID),
+			"ParentID":   paste.ParentID,
+			"ParentView": parentView,
+			"Files":      toPasteFileDTOs(paste, true),
+			"Zip":        apiv1.PasteZipPath(paste.ID),
+		})
+		return
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		writeError(w, logger, err)
 		return
 	}
 
-	// Try as file
-	file, err := h.fileService.GetInfo(r.Context(), id)
+	// Try as file, which is only offered while it can be downloaded
+	err = h.fileService.Downloadable(r.Context(), id)
 	if err == nil {
-		// Render file view
-		w.Header().Set("Content-Type", "text/html")
-		fmt.Fprintf(w, "<html><body><h1>%s</h1><p>Size: %d bytes</p><a href='/files/%s'>Download</a></body></html>",
-			file.OriginalName, file.Size, file.ID)
+		file, err := h.fileService.GetInfo(r.Context(), id)
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
 		logger.Debug("Serving as file")
+		h.render(w, logger, fileViewTemplate, map[string]any{
+			"Name":     file.OriginalName,
+			"Size":     file.Size,
+			"Download": apiv1.FilePath(file.ID),
+		})
+		return
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		writeError(w, logger, err)
+		return
+	}
+
+	// Try as bundle
+	bundle, err := h.fileService.GetBundle(r.Context(), id)
+	if err != nil {
+		logger.Warn("Content not found", "error", err)
+		writeError(w, logger, err)
 		return
 	}
+	logger.Debug("Serving as bundle")
+	h.render(w, logger, bundleViewTemplate, toBundleDTO(bundle))
+}
 
-	logger.Warn("Content not found")
-	http.Error(w, "Content not found", http.StatusNotFound)
+func (h *ViewHandler) render(w http.ResponseWriter, log *slog.Logger, tmpl *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Error("Failed to render view", "error", err)
+	}
+}
+
+// Pixels per module of PNG QR codes, see the scale query parameter
+const (
+	defaultQRScale = 8
+	maxQRScale     = 32
+)
+
+// QRCodePNG serves a QR code of the viewer link of a file, paste or bundle
+// as a PNG image
+func (h *ViewHandler) QRCodePNG(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	logger := h.log.With("content_id", id, "remote_addr", r.RemoteAddr)
+
+	scale := defaultQRScale
+	if raw := r.URL.Query().Get("scale"); raw != "" {
+		var err error
+		if scale, err = strconv.Atoi(raw); err != nil || scale < 1 || scale > maxQRScale {
+			writeError(w, logger, fmt.Errorf("%w: scale must be between 1 and %d", domain.ErrInvalidInput, maxQRScale))
+			return
+		}
+	}
+
+	code, ok := h.qrCode(w, r, logger, id)
+	if !ok {
+		return
+	}
+	data, err := code.PNG(scale)
+	if err != nil {
+		writeError(w, logger, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if _, err := w.Write(data); err != nil {
+		logger.Warn("Failed to write QR code", "error", err)
+	}
+}
+
+// QRCodeSVG serves a QR code of the viewer link of a file, paste or bundle
+// as an SVG image
+func (h *ViewHandler) QRCodeSVG(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	logger := h.log.With("content_id", id, "remote_addr", r.RemoteAddr)
+
+	code, ok := h.qrCode(w, r, logger, id)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	if err := code.WriteSVG(w); err != nil {
+		logger.Warn("Failed to write QR code", "error", err)
+	}
+}
+
+// qrCode encodes the absolute viewer link of content that can still be
+// viewed. Looking pastes up this way does not count a view. Errors are
+// written to w.
+func (h *ViewHandler) qrCode(w http.ResponseWriter, r *http.Request, logger *slog.Logger, id string) (*qr.Code, bool) {
+	err := h.pasteService.Viewable(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		err = h.fileService.Downloadable(r.Context(), id)
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		_, err = h.fileService.GetBundle(r.Context(), id)
+	}
+	if err != nil {
+		writeError(w, logger, err)
+		return nil, false
+	}
+
+	code, err := qr.Encode(publicURL(r, h.opts.PublicURL)+apiv1.ViewPath(id), qr.Medium)
+	if err != nil {
+		writeError(w, logger, err)
+		return nil, false
+	}
+	logger.Debug("Serving QR code", "version", code.Version)
+	return code, true
+}
+
+// publicURL returns the base of absolute links: the configured public URL,
+// or the one the request was sent to. X-Forwarded-* headers are ignored, as
+// any client can set them; servers behind a proxy must be configured.
+func publicURL(r *http.Request, configured string) string {
+	if configured != "" {
+		return strings.TrimSuffix(configured, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
 }
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicURL(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "http://quip.internal:8080/", nil)
+	assert.Equal(t, "http://quip.internal:8080", publicURL(r, ""))
+	assert.Equal(t, "https://quip.example", publicURL(r, "https://quip.example/"), "configured URLs win")
+
+	r.TLS = &tls.ConnectionState{}
+	assert.Equal(t, "https://quip.internal:8080", publicURL(r, ""))
+
+	r.Header.Set("X-Forwarded-Proto", "http")
+	r.Header.Set("X-Forwarded-Host", "evil.example")
+	assert.Equal(t, "https://quip.internal:8080", publicURL(r, ""), "clients can forge forwarded headers")
+}
+
+func TestQRCodeScale(t *testing.T) {
+	router := newTestRouter(testOptions(), nil)
+	for _, scale := range []string{"0", "33", "big"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiv1.ContentQRPath("abc", "png")+"?scale="+scale, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "scale %s", scale)
+	}
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/ports"
+)
+
+// Bundles are stored alongside the files they group
+var _ ports.BundleRepository = (*Repository)(nil)
+
+func (r *Repository) StoreBundle(ctx context.Context, bundle *domain.Bundle) error {
+	return r.inTx(ctx, func(q *Queries) error {
+		err := q.CreateBundle(ctx, CreateBundleParams{
+			ID:           bundle.ID,
+			Title:        toNullString(bundle.Title),
+			Downloads:    int32(bundle.Downloads),
+			MaxDownloads: int32(bundle.MaxDownloads),
+			CreatedAt:    bundle.CreatedAt,
+			ExpiresAt:    toNullTime(bundle.ExpiresAt),
+
+			OwnerID:         toNullString(bundle.OwnerID),
+			ManageTokenHash: toNullString(bundle.ManageTokenHash),
+		})
+		if err != nil {
+			return err
+		}
+		for i, file := range bundle.Files {
+			if _, err := q.CreateFile(ctx, toFileParams(file, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Repository) FindBundleByID(ctx context.Context, id string) (*domain.Bundle, error) {
+	row, err := r.queries.GetBundleByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.queries.ListBundleFiles(ctx, toNullString(id))
+	if err != nil {
+		return nil, err
+	}
+	bundle := &domain.Bundle{
+		ID:           row.ID,
+		Title:        row.Title.String,
+		Files:        make([]*domain.File, len(rows)),
+		Downloads:    int(row.Downloads),
+		MaxDownloads: int(row.MaxDownloads),
+		CreatedAt:    row.CreatedAt,
+		ExpiresAt:    row.ExpiresAt.Time,
+		OwnerID:      row.OwnerID.String,
+
+		ManageTokenHash: row.ManageTokenHash.String,
+	}
+	for i, f := range rows {
+		bundle.Files[i] = toFile(f)
+	}
+	return bundle, nil
+}
+
+func (r *Repository) IncrementBundleDownloads(ctx context.Context, id string) error {
+	return r.queries.IncrementBundleDownloads(ctx, id)
+}
+
+func (r *Repository) DeleteBundle(ctx context.Context, id string) ([]*domain.File, error) {
+	var files []*domain.File
+	err := r.inTx(ctx, func(q *Queries) error {
+		rows, err := q.DeleteBundleFiles(ctx, toNullString(id))
+		if err != nil {
+			return err
+		}
+		files = make([]*domain.File, len(rows))
+		for i, row := range rows {
+			files[i] = toFile(row)
+		}
+		return q.DeleteBundle(ctx, id)
+	})
+	return files, err
+}
+
+func (r *Repository) DeleteExpiredBundles(ctx context.Context) error {
+	return r.queries.DeleteExpiredBundles(ctx)
+}
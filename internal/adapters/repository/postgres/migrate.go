@@ -0,0 +1,17 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+)
+
+//go:embed schema.sql
+var schema string
+
+// Migrate brings the database schema up to date. The schema is idempotent, so
+// this is safe to run on every startup.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, schema)
+	return err
+}
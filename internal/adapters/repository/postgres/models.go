@@ -9,25 +9,83 @@ import (
 	"time"
 )
 
+type ApiKey struct {
+	ID         string       `json:"id"`
+	UserID     string       `json:"user_id"`
+	Name       string       `json:"name"`
+	SecretHash string       `json:"secret_hash"`
+	Scopes     []string     `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+}
+
+type Bundle struct {
+	ID              string         `json:"id"`
+	Title           sql.NullString `json:"title"`
+	Downloads       int32          `json:"downloads"`
+	MaxDownloads    int32          `json:"max_downloads"`
+	CreatedAt       time.Time      `json:"created_at"`
+	ExpiresAt       sql.NullTime   `json:"expires_at"`
+	OwnerID         sql.NullString `json:"owner_id"`
+	ManageTokenHash sql.NullString `json:"manage_token_hash"`
+}
+
 type File struct {
-	ID           string    `json:"id"`
-	OriginalName string    `json:"original_name"`
-	Size         int64     `json:"size"`
-	ContentType  string    `json:"content_type"`
-	StorageKey   string    `json:"storage_key"`
-	Downloads    int32     `json:"downloads"`
-	MaxDownloads int32     `json:"max_downloads"`
-	CreatedAt    time.Time `json:"created_at"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	ID              string         `json:"id"`
+	OriginalName    string         `json:"original_name"`
+	Size            int64          `json:"size"`
+	ContentType     string         `json:"content_type"`
+	StorageKey      string         `json:"storage_key"`
+	Downloads       int32          `json:"downloads"`
+	MaxDownloads    int32          `json:"max_downloads"`
+	CreatedAt       time.Time      `json:"created_at"`
+	ExpiresAt       sql.NullTime   `json:"expires_at"`
+	OwnerID         sql.NullString `json:"owner_id"`
+	ManageTokenHash sql.NullString `json:"manage_token_hash"`
+	BundleID        sql.NullString `json:"bundle_id"`
+	BundlePosition  sql.NullInt32  `json:"bundle_position"`
 }
 
 type Paste struct {
-	ID        string         `json:"id"`
+	ID              string         `json:"id"`
+	Content         string         `json:"content"`
+	Language        string         `json:"language"`
+	Title           sql.NullString `json:"title"`
+	Views           int32          `json:"views"`
+	MaxViews        int32          `json:"max_views"`
+	CreatedAt       time.Time      `json:"created_at"`
+	ExpiresAt       sql.NullTime   `json:"expires_at"`
+	OwnerID         sql.NullString `json:"owner_id"`
+	ManageTokenHash sql.NullString `json:"manage_token_hash"`
+	Size            sql.NullInt64  `json:"size"`
+	Revision        int32          `json:"revision"`
+	UpdatedAt       sql.NullTime   `json:"updated_at"`
+	ParentID        sql.NullString `json:"parent_id"`
+	Filename        sql.NullString `json:"filename"`
+}
+
+type PasteFile struct {
+	PasteID  string        `json:"paste_id"`
+	Position int32         `json:"position"`
+	Name     string        `json:"name"`
+	Content  string        `json:"content"`
+	Language string        `json:"language"`
+	Size     sql.NullInt64 `json:"size"`
+}
+
+type PasteRevision struct {
+	PasteID   string         `json:"paste_id"`
+	Number    int32          `json:"number"`
 	Content   string         `json:"content"`
 	Language  string         `json:"language"`
 	Title     sql.NullString `json:"title"`
-	Views     int32          `json:"views"`
-	MaxViews  int32          `json:"max_views"`
+	Size      sql.NullInt64  `json:"size"`
 	CreatedAt time.Time      `json:"created_at"`
-	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -6,17 +6,56 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 )
 
 type Querier interface {
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
+	CreateBundle(ctx context.Context, arg CreateBundleParams) error
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
 	CreatePaste(ctx context.Context, arg CreatePasteParams) (Paste, error)
-	DeleteExpiredFiles(ctx context.Context) error
+	CreatePasteFile(ctx context.Context, arg CreatePasteFileParams) error
+	CreatePasteRevision(ctx context.Context, arg CreatePasteRevisionParams) error
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteBundle(ctx context.Context, id string) error
+	DeleteBundleFiles(ctx context.Context, bundleID sql.NullString) ([]File, error)
+	DeleteExpiredBundles(ctx context.Context) error
+	DeleteExpiredFiles(ctx context.Context) ([]File, error)
 	DeleteExpiredPastes(ctx context.Context) error
+	DeleteFile(ctx context.Context, id string) error
+	DeletePaste(ctx context.Context, id string) error
+	GetAPIKeyByID(ctx context.Context, id string) (ApiKey, error)
+	GetBundleByID(ctx context.Context, id string) (Bundle, error)
 	GetFileByID(ctx context.Context, id string) (File, error)
+	GetOwnerUsage(ctx context.Context, ownerID sql.NullString) (GetOwnerUsageRow, error)
 	GetPasteByID(ctx context.Context, id string) (Paste, error)
+	GetPasteRevision(ctx context.Context, arg GetPasteRevisionParams) (PasteRevision, error)
+	GetTotalUsage(ctx context.Context) (GetTotalUsageRow, error)
+	GetUserByID(ctx context.Context, id string) (User, error)
+	GetUserByName(ctx context.Context, name string) (User, error)
+	IncrementBundleDownloads(ctx context.Context, id string) error
 	IncrementFileDownloads(ctx context.Context, id string) error
 	IncrementPasteViews(ctx context.Context, id string) error
+	ListAPIKeysByUser(ctx context.Context, userID string) ([]ApiKey, error)
+	ListBundleFiles(ctx context.Context, bundleID sql.NullString) ([]File, error)
+	// Listing is keyset paginated: each sort has its own query and index, and
+	// a page starts after the (sort key, id) of the previous page's last row.
+	// Permanent content sorts last by expiry, as if it expired in 9999.
+	ListFilesByCreated(ctx context.Context, arg ListFilesByCreatedParams) ([]File, error)
+	ListFilesByExpires(ctx context.Context, arg ListFilesByExpiresParams) ([]File, error)
+	ListFilesBySize(ctx context.Context, arg ListFilesBySizeParams) ([]File, error)
+	ListPasteFiles(ctx context.Context, pasteID string) ([]PasteFile, error)
+	ListPasteRevisions(ctx context.Context, pasteID string) ([]ListPasteRevisionsRow, error)
+	ListPastesByCreated(ctx context.Context, arg ListPastesByCreatedParams) ([]Paste, error)
+	ListPastesByExpires(ctx context.Context, arg ListPastesByExpiresParams) ([]Paste, error)
+	ListPastesBySize(ctx context.Context, arg ListPastesBySizeParams) ([]Paste, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	RevokeAPIKey(ctx context.Context, id string) (int64, error)
+	// Pastes of a single text use the GIN index on their content. Multi-file
+	// pastes are matched on all their files at once, read from paste_files.
+	SearchPastes(ctx context.Context, arg SearchPastesParams) ([]SearchPastesRow, error)
+	TouchAPIKey(ctx context.Context, id string) error
+	UpdatePaste(ctx context.Context, arg UpdatePasteParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -1,32 +1,274 @@
 -- name: CreateFile :one
 INSERT INTO files (
     id, original_name, size, content_type, storage_key,
-    downloads, max_downloads, created_at, expires_at
+    downloads, max_downloads, created_at, expires_at,
+    owner_id, manage_token_hash, bundle_id, bundle_position
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
 ) RETURNING *;
 
 -- name: GetFileByID :one
 SELECT * FROM files WHERE id = $1 LIMIT 1;
 
+-- Listing is keyset paginated: each sort has its own query and index, and
+-- a page starts after the (sort key, id) of the previous page's last row.
+-- Permanent content sorts last by expiry, as if it expired in 9999.
+
+-- name: ListFilesByCreated :many
+SELECT * FROM files
+WHERE (sqlc.narg(owner_id)::text IS NULL OR owner_id = sqlc.narg(owner_id))
+  AND (sqlc.narg(kind)::text IS NULL OR content_type LIKE sqlc.narg(kind) || '/%')
+  AND (sqlc.narg(content_type)::text IS NULL OR content_type = sqlc.narg(content_type))
+  AND (sqlc.narg(created_after)::timestamp IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamp IS NULL OR created_at < sqlc.narg(created_before))
+  AND (sqlc.narg(expires_before)::timestamp IS NULL OR expires_at <= sqlc.narg(expires_before))
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (sqlc.narg(after_time)::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg(after_time)::timestamp, sqlc.arg(after_id)::text))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListFilesByExpires :many
+SELECT * FROM files
+WHERE (sqlc.narg(owner_id)::text IS NULL OR owner_id = sqlc.narg(owner_id))
+  AND (sqlc.narg(kind)::text IS NULL OR content_type LIKE sqlc.narg(kind) || '/%')
+  AND (sqlc.narg(content_type)::text IS NULL OR content_type = sqlc.narg(content_type))
+  AND (sqlc.narg(created_after)::timestamp IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamp IS NULL OR created_at < sqlc.narg(created_before))
+  AND (sqlc.narg(expires_before)::timestamp IS NULL OR expires_at <= sqlc.narg(expires_before))
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (sqlc.narg(after_time)::timestamp IS NULL
+       OR (COALESCE(expires_at, '9999-12-31'::timestamp), id) > (sqlc.narg(after_time)::timestamp, sqlc.arg(after_id)::text))
+ORDER BY COALESCE(expires_at, '9999-12-31'::timestamp), id
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListFilesBySize :many
+SELECT * FROM files
+WHERE (sqlc.narg(owner_id)::text IS NULL OR owner_id = sqlc.narg(owner_id))
+  AND (sqlc.narg(kind)::text IS NULL OR content_type LIKE sqlc.narg(kind) || '/%')
+  AND (sqlc.narg(content_type)::text IS NULL OR content_type = sqlc.narg(content_type))
+  AND (sqlc.narg(created_after)::timestamp IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamp IS NULL OR created_at < sqlc.narg(created_before))
+  AND (sqlc.narg(expires_before)::timestamp IS NULL OR expires_at <= sqlc.narg(expires_before))
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (sqlc.narg(after_size)::bigint IS NULL
+       OR (size, id) < (sqlc.narg(after_size)::bigint, sqlc.arg(after_id)::text))
+ORDER BY size DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
 -- name: IncrementFileDownloads :exec
 UPDATE files SET downloads = downloads + 1 WHERE id = $1;
 
--- name: DeleteExpiredFiles :exec
-DELETE FROM files WHERE expires_at < NOW();
+-- name: DeleteFile :exec
+DELETE FROM files WHERE id = $1;
+
+-- name: DeleteExpiredFiles :many
+DELETE FROM files WHERE expires_at < NOW() RETURNING *;
+
+-- name: CreateBundle :exec
+INSERT INTO bundles (
+    id, title, downloads, max_downloads, created_at, expires_at,
+    owner_id, manage_token_hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+);
+
+-- name: GetBundleByID :one
+SELECT * FROM bundles WHERE id = $1 LIMIT 1;
+
+-- name: ListBundleFiles :many
+SELECT * FROM files WHERE bundle_id = $1 ORDER BY bundle_position;
+
+-- name: IncrementBundleDownloads :exec
+UPDATE bundles SET downloads = downloads + 1 WHERE id = $1;
+
+-- name: DeleteBundleFiles :many
+DELETE FROM files WHERE bundle_id = $1 RETURNING *;
+
+-- name: DeleteBundle :exec
+DELETE FROM bundles WHERE id = $1;
+
+-- name: DeleteExpiredBundles :exec
+DELETE FROM bundles WHERE expires_at < NOW();
 
 -- name: CreatePaste :one
 INSERT INTO pastes (
-    id, content, language, title, views, max_views, created_at, expires_at
+    id, content, language, title, views, max_views, created_at, expires_at,
+    owner_id, manage_token_hash, revision, parent_id, filename
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
 ) RETURNING *;
 
+-- name: CreatePasteFile :exec
+INSERT INTO paste_files (paste_id, position, name, content, language)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListPasteFiles :many
+SELECT * FROM paste_files WHERE paste_id = $1 ORDER BY position;
+
 -- name: GetPasteByID :one
 SELECT * FROM pastes WHERE id = $1 LIMIT 1;
 
+-- name: ListPastesByCreated :many
+SELECT * FROM pastes
+WHERE (sqlc.narg(owner_id)::text IS NULL OR owner_id = sqlc.narg(owner_id))
+  AND (sqlc.narg(language)::text IS NULL OR language = sqlc.narg(language))
+  AND (sqlc.narg(created_after)::timestamp IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamp IS NULL OR created_at < sqlc.narg(created_before))
+  AND (sqlc.narg(expires_before)::timestamp IS NULL OR expires_at <= sqlc.narg(expires_before))
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (sqlc.narg(after_time)::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg(after_time)::timestamp, sqlc.arg(after_id)::text))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListPastesByExpires :many
+SELECT * FROM pastes
+WHERE (sqlc.narg(owner_id)::text IS NULL OR owner_id = sqlc.narg(owner_id))
+  AND (sqlc.narg(language)::text IS NULL OR language = sqlc.narg(language))
+  AND (sqlc.narg(created_after)::timestamp IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamp IS NULL OR created_at < sqlc.narg(created_before))
+  AND (sqlc.narg(expires_before)::timestamp IS NULL OR expires_at <= sqlc.narg(expires_before))
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (sqlc.narg(after_time)::timestamp IS NULL
+       OR (COALESCE(expires_at, '9999-12-31'::timestamp), id) > (sqlc.narg(after_time)::timestamp, sqlc.arg(after_id)::text))
+ORDER BY COALESCE(expires_at, '9999-12-31'::timestamp), id
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListPastesBySize :many
+SELECT * FROM pastes
+WHERE (sqlc.narg(owner_id)::text IS NULL OR owner_id = sqlc.narg(owner_id))
+  AND (sqlc.narg(language)::text IS NULL OR language = sqlc.narg(language))
+  AND (sqlc.narg(created_after)::timestamp IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamp IS NULL OR created_at < sqlc.narg(created_before))
+  AND (sqlc.narg(expires_before)::timestamp IS NULL OR expires_at <= sqlc.narg(expires_before))
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (sqlc.narg(after_size)::bigint IS NULL
+       OR (size, id) < (sqlc.narg(after_size)::bigint, sqlc.arg(after_id)::text))
+ORDER BY size DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: UpdatePaste :execrows
+UPDATE pastes
+SET content = $2, language = $3, title = $4, revision = $5, updated_at = $6
+WHERE id = $1 AND revision = sqlc.arg(previous_revision);
+
+-- name: CreatePasteRevision :exec
+INSERT INTO paste_revisions (paste_id, number, content, language, title, created_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListPasteRevisions :many
+SELECT paste_id, number, language, title, size, created_at FROM paste_revisions
+WHERE paste_id = $1
+ORDER BY number;
+
+-- name: GetPasteRevision :one
+SELECT * FROM paste_revisions WHERE paste_id = $1 AND number = $2;
+
 -- name: IncrementPasteViews :exec
 UPDATE pastes SET views = views + 1 WHERE id = $1;
 
+-- name: DeletePaste :exec
+DELETE FROM pastes WHERE id = $1;
+
 -- name: DeleteExpiredPastes :exec
-DELETE FROM pastes WHERE expires_at < NOW();
\ No newline at end of file
+DELETE FROM pastes WHERE expires_at < NOW();
+
+-- name: GetOwnerUsage :one
+SELECT
+    ((SELECT COUNT(*) FROM files f WHERE f.owner_id = sqlc.arg(owner_id))
+        + (SELECT COUNT(*) FROM pastes p WHERE p.owner_id = sqlc.arg(owner_id)))::BIGINT AS items,
+    ((SELECT COALESCE(SUM(f.size), 0) FROM files f WHERE f.owner_id = sqlc.arg(owner_id))
+        + (SELECT COALESCE(SUM(p.size), 0) FROM pastes p WHERE p.owner_id = sqlc.arg(owner_id))
+        + (SELECT COALESCE(SUM(r.size), 0) FROM paste_revisions r JOIN pastes p ON p.id = r.paste_id
+           WHERE p.owner_id = sqlc.arg(owner_id) AND r.number < p.revision)
+        + (SELECT COALESCE(SUM(pf.size), 0) FROM paste_files pf JOIN pastes p ON p.id = pf.paste_id
+           WHERE p.owner_id = sqlc.arg(owner_id)))::BIGINT AS bytes;
+
+-- name: GetTotalUsage :one
+SELECT
+    ((SELECT COUNT(*) FROM files) + (SELECT COUNT(*) FROM pastes))::BIGINT AS items,
+    ((SELECT COALESCE(SUM(size), 0) FROM files) + (SELECT COALESCE(SUM(size), 0) FROM pastes)
+        + (SELECT COALESCE(SUM(r.size), 0) FROM paste_revisions r JOIN pastes p ON p.id = r.paste_id
+           WHERE r.number < p.revision)
+        + (SELECT COALESCE(SUM(size), 0) FROM paste_files))::BIGINT AS bytes;
+
+-- name: CreateUser :one
+INSERT INTO users (id, name, created_at) VALUES ($1, $2, $3) RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1 LIMIT 1;
+
+-- name: GetUserByName :one
+SELECT * FROM users WHERE name = $1 LIMIT 1;
+
+-- name: ListUsers :many
+SELECT * FROM users ORDER BY name;
+
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    id, user_id, name, secret_hash, scopes, created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetAPIKeyByID :one
+SELECT * FROM api_keys WHERE id = $1 LIMIT 1;
+
+-- name: ListAPIKeysByUser :many
+SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = NOW() WHERE id = $1;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: SearchPastes :many
+-- Pastes of a single text use the GIN index on their content. Multi-file
+-- pastes are matched on all their files at once, read from paste_files.
+SELECT
+    p.id, p.title, p.language, p.views, p.max_views, p.created_at, p.expires_at, p.owner_id,
+    ts_rank(
+        setweight(to_tsvector('simple', COALESCE(p.title, '')), 'A')
+        || setweight(to_tsvector('simple', left(p.content, 262144)), 'B'),
+        q
+    )::REAL AS rank,
+    ts_headline('simple', left(p.content, 262144), q,
+        E'StartSel=\x01, StopSel=\x02, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=" … "'
+    )::TEXT AS snippet
+FROM pastes p, websearch_to_tsquery('simple', sqlc.arg(query)::text) q
+WHERE p.owner_id = sqlc.arg(owner_id)
+  AND NOT EXISTS (SELECT 1 FROM paste_files f WHERE f.paste_id = p.id)
+  AND (
+      setweight(to_tsvector('simple', COALESCE(p.title, '')), 'A')
+      || setweight(to_tsvector('simple', left(p.content, 262144)), 'B')
+  ) @@ q
+  AND (p.expires_at IS NULL OR p.expires_at > NOW())
+UNION ALL
+SELECT
+    p.id, p.title, p.language, p.views, p.max_views, p.created_at, p.expires_at, p.owner_id,
+    ts_rank(
+        setweight(to_tsvector('simple', COALESCE(p.title, '')), 'A')
+        || setweight(to_tsvector('simple', files.content), 'B'),
+        q
+    )::REAL AS rank,
+    ts_headline('simple', files.content, q,
+        E'StartSel=\x01, StopSel=\x02, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=" … "'
+    )::TEXT AS snippet
+FROM pastes p
+CROSS JOIN websearch_to_tsquery('simple', sqlc.arg(query)::text) q
+CROSS JOIN LATERAL (
+    SELECT left(p.content || E'\n' || string_agg(f.content, E'\n' ORDER BY f.position), 262144) AS content
+    FROM paste_files f
+    WHERE f.paste_id = p.id
+) files
+WHERE p.owner_id = sqlc.arg(owner_id)
+  AND files.content IS NOT NULL
+  AND (
+      setweight(to_tsvector('simple', COALESCE(p.title, '')), 'A')
+      || setweight(to_tsvector('simple', files.content), 'B')
+  ) @@ q
+  AND (p.expires_at IS NULL OR p.expires_at > NOW())
+ORDER BY rank DESC, created_at DESC
+LIMIT sqlc.arg(max_rows);
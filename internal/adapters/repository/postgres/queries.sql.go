@@ -9,27 +9,108 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
+)
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    id, user_id, name, secret_hash, scopes, created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, user_id, name, secret_hash, scopes, created_at, last_used_at, revoked_at
+`
+
+type CreateAPIKeyParams struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Name       string    `json:"name"`
+	SecretHash string    `json:"secret_hash"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, createAPIKey,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.SecretHash,
+		pq.Array(arg.Scopes),
+		arg.CreatedAt,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.SecretHash,
+		pq.Array(&i.Scopes),
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const createBundle = `-- name: CreateBundle :exec
+INSERT INTO bundles (
+    id, title, downloads, max_downloads, created_at, expires_at,
+    owner_id, manage_token_hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
+`
+
+type CreateBundleParams struct {
+	ID              string         `json:"id"`
+	Title           sql.NullString `json:"title"`
+	Downloads       int32          `json:"downloads"`
+	MaxDownloads    int32          `json:"max_downloads"`
+	CreatedAt       time.Time      `json:"created_at"`
+	ExpiresAt       sql.NullTime   `json:"expires_at"`
+	OwnerID         sql.NullString `json:"owner_id"`
+	ManageTokenHash sql.NullString `json:"manage_token_hash"`
+}
+
+func (q *Queries) CreateBundle(ctx context.Context, arg CreateBundleParams) error {
+	_, err := q.db.ExecContext(ctx, createBundle,
+		arg.ID,
+		arg.Title,
+		arg.Downloads,
+		arg.MaxDownloads,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+		arg.OwnerID,
+		arg.ManageTokenHash,
+	)
+	return err
+}
 
 const createFile = `-- name: CreateFile :one
 INSERT INTO files (
     id, original_name, size, content_type, storage_key,
-    downloads, max_downloads, created_at, expires_at
+    downloads, max_downloads, created_at, expires_at,
+    owner_id, manage_token_hash, bundle_id, bundle_position
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9
-) RETURNING id, original_name, size, content_type, storage_key, downloads, max_downloads, created_at, expires_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) RETURNING id, original_name, size, content_type, storage_key, downloads, max_downloads, created_at, expires_at, owner_id, manage_token_hash, bundle_id, bundle_position
 `
 
 type CreateFileParams struct {
-	ID           string    `json:"id"`
-	OriginalName string    `json:"original_name"`
-	Size         int64     `json:"size"`
-	ContentType  string    `json:"content_type"`
-	StorageKey   string    `json:"storage_key"`
-	Downloads    int32     `json:"downloads"`
-	MaxDownloads int32     `json:"max_downloads"`
-	CreatedAt    time.Time `json:"created_at"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	ID              string         `json:"id"`
+	OriginalName    string         `json:"original_name"`
+	Size            int64          `json:"size"`
+	ContentType     string         `json:"content_type"`
+	StorageKey      string         `json:"storage_key"`
+	Downloads       int32          `json:"downloads"`
+	MaxDownloads    int32          `json:"max_downloads"`
+	CreatedAt       time.Time      `json:"created_at"`
+	ExpiresAt       sql.NullTime   `json:"expires_at"`
+	OwnerID         sql.NullString `json:"owner_id"`
+	ManageTokenHash sql.NullString `json:"manage_token_hash"`
+	BundleID        sql.NullString `json:"bundle_id"`
+	BundlePosition  sql.NullInt32  `json:"bundle_position"`
 }
 
 func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, error) {
@@ -43,6 +124,10 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		arg.MaxDownloads,
 		arg.CreatedAt,
 		arg.ExpiresAt,
+		arg.OwnerID,
+		arg.ManageTokenHash,
+		arg.BundleID,
+		arg.BundlePosition,
 	)
 	var i File
 	err := row.Scan(
@@ -55,27 +140,37 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		&i.MaxDownloads,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.OwnerID,
+		&i.ManageTokenHash,
+		&i.BundleID,
+		&i.BundlePosition,
 	)
 	return i, err
 }
 
 const createPaste = `-- name: CreatePaste :one
 INSERT INTO pastes (
-    id, content, language, title, views, max_views, created_at, expires_at
+    id, content, language, title, views, max_views, created_at, expires_at,
+    owner_id, manage_token_hash, revision, parent_id, filename
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
-) RETURNING id, content, language, title, views, max_views, created_at, expires_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) RETURNING id, content, language, title, views, max_views, created_at, expires_at, owner_id, manage_token_hash, size, revision, updated_at, parent_id, filename
 `
 
 type CreatePasteParams struct {
-	ID        string         `json:"id"`
-	Content   string         `json:"content"`
-	Language  string         `json:"language"`
-	Title     sql.NullString `json:"title"`
-	Views     int32          `json:"views"`
-	MaxViews  int32          `json:"max_views"`
-	CreatedAt time.Time      `json:"created_at"`
-	ExpiresAt time.Time      `json:"expires_at"`
+	ID              string         `json:"id"`
+	Content         string         `json:"content"`
+	Language        string         `json:"language"`
+	Title           sql.NullString `json:"title"`
+	Views           int32          `json:"views"`
+	MaxViews        int32          `json:"max_views"`
+	CreatedAt       time.Time      `json:"created_at"`
+	ExpiresAt       sql.NullTime   `json:"expires_at"`
+	OwnerID         sql.NullString `json:"owner_id"`
+	ManageTokenHash sql.NullString `json:"manage_token_hash"`
+	Revision        int32          `json:"revision"`
+	ParentID        sql.NullString `json:"parent_id"`
+	Filename        sql.NullString `json:"filename"`
 }
 
 func (q *Queries) CreatePaste(ctx context.Context, arg CreatePasteParams) (Paste, error) {
@@ -88,6 +183,11 @@ func (q *Queries) CreatePaste(ctx context.Context, arg CreatePasteParams) (Paste
 		arg.MaxViews,
 		arg.CreatedAt,
 		arg.ExpiresAt,
+		arg.OwnerID,
+		arg.ManageTokenHash,
+		arg.Revision,
+		arg.ParentID,
+		arg.Filename,
 	)
 	var i Paste
 	err := row.Scan(
@@ -99,19 +199,184 @@ func (q *Queries) CreatePaste(ctx context.Context, arg CreatePasteParams) (Paste
 		&i.MaxViews,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.OwnerID,
+		&i.ManageTokenHash,
+		&i.Size,
+		&i.Revision,
+		&i.UpdatedAt,
+		&i.ParentID,
+		&i.Filename,
+	)
+	return i, err
+}
+
+const createPasteFile = `-- name: CreatePasteFile :exec
+INSERT INTO paste_files (paste_id, position, name, content, language)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreatePasteFileParams struct {
+	PasteID  string `json:"paste_id"`
+	Position int32  `json:"position"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+	Language string `json:"language"`
+}
+
+func (q *Queries) CreatePasteFile(ctx context.Context, arg CreatePasteFileParams) error {
+	_, err := q.db.ExecContext(ctx, createPasteFile,
+		arg.PasteID,
+		arg.Position,
+		arg.Name,
+		arg.Content,
+		arg.Language,
+	)
+	return err
+}
+
+const createPasteRevision = `-- name: CreatePasteRevision :exec
+INSERT INTO paste_revisions (paste_id, number, content, language, title, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreatePasteRevisionParams struct {
+	PasteID   string         `json:"paste_id"`
+	Number    int32          `json:"number"`
+	Content   string         `json:"content"`
+	Language  string         `json:"language"`
+	Title     sql.NullString `json:"title"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func (q *Queries) CreatePasteRevision(ctx context.Context, arg CreatePasteRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createPasteRevision,
+		arg.PasteID,
+		arg.Number,
+		arg.Content,
+		arg.Language,
+		arg.Title,
+		arg.CreatedAt,
 	)
+	return err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (id, name, created_at) VALUES ($1, $2, $3) RETURNING id, name, created_at
+`
+
+type CreateUserParams struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser, arg.ID, arg.Name, arg.CreatedAt)
+	var i User
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
 	return i, err
 }
 
-const deleteExpiredFiles = `-- name: DeleteExpiredFiles :exec
-DELETE FROM files WHERE expires_at < NOW()
+const deleteBundle = `-- name: DeleteBundle :exec
+DELETE FROM bundles WHERE id = $1
 `
 
-func (q *Queries) DeleteExpiredFiles(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, deleteExpiredFiles)
+func (q *Queries) DeleteBundle(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteBundle, id)
 	return err
 }
 
+const deleteBundleFiles = `-- name: DeleteBundleFiles :many
+DELETE FROM files WHERE bundle_id = $1 RETURNING id, original_name, size, content_type, storage_key, downloads, max_downloads, created_at, expires_at, owner_id, manage_token_hash, bundle_id, bundle_position
+`
+
+func (q *Queries) DeleteBundleFiles(ctx context.Context, bundleID sql.NullString) ([]File, error) {
+	rows, err := q.db.QueryContext(ctx, deleteBundleFiles, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []File{}
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginalName,
+			&i.Size,
+			&i.ContentType,
+			&i.StorageKey,
+			&i.Downloads,
+			&i.MaxDownloads,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.OwnerID,
+			&i.ManageTokenHash,
+			&i.BundleID,
+			&i.BundlePosition,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteExpiredBundles = `-- name: DeleteExpiredBundles :exec
+DELETE FROM bundles WHERE expires_at < NOW()
+`
+
+func (q *Queries) DeleteExpiredBundles(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredBundles)
+	return err
+}
+
+const deleteExpiredFiles = `-- name: DeleteExpiredFiles :many
+DELETE FROM files WHERE expires_at < NOW() RETURNING id, original_name, size, content_type, storage_key, downloads, max_downloads, created_at, expires_at, owner_id, manage_token_hash, bundle_id, bundle_position
+`
+
+func (q *Queries) DeleteExpiredFiles(ctx context.Context) ([]File, error) {
+	rows, err := q.db.QueryContext(ctx, deleteExpiredFiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []File{}
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginalName,
+			&i.Size,
+			&i.ContentType,
+			&i.StorageKey,
+			&i.Downloads,
+			&i.MaxDownloads,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.OwnerID,
+			&i.ManageTokenHash,
+			&i.BundleID,
+			&i.BundlePosition,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteExpiredPastes = `-- name: DeleteExpiredPastes :exec
 DELETE FROM pastes WHERE expires_at < NOW()
 `
@@ -121,8 +386,66 @@ func (q *Queries) DeleteExpiredPastes(ctx context.Context) error {
 	return err
 }
 
+const deleteFile = `-- name: DeleteFile :exec
+DELETE FROM files WHERE id = $1
+`
+
+func (q *Queries) DeleteFile(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteFile, id)
+	return err
+}
+
+const deletePaste = `-- name: DeletePaste :exec
+DELETE FROM pastes WHERE id = $1
+`
+
+func (q *Queries) DeletePaste(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deletePaste, id)
+	return err
+}
+
+const getAPIKeyByID = `-- name: GetAPIKeyByID :one
+SELECT id, user_id, name, secret_hash, scopes, created_at, last_used_at, revoked_at FROM api_keys WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAPIKeyByID(ctx context.Context, id string) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, getAPIKeyByID, id)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.SecretHash,
+		pq.Array(&i.Scopes),
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getBundleByID = `-- name: GetBundleByID :one
+SELECT id, title, downloads, max_downloads, created_at, expires_at, owner_id, manage_token_hash FROM bundles WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetBundleByID(ctx context.Context, id string) (Bundle, error) {
+	row := q.db.QueryRowContext(ctx, getBundleByID, id)
+	var i Bundle
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Downloads,
+		&i.MaxDownloads,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.OwnerID,
+		&i.ManageTokenHash,
+	)
+	return i, err
+}
+
 const getFileByID = `-- name: GetFileByID :one
-SELECT id, original_name, size, content_type, storage_key, downloads, max_downloads, created_at, expires_at FROM files WHERE id = $1 LIMIT 1
+SELECT id, original_name, size, content_type, storage_key, downloads, max_downloads, created_at, expires_at, owner_id, manage_token_hash, bundle_id, bundle_position FROM files WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetFileByID(ctx context.Context, id string) (File, error) {
@@ -138,12 +461,40 @@ func (q *Queries) GetFileByID(ctx context.Context, id string) (File, error) {
 		&i.MaxDownloads,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.OwnerID,
+		&i.ManageTokenHash,
+		&i.BundleID,
+		&i.BundlePosition,
 	)
 	return i, err
 }
 
+const getOwnerUsage = `-- name: GetOwnerUsage :one
+SELECT
+    ((SELECT COUNT(*) FROM files f WHERE f.owner_id = $1)
+        + (SELECT COUNT(*) FROM pastes p WHERE p.owner_id = $1))::BIGINT AS items,
+    ((SELECT COALESCE(SUM(f.size), 0) FROM files f WHERE f.owner_id = $1)
+        + (SELECT COALESCE(SUM(p.size), 0) FROM pastes p WHERE p.owner_id = $1)
+        + (SELECT COALESCE(SUM(r.size), 0) FROM paste_revisions r JOIN pastes p ON p.id = r.paste_id
+           WHERE p.owner_id = $1 AND r.number < p.revision)
+        + (SELECT COALESCE(SUM(pf.size), 0) FROM paste_files pf JOIN pastes p ON p.id = pf.paste_id
+           WHERE p.owner_id = $1))::BIGINT AS bytes
+`
+
+type GetOwnerUsageRow struct {
+	Items int64 `json:"items"`
+	Bytes int64 `json:"bytes"`
+}
+
+func (q *Queries) GetOwnerUsage(ctx context.Context, ownerID sql.NullString) (GetOwnerUsageRow, error) {
+	row := q.db.QueryRowContext(ctx, getOwnerUsage, ownerID)
+	var i GetOwnerUsageRow
+	err := row.Scan(&i.Items, &i.Bytes)
+	return i, err
+}
+
 const getPasteByID = `-- name: GetPasteByID :one
-SELECT id, content, language, title, views, max_views, created_at, expires_at FROM pastes WHERE id = $1 LIMIT 1
+SELECT id, content, language, title, views, max_views, created_at, expires_at, owner_id, manage_token_hash, size, revision, updated_at, parent_id, filename FROM pastes WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetPasteByID(ctx context.Context, id string) (Paste, error) {
@@ -158,10 +509,93 @@ func (q *Queries) GetPasteByID(ctx context.Context, id string) (Paste, error) {
 		&i.MaxViews,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.OwnerID,
+		&i.ManageTokenHash,
+		&i.Size,
+		&i.Revision,
+		&i.UpdatedAt,
+		&i.ParentID,
+		&i.Filename,
+	)
+	return i, err
+}
+
+const getPasteRevision = `-- name: GetPasteRevision :one
+SELECT paste_id, number, content, language, title, size, created_at FROM paste_revisions WHERE paste_id = $1 AND number = $2
+`
+
+type GetPasteRevisionParams struct {
+	PasteID string `json:"paste_id"`
+	Number  int32  `json:"number"`
+}
+
+func (q *Queries) GetPasteRevision(ctx context.Context, arg GetPasteRevisionParams) (PasteRevision, error) {
+	row := q.db.QueryRowContext(ctx, getPasteRevision, arg.PasteID, arg.Number)
+	var i PasteRevision
+	err := row.Scan(
+		&i.PasteID,
+		&i.Number,
+		&i.Content,
+		&i.Language,
+		&i.Title,
+		&i.Size,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const getTotalUsage = `-- name: GetTotalUsage :one
+SELECT
+    ((SELECT COUNT(*) FROM files) + (SELECT COUNT(*) FROM pastes))::BIGINT AS items,
+    ((SELECT COALESCE(SUM(size), 0) FROM files) + (SELECT COALESCE(SUM(size), 0) FROM pastes)
+        + (SELECT COALESCE(SUM(r.size), 0) FROM paste_revisions r JOIN pastes p ON p.id = r.paste_id
+           WHERE r.number < p.revision)
+        + (SELECT COALESCE(SUM(size), 0) FROM paste_files))::BIGINT AS bytes
+`
+
+type GetTotalUsageRow struct {
+	Items int64 `json:"items"`
+	Bytes int64 `json:"bytes"`
+}
+
+func (q *Queries) GetTotalUsage(ctx context.Context) (GetTotalUsageRow, error) {
+	row := q.db.QueryRowContext(ctx, getTotalUsage)
+	var i GetTotalUsageRow
+	err := row.Scan(&i.Items, &i.Bytes)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, name, created_at FROM users WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const getUserByName = `-- name: GetUserByName :one
+SELECT id, name, created_at FROM users WHERE name = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByName(ctx context.Context, name string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByName, name)
+	var i User
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const incrementBundleDownloads = `-- name: IncrementBundleDownloads :exec
+UPDATE bundles SET downloads = downloads + 1 WHERE id = $1
+`
+
+func (q *Queries) IncrementBundleDownloads(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, incrementBundleDownloads, id)
+	return err
+}
+
 const incrementFileDownloads = `-- name: IncrementFileDownloads :exec
 UPDATE files SET downloads = downloads + 1 WHERE id = $1
 `
@@ -179,3 +613,787 @@ func (q *Queries) IncrementPasteViews(ctx context.Context, id string) error {
 	_, err := q.db.ExecContext(ctx, incrementPasteViews, id)
 	return err
 }
+
+const listAPIKeysByUser = `-- name: ListAPIKeysByUser :many
+SELECT id, user_id, name, secret_hash, scopes, created_at, last_used_at, revoked_at FROM api_keys WHERE user_id = $1 ORDER BY created_at
+`
+
+func (q *Queries) ListAPIKeysByUser(ctx context.Context, userID string) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listAPIKeysByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ApiKey{}
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.SecretHash,
+			pq.Array(&i.Scopes),
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBundleFiles = `-- name: ListBundleFiles :many
+SELECT id, original_name, size, content_type, storage_key, downloads, max_downloads, created_at, expires_at, owner_id, manage_token_hash, bundle_id, bundle_position FROM files WHERE bundle_id = $1 ORDER BY bundle_position
+`
+
+func (q *Queries) ListBundleFiles(ctx context.Context, bundleID sql.NullString) ([]File, error) {
+	rows, err := q.db.QueryContext(ctx, listBundleFiles, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []File{}
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginalName,
+			&i.Size,
+			&i.ContentType,
+			&i.StorageKey,
+			&i.Downloads,
+			&i.MaxDownloads,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.OwnerID,
+			&i.ManageTokenHash,
+			&i.BundleID,
+			&i.BundlePosition,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFilesByCreated = `-- name: ListFilesByCreated :many
+
+SELECT id, original_name, size, content_type, storage_key, downloads, max_downloads, created_at, expires_at, owner_id, manage_token_hash, bundle_id, bundle_position FROM files
+WHERE ($1::text IS NULL OR owner_id = $1)
+  AND ($2::text IS NULL OR content_type LIKE $2 || '/%')
+  AND ($3::text IS NULL OR content_type = $3)
+  AND ($4::timestamp IS NULL OR created_at >= $4)
+  AND ($5::timestamp IS NULL OR created_at < $5)
+  AND ($6::timestamp IS NULL OR expires_at <= $6)
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND ($7::timestamp IS NULL
+       OR (created_at, id) < ($7::timestamp, $8::text))
+ORDER BY created_at DESC, id DESC
+LIMIT $9
+`
+
+type ListFilesByCreatedParams struct {
+	OwnerID       sql.NullString `json:"owner_id"`
+	Kind          sql.NullString `json:"kind"`
+	ContentType   sql.NullString `json:"content_type"`
+	CreatedAfter  sql.NullTime   `json:"created_after"`
+	CreatedBefore sql.NullTime   `json:"created_before"`
+	ExpiresBefore sql.NullTime   `json:"expires_before"`
+	AfterTime     sql.NullTime   `json:"after_time"`
+	AfterID       string         `json:"after_id"`
+	MaxRows       int32          `json:"max_rows"`
+}
+
+// Listing is keyset paginated: each sort has its own query and index, and
+// a page starts after the (sort key, id) of the previous page's last row.
+// Permanent content sorts last by expiry, as if it expired in 9999.
+func (q *Queries) ListFilesByCreated(ctx context.Context, arg ListFilesByCreatedParams) ([]File, error) {
+	rows, err := q.db.QueryContext(ctx, listFilesByCreated,
+		arg.OwnerID,
+		arg.Kind,
+		arg.ContentType,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ExpiresBefore,
+		arg.AfterTime,
+		arg.AfterID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []File{}
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginalName,
+			&i.Size,
+			&i.ContentType,
+			&i.StorageKey,
+			&i.Downloads,
+			&i.MaxDownloads,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.OwnerID,
+			&i.ManageTokenHash,
+			&i.BundleID,
+			&i.BundlePosition,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFilesByExpires = `-- name: ListFilesByExpires :many
+SELECT id, original_name, size, content_type, storage_key, downloads, max_downloads, created_at, expires_at, owner_id, manage_token_hash, bundle_id, bundle_position FROM files
+WHERE ($1::text IS NULL OR owner_id = $1)
+  AND ($2::text IS NULL OR content_type LIKE $2 || '/%')
+  AND ($3::text IS NULL OR content_type = $3)
+  AND ($4::timestamp IS NULL OR created_at >= $4)
+  AND ($5::timestamp IS NULL OR created_at < $5)
+  AND ($6::timestamp IS NULL OR expires_at <= $6)
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND ($7::timestamp IS NULL
+       OR (COALESCE(expires_at, '9999-12-31'::timestamp), id) > ($7::timestamp, $8::text))
+ORDER BY COALESCE(expires_at, '9999-12-31'::timestamp), id
+LIMIT $9
+`
+
+type ListFilesByExpiresParams struct {
+	OwnerID       sql.NullString `json:"owner_id"`
+	Kind          sql.NullString `json:"kind"`
+	ContentType   sql.NullString `json:"content_type"`
+	CreatedAfter  sql.NullTime   `json:"created_after"`
+	CreatedBefore sql.NullTime   `json:"created_before"`
+	ExpiresBefore sql.NullTime   `json:"expires_before"`
+	AfterTime     sql.NullTime   `json:"after_time"`
+	AfterID       string         `json:"after_id"`
+	MaxRows       int32          `json:"max_rows"`
+}
+
+func (q *Queries) ListFilesByExpires(ctx context.Context, arg ListFilesByExpiresParams) ([]File, error) {
+	rows, err := q.db.QueryContext(ctx, listFilesByExpires,
+		arg.OwnerID,
+		arg.Kind,
+		arg.ContentType,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ExpiresBefore,
+		arg.AfterTime,
+		arg.AfterID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []File{}
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginalName,
+			&i.Size,
+			&i.ContentType,
+			&i.StorageKey,
+			&i.Downloads,
+			&i.MaxDownloads,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.OwnerID,
+			&i.ManageTokenHash,
+			&i.BundleID,
+			&i.BundlePosition,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFilesBySize = `-- name: ListFilesBySize :many
+SELECT id, original_name, size, content_type, storage_key, downloads, max_downloads, created_at, expires_at, owner_id, manage_token_hash, bundle_id, bundle_position FROM files
+WHERE ($1::text IS NULL OR owner_id = $1)
+  AND ($2::text IS NULL OR content_type LIKE $2 || '/%')
+  AND ($3::text IS NULL OR content_type = $3)
+  AND ($4::timestamp IS NULL OR created_at >= $4)
+  AND ($5::timestamp IS NULL OR created_at < $5)
+  AND ($6::timestamp IS NULL OR expires_at <= $6)
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND ($7::bigint IS NULL
+       OR (size, id) < ($7::bigint, $8::text))
+ORDER BY size DESC, id DESC
+LIMIT $9
+`
+
+type ListFilesBySizeParams struct {
+	OwnerID       sql.NullString `json:"owner_id"`
+	Kind          sql.NullString `json:"kind"`
+	ContentType   sql.NullString `json:"content_type"`
+	CreatedAfter  sql.NullTime   `json:"created_after"`
+	CreatedBefore sql.NullTime   `json:"created_before"`
+	ExpiresBefore sql.NullTime   `json:"expires_before"`
+	AfterSize     sql.NullInt64  `json:"after_size"`
+	AfterID       string         `json:"after_id"`
+	MaxRows       int32          `json:"max_rows"`
+}
+
+func (q *Queries) ListFilesBySize(ctx context.Context, arg ListFilesBySizeParams) ([]File, error) {
+	rows, err := q.db.QueryContext(ctx, listFilesBySize,
+		arg.OwnerID,
+		arg.Kind,
+		arg.ContentType,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ExpiresBefore,
+		arg.AfterSize,
+		arg.AfterID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []File{}
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginalName,
+			&i.Size,
+			&i.ContentType,
+			&i.StorageKey,
+			&i.Downloads,
+			&i.MaxDownloads,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.OwnerID,
+			&i.ManageTokenHash,
+			&i.BundleID,
+			&i.BundlePosition,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPasteFiles = `-- name: ListPasteFiles :many
+SELECT paste_id, position, name, content, language, size FROM paste_files WHERE paste_id = $1 ORDER BY position
+`
+
+func (q *Queries) ListPasteFiles(ctx context.Context, pasteID string) ([]PasteFile, error) {
+	rows, err := q.db.QueryContext(ctx, listPasteFiles, pasteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PasteFile{}
+	for rows.Next() {
+		var i PasteFile
+		if err := rows.Scan(
+			&i.PasteID,
+			&i.Position,
+			&i.Name,
+			&i.Content,
+			&i.Language,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPasteRevisions = `-- name: ListPasteRevisions :many
+SELECT paste_id, number, language, title, size, created_at FROM paste_revisions
+WHERE paste_id = $1
+ORDER BY number
+`
+
+type ListPasteRevisionsRow struct {
+	PasteID   string         `json:"paste_id"`
+	Number    int32          `json:"number"`
+	Language  string         `json:"language"`
+	Title     sql.NullString `json:"title"`
+	Size      sql.NullInt64  `json:"size"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func (q *Queries) ListPasteRevisions(ctx context.Context, pasteID string) ([]ListPasteRevisionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPasteRevisions, pasteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPasteRevisionsRow{}
+	for rows.Next() {
+		var i ListPasteRevisionsRow
+		if err := rows.Scan(
+			&i.PasteID,
+			&i.Number,
+			&i.Language,
+			&i.Title,
+			&i.Size,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPastesByCreated = `-- name: ListPastesByCreated :many
+SELECT id, content, language, title, views, max_views, created_at, expires_at, owner_id, manage_token_hash, size, revision, updated_at, parent_id, filename FROM pastes
+WHERE ($1::text IS NULL OR owner_id = $1)
+  AND ($2::text IS NULL OR language = $2)
+  AND ($3::timestamp IS NULL OR created_at >= $3)
+  AND ($4::timestamp IS NULL OR created_at < $4)
+  AND ($5::timestamp IS NULL OR expires_at <= $5)
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND ($6::timestamp IS NULL
+       OR (created_at, id) < ($6::timestamp, $7::text))
+ORDER BY created_at DESC, id DESC
+LIMIT $8
+`
+
+type ListPastesByCreatedParams struct {
+	OwnerID       sql.NullString `json:"owner_id"`
+	Language      sql.NullString `json:"language"`
+	CreatedAfter  sql.NullTime   `json:"created_after"`
+	CreatedBefore sql.NullTime   `json:"created_before"`
+	ExpiresBefore sql.NullTime   `json:"expires_before"`
+	AfterTime     sql.NullTime   `json:"after_time"`
+	AfterID       string         `json:"after_id"`
+	MaxRows       int32          `json:"max_rows"`
+}
+
+func (q *Queries) ListPastesByCreated(ctx context.Context, arg ListPastesByCreatedParams) ([]Paste, error) {
+	rows, err := q.db.QueryContext(ctx, listPastesByCreated,
+		arg.OwnerID,
+		arg.Language,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ExpiresBefore,
+		arg.AfterTime,
+		arg.AfterID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Paste{}
+	for rows.Next() {
+		var i Paste
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.Language,
+			&i.Title,
+			&i.Views,
+			&i.MaxViews,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.OwnerID,
+			&i.ManageTokenHash,
+			&i.Size,
+			&i.Revision,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.Filename,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPastesByExpires = `-- name: ListPastesByExpires :many
+SELECT id, content, language, title, views, max_views, created_at, expires_at, owner_id, manage_token_hash, size, revision, updated_at, parent_id, filename FROM pastes
+WHERE ($1::text IS NULL OR owner_id = $1)
+  AND ($2::text IS NULL OR language = $2)
+  AND ($3::timestamp IS NULL OR created_at >= $3)
+  AND ($4::timestamp IS NULL OR created_at < $4)
+  AND ($5::timestamp IS NULL OR expires_at <= $5)
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND ($6::timestamp IS NULL
+       OR (COALESCE(expires_at, '9999-12-31'::timestamp), id) > ($6::timestamp, $7::text))
+ORDER BY COALESCE(expires_at, '9999-12-31'::timestamp), id
+LIMIT $8
+`
+
+type ListPastesByExpiresParams struct {
+	OwnerID       sql.NullString `json:"owner_id"`
+	Language      sql.NullString `json:"language"`
+	CreatedAfter  sql.NullTime   `json:"created_after"`
+	CreatedBefore sql.NullTime   `json:"created_before"`
+	ExpiresBefore sql.NullTime   `json:"expires_before"`
+	AfterTime     sql.NullTime   `json:"after_time"`
+	AfterID       string         `json:"after_id"`
+	MaxRows       int32          `json:"max_rows"`
+}
+
+func (q *Queries) ListPastesByExpires(ctx context.Context, arg ListPastesByExpiresParams) ([]Paste, error) {
+	rows, err := q.db.QueryContext(ctx, listPastesByExpires,
+		arg.OwnerID,
+		arg.Language,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ExpiresBefore,
+		arg.AfterTime,
+		arg.AfterID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Paste{}
+	for rows.Next() {
+		var i Paste
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.Language,
+			&i.Title,
+			&i.Views,
+			&i.MaxViews,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.OwnerID,
+			&i.ManageTokenHash,
+			&i.Size,
+			&i.Revision,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.Filename,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPastesBySize = `-- name: ListPastesBySize :many
+SELECT id, content, language, title, views, max_views, created_at, expires_at, owner_id, manage_token_hash, size, revision, updated_at, parent_id, filename FROM pastes
+WHERE ($1::text IS NULL OR owner_id = $1)
+  AND ($2::text IS NULL OR language = $2)
+  AND ($3::timestamp IS NULL OR created_at >= $3)
+  AND ($4::timestamp IS NULL OR created_at < $4)
+  AND ($5::timestamp IS NULL OR expires_at <= $5)
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND ($6::bigint IS NULL
+       OR (size, id) < ($6::bigint, $7::text))
+ORDER BY size DESC, id DESC
+LIMIT $8
+`
+
+type ListPastesBySizeParams struct {
+	OwnerID       sql.NullString `json:"owner_id"`
+	Language      sql.NullString `json:"language"`
+	CreatedAfter  sql.NullTime   `json:"created_after"`
+	CreatedBefore sql.NullTime   `json:"created_before"`
+	ExpiresBefore sql.NullTime   `json:"expires_before"`
+	AfterSize     sql.NullInt64  `json:"after_size"`
+	AfterID       string         `json:"after_id"`
+	MaxRows       int32          `json:"max_rows"`
+}
+
+func (q *Queries) ListPastesBySize(ctx context.Context, arg ListPastesBySizeParams) ([]Paste, error) {
+	rows, err := q.db.QueryContext(ctx, listPastesBySize,
+		arg.OwnerID,
+		arg.Language,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ExpiresBefore,
+		arg.AfterSize,
+		arg.AfterID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Paste{}
+	for rows.Next() {
+		var i Paste
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.Language,
+			&i.Title,
+			&i.Views,
+			&i.MaxViews,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.OwnerID,
+			&i.ManageTokenHash,
+			&i.Size,
+			&i.Revision,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.Filename,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, name, created_at FROM users ORDER BY name
+`
+
+func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :execrows
+UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAPIKey, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchPastes = `-- name: SearchPastes :many
+SELECT
+    p.id, p.title, p.language, p.views, p.max_views, p.created_at, p.expires_at, p.owner_id,
+    ts_rank(
+        setweight(to_tsvector('simple', COALESCE(p.title, '')), 'A')
+        || setweight(to_tsvector('simple', left(p.content, 262144)), 'B'),
+        q
+    )::REAL AS rank,
+    ts_headline('simple', left(p.content, 262144), q,
+        E'StartSel=\x01, StopSel=\x02, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=" … "'
+    )::TEXT AS snippet
+FROM pastes p, websearch_to_tsquery('simple', $2::text) q
+WHERE p.owner_id = $3
+  AND NOT EXISTS (SELECT 1 FROM paste_files f WHERE f.paste_id = p.id)
+  AND (
+      setweight(to_tsvector('simple', COALESCE(p.title, '')), 'A')
+      || setweight(to_tsvector('simple', left(p.content, 262144)), 'B')
+  ) @@ q
+  AND (p.expires_at IS NULL OR p.expires_at > NOW())
+UNION ALL
+SELECT
+    p.id, p.title, p.language, p.views, p.max_views, p.created_at, p.expires_at, p.owner_id,
+    ts_rank(
+        setweight(to_tsvector('simple', COALESCE(p.title, '')), 'A')
+        || setweight(to_tsvector('simple', files.content), 'B'),
+        q
+    )::REAL AS rank,
+    ts_headline('simple', files.content, q,
+        E'StartSel=\x01, StopSel=\x02, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=" … "'
+    )::TEXT AS snippet
+FROM pastes p
+CROSS JOIN websearch_to_tsquery('simple', $2::text) q
+CROSS JOIN LATERAL (
+    SELECT left(p.content || E'\n' || string_agg(f.content, E'\n' ORDER BY f.position), 262144) AS content
+    FROM paste_files f
+    WHERE f.paste_id = p.id
+) files
+WHERE p.owner_id = $3
+  AND files.content IS NOT NULL
+  AND (
+      setweight(to_tsvector('simple', COALESCE(p.title, '')), 'A')
+      || setweight(to_tsvector('simple', files.content), 'B')
+  ) @@ q
+  AND (p.expires_at IS NULL OR p.expires_at > NOW())
+ORDER BY rank DESC, created_at DESC
+LIMIT $1
+`
+
+type SearchPastesParams struct {
+	MaxRows int32          `json:"max_rows"`
+	Query   string         `json:"query"`
+	OwnerID sql.NullString `json:"owner_id"`
+}
+
+type SearchPastesRow struct {
+	ID        string         `json:"id"`
+	Title     sql.NullString `json:"title"`
+	Language  string         `json:"language"`
+	Views     int32          `json:"views"`
+	MaxViews  int32          `json:"max_views"`
+	CreatedAt time.Time      `json:"created_at"`
+	ExpiresAt sql.NullTime   `json:"expires_at"`
+	OwnerID   sql.NullString `json:"owner_id"`
+	Rank      float32        `json:"rank"`
+	Snippet   string         `json:"snippet"`
+}
+
+// Pastes of a single text use the GIN index on their content. Multi-file
+// pastes are matched on all their files at once, read from paste_files.
+func (q *Queries) SearchPastes(ctx context.Context, arg SearchPastesParams) ([]SearchPastesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPastes, arg.MaxRows, arg.Query, arg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchPastesRow{}
+	for rows.Next() {
+		var i SearchPastesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Language,
+			&i.Views,
+			&i.MaxViews,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.OwnerID,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) TouchAPIKey(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, touchAPIKey, id)
+	return err
+}
+
+const updatePaste = `-- name: UpdatePaste :execrows
+UPDATE pastes
+SET content = $2, language = $3, title = $4, revision = $5, updated_at = $6
+WHERE id = $1 AND revision = $7
+`
+
+type UpdatePasteParams struct {
+	ID               string         `json:"id"`
+	Content          string         `json:"content"`
+	Language         string         `json:"language"`
+	Title            sql.NullString `json:"title"`
+	Revision         int32          `json:"revision"`
+	UpdatedAt        sql.NullTime   `json:"updated_at"`
+	PreviousRevision int32          `json:"previous_revision"`
+}
+
+func (q *Queries) UpdatePaste(ctx context.Context, arg UpdatePasteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updatePaste,
+		arg.ID,
+		arg.Content,
+		arg.Language,
+		arg.Title,
+		arg.Revision,
+		arg.UpdatedAt,
+		arg.PreviousRevision,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
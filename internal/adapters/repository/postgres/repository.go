@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"strings"
+	"time"
 
 	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
 	"github.com/Gandalf-Le-Dev/quip/internal/core/ports"
@@ -25,17 +27,7 @@ func NewRepository(db *sql.DB) *Repository {
 }
 
 func (r *Repository) Store(ctx context.Context, file *domain.File) error {
-	_, err := r.queries.CreateFile(ctx, CreateFileParams{
-		ID:           file.ID,
-		OriginalName: file.OriginalName,
-		Size:         file.Size,
-		ContentType:  file.ContentType,
-		StorageKey:   file.StorageKey,
-		Downloads:    int32(file.Downloads),
-		MaxDownloads: int32(file.MaxDownloads),
-		CreatedAt:    file.CreatedAt,
-		ExpiresAt:    file.ExpiresAt,
-	})
+	_, err := r.queries.CreateFile(ctx, toFileParams(file, 0))
 	return err
 }
 
@@ -47,26 +39,76 @@ func (r *Repository) FindByID(ctx context.Context, id string) (*domain.File, err
 		}
 		return nil, err
 	}
+	return toFile(row), nil
+}
 
-	return &domain.File{
-		ID:           row.ID,
-		OriginalName: row.OriginalName,
-		Size:         row.Size,
-		ContentType:  row.ContentType,
-		StorageKey:   row.StorageKey,
-		Downloads:    int(row.Downloads),
-		MaxDownloads: int(row.MaxDownloads),
-		CreatedAt:    row.CreatedAt,
-		ExpiresAt:    row.ExpiresAt,
-	}, nil
+func (r *Repository) List(ctx context.Context, filter domain.ListFilter, page domain.Page) ([]*domain.File, error) {
+	var (
+		ownerID       = toNullString(filter.OwnerID)
+		kind          = toNullString(filter.Kind)
+		contentType   = toNullString(filter.ContentType)
+		createdAfter  = toNullTime(filter.CreatedAfter)
+		createdBefore = toNullTime(filter.CreatedBefore)
+		expiresBefore = toNullTime(filter.ExpiresBefore)
+		after         = page.After
+		maxRows       = int32(page.Limit)
+	)
+	if after == nil {
+		after = &domain.Cursor{}
+	}
+
+	var rows []File
+	var err error
+	switch page.Sort {
+	case domain.SortExpires:
+		rows, err = r.queries.ListFilesByExpires(ctx, ListFilesByExpiresParams{
+			OwnerID: ownerID, Kind: kind, ContentType: contentType,
+			CreatedAfter: createdAfter, CreatedBefore: createdBefore, ExpiresBefore: expiresBefore,
+			AfterTime: toNullTime(after.Time), AfterID: after.ID, MaxRows: maxRows,
+		})
+	case domain.SortSize:
+		rows, err = r.queries.ListFilesBySize(ctx, ListFilesBySizeParams{
+			OwnerID: ownerID, Kind: kind, ContentType: contentType,
+			CreatedAfter: createdAfter, CreatedBefore: createdBefore, ExpiresBefore: expiresBefore,
+			AfterSize: sql.NullInt64{Int64: after.Size, Valid: page.After != nil}, AfterID: after.ID, MaxRows: maxRows,
+		})
+	default:
+		rows, err = r.queries.ListFilesByCreated(ctx, ListFilesByCreatedParams{
+			OwnerID: ownerID, Kind: kind, ContentType: contentType,
+			CreatedAfter: createdAfter, CreatedBefore: createdBefore, ExpiresBefore: expiresBefore,
+			AfterTime: toNullTime(after.Time), AfterID: after.ID, MaxRows: maxRows,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*domain.File, len(rows))
+	for i, row := range rows {
+		files[i] = toFile(row)
+	}
+	return files, nil
 }
 
 func (r *Repository) IncrementDownloads(ctx context.Context, id string) error {
 	return r.queries.IncrementFileDownloads(ctx, id)
 }
 
-func (r *Repository) DeleteExpired(ctx context.Context) error {
-	return r.queries.DeleteExpiredFiles(ctx)
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	return r.queries.DeleteFile(ctx, id)
+}
+
+func (r *Repository) DeleteExpired(ctx context.Context) ([]*domain.File, error) {
+	rows, err := r.queries.DeleteExpiredFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*domain.File, len(rows))
+	for i, row := range rows {
+		files[i] = toFile(row)
+	}
+	return files, nil
 }
 
 // PasteRepository implementation
@@ -74,7 +116,10 @@ type PasteRepository struct {
 	*Repository
 }
 
-var _ ports.PasteRepository = (*PasteRepository)(nil)
+var (
+	_ ports.PasteRepository = (*PasteRepository)(nil)
+	_ ports.PasteSearcher   = (*PasteRepository)(nil)
+)
 
 func NewPasteRepository(db *sql.DB) ports.PasteRepository {
 	return &PasteRepository{
@@ -83,17 +128,43 @@ func NewPasteRepository(db *sql.DB) ports.PasteRepository {
 }
 
 func (r *PasteRepository) Store(ctx context.Context, paste *domain.Paste) error {
-	_, err := r.queries.CreatePaste(ctx, CreatePasteParams{
-		ID:        paste.ID,
-		Content:   paste.Content,
-		Language:  paste.Language,
-		Title:     sql.NullString{String: paste.Title, Valid: paste.Title != ""},
-		Views:     int32(paste.Views),
-		MaxViews:  int32(paste.MaxViews),
-		CreatedAt: paste.CreatedAt,
-		ExpiresAt: paste.ExpiresAt,
+	var filename string
+	if paste.IsMultiFile() {
+		filename = paste.Files[0].Name
+	}
+
+	return r.inTx(ctx, func(q *Queries) error {
+		_, err := q.CreatePaste(ctx, CreatePasteParams{
+			ID:        paste.ID,
+			Content:   paste.Content,
+			Language:  paste.Language,
+			Title:     sql.NullString{String: paste.Title, Valid: paste.Title != ""},
+			Views:     int32(paste.Views),
+			MaxViews:  int32(paste.MaxViews),
+			CreatedAt: paste.CreatedAt,
+			ExpiresAt: toNullTime(paste.ExpiresAt),
+
+			OwnerID:         toNullString(paste.OwnerID),
+			ManageTokenHash: toNullString(paste.ManageTokenHash),
+			Revision:        int32(paste.Revision),
+			ParentID:        toNullString(paste.ParentID),
+			Filename:        toNullString(filename),
+		})
+		if err != nil {
+			return err
+		}
+		// The first file is the paste's own content
+		for i := 1; i < len(paste.Files); i++ {
+			f := paste.Files[i]
+			err := q.CreatePasteFile(ctx, CreatePasteFileParams{
+				PasteID: paste.ID, Position: int32(i), Name: f.Name, Content: f.Content, Language: f.Language,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return q.CreatePasteRevision(ctx, toRevisionParams(paste.LatestRevision()))
 	})
-	return err
 }
 
 func (r *PasteRepository) FindByID(ctx context.Context, id string) (*domain.Paste, error) {
@@ -104,7 +175,186 @@ func (r *PasteRepository) FindByID(ctx context.Context, id string) (*domain.Past
 		}
 		return nil, err
 	}
+	paste := toPaste(row)
 
+	if row.Filename.Valid {
+		rows, err := r.queries.ListPasteFiles(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		paste.Files = make([]domain.PasteFile, 0, len(rows)+1)
+		paste.Files = append(paste.Files, domain.PasteFile{Name: row.Filename.String, Content: row.Content, Language: row.Language})
+		for _, f := range rows {
+			paste.Files = append(paste.Files, domain.PasteFile{Name: f.Name, Content: f.Content, Language: f.Language})
+		}
+	}
+	return paste, nil
+}
+
+func (r *PasteRepository) List(ctx context.Context, filter domain.ListFilter, page domain.Page) ([]*domain.Paste, error) {
+	var (
+		ownerID       = toNullString(filter.OwnerID)
+		language      = toNullString(filter.Language)
+		createdAfter  = toNullTime(filter.CreatedAfter)
+		createdBefore = toNullTime(filter.CreatedBefore)
+		expiresBefore = toNullTime(filter.ExpiresBefore)
+		after         = page.After
+		maxRows       = int32(page.Limit)
+	)
+	if after == nil {
+		after = &domain.Cursor{}
+	}
+
+	var rows []Paste
+	var err error
+	switch page.Sort {
+	case domain.SortExpires:
+		rows, err = r.queries.ListPastesByExpires(ctx, ListPastesByExpiresParams{
+			OwnerID: ownerID, Language: language,
+			CreatedAfter: createdAfter, CreatedBefore: createdBefore, ExpiresBefore: expiresBefore,
+			AfterTime: toNullTime(after.Time), AfterID: after.ID, MaxRows: maxRows,
+		})
+	case domain.SortSize:
+		rows, err = r.queries.ListPastesBySize(ctx, ListPastesBySizeParams{
+			OwnerID: ownerID, Language: language,
+			CreatedAfter: createdAfter, CreatedBefore: createdBefore, ExpiresBefore: expiresBefore,
+			AfterSize: sql.NullInt64{Int64: after.Size, Valid: page.After != nil}, AfterID: after.ID, MaxRows: maxRows,
+		})
+	default:
+		rows, err = r.queries.ListPastesByCreated(ctx, ListPastesByCreatedParams{
+			OwnerID: ownerID, Language: language,
+			CreatedAfter: createdAfter, CreatedBefore: createdBefore, ExpiresBefore: expiresBefore,
+			AfterTime: toNullTime(after.Time), AfterID: after.ID, MaxRows: maxRows,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pastes := make([]*domain.Paste, len(rows))
+	for i, row := range rows {
+		pastes[i] = toPaste(row)
+	}
+	return pastes, nil
+}
+
+func (r *PasteRepository) Search(ctx context.Context, ownerID, query string, limit int) ([]domain.SearchResult, error) {
+	rows, err := r.queries.SearchPastes(ctx, SearchPastesParams{
+		Query:   query,
+		OwnerID: toNullString(ownerID),
+		MaxRows: int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.SearchResult, len(rows))
+	for i, row := range rows {
+		snippet, highlights := splitHighlights(row.Snippet)
+		results[i] = domain.SearchResult{
+			Paste: &domain.Paste{
+				ID:        row.ID,
+				Language:  row.Language,
+				Title:     row.Title.String,
+				Views:     int(row.Views),
+				MaxViews:  int(row.MaxViews),
+				CreatedAt: row.CreatedAt,
+				ExpiresAt: row.ExpiresAt.Time,
+				OwnerID:   row.OwnerID.String,
+			},
+			Rank:       float64(row.Rank),
+			Snippet:    snippet,
+			Highlights: highlights,
+		}
+	}
+	return results, nil
+}
+
+// splitHighlights removes the \x01 and \x02 markers SearchPastes puts around
+// matches in a snippet, and returns where they were
+func splitHighlights(marked string) (string, []domain.Span) {
+	var b strings.Builder
+	var spans []domain.Span
+	for _, r := range marked {
+		switch r {
+		case '\x01':
+			spans = append(spans, domain.Span{Start: b.Len()})
+		case '\x02':
+			if n := len(spans); n > 0 {
+				spans[n-1].End = b.Len()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), spans
+}
+
+func (r *PasteRepository) IncrementViews(ctx context.Context, id string) error {
+	return r.queries.IncrementPasteViews(ctx, id)
+}
+
+func (r *PasteRepository) Delete(ctx context.Context, id string) error {
+	return r.queries.DeletePaste(ctx, id)
+}
+
+func (r *PasteRepository) DeleteExpired(ctx context.Context) error {
+	return r.queries.DeleteExpiredPastes(ctx)
+}
+
+// inTx runs fn in a transaction, committed if fn succeeds
+func (r *Repository) inTx(ctx context.Context, fn func(q *Queries) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(r.queries.WithTx(tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func toFile(row File) *domain.File {
+	return &domain.File{
+		ID:           row.ID,
+		OriginalName: row.OriginalName,
+		Size:         row.Size,
+		ContentType:  row.ContentType,
+		StorageKey:   row.StorageKey,
+		Downloads:    int(row.Downloads),
+		MaxDownloads: int(row.MaxDownloads),
+		CreatedAt:    row.CreatedAt,
+		ExpiresAt:    row.ExpiresAt.Time,
+		OwnerID:      row.OwnerID.String,
+		BundleID:     row.BundleID.String,
+
+		ManageTokenHash: row.ManageTokenHash.String,
+	}
+}
+
+// toFileParams maps a file to its row. position is its place in its bundle,
+// if it has one.
+func toFileParams(file *domain.File, position int) CreateFileParams {
+	return CreateFileParams{
+		ID:           file.ID,
+		OriginalName: file.OriginalName,
+		Size:         file.Size,
+		ContentType:  file.ContentType,
+		StorageKey:   file.StorageKey,
+		Downloads:    int32(file.Downloads),
+		MaxDownloads: int32(file.MaxDownloads),
+		CreatedAt:    file.CreatedAt,
+		ExpiresAt:    toNullTime(file.ExpiresAt),
+
+		OwnerID:         toNullString(file.OwnerID),
+		ManageTokenHash: toNullString(file.ManageTokenHash),
+		BundleID:        toNullString(file.BundleID),
+		BundlePosition:  sql.NullInt32{Int32: int32(position), Valid: file.BundleID != ""},
+	}
+}
+
+func toPaste(row Paste) *domain.Paste {
 	return &domain.Paste{
 		ID:        row.ID,
 		Content:   row.Content,
@@ -113,14 +363,22 @@ func (r *PasteRepository) FindByID(ctx context.Context, id string) (*domain.Past
 		Views:     int(row.Views),
 		MaxViews:  int(row.MaxViews),
 		CreatedAt: row.CreatedAt,
-		ExpiresAt: row.ExpiresAt,
-	}, nil
+		ExpiresAt: row.ExpiresAt.Time,
+		OwnerID:   row.OwnerID.String,
+		Revision:  int(row.Revision),
+		UpdatedAt: row.UpdatedAt.Time,
+		ParentID:  row.ParentID.String,
+
+		ManageTokenHash: row.ManageTokenHash.String,
+	}
 }
 
-func (r *PasteRepository) IncrementViews(ctx context.Context, id string) error {
-	return r.queries.IncrementPasteViews(ctx, id)
+// toNullString maps the empty string, e.g. the owner of anonymous content, to NULL
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
 
-func (r *PasteRepository) DeleteExpired(ctx context.Context) error {
-	return r.queries.DeleteExpiredPastes(ctx)
+// toNullTime maps the zero time, used for content that never expires, to NULL
+func toNullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
 }
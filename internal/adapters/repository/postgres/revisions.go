@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+)
+
+func (r *PasteRepository) Update(ctx context.Context, paste *domain.Paste) error {
+	return r.inTx(ctx, func(q *Queries) error {
+		// The revision check makes concurrent edits of one paste conflict
+		// instead of silently overwriting each other
+		n, err := q.UpdatePaste(ctx, UpdatePasteParams{
+			ID:               paste.ID,
+			Content:          paste.Content,
+			Language:         paste.Language,
+			Title:            toNullString(paste.Title),
+			Revision:         int32(paste.Revision),
+			UpdatedAt:        toNullTime(paste.UpdatedAt),
+			PreviousRevision: int32(paste.Revision - 1),
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return domain.ErrConflict
+		}
+		return q.CreatePasteRevision(ctx, toRevisionParams(paste.LatestRevision()))
+	})
+}
+
+func (r *PasteRepository) ListRevisions(ctx context.Context, id string) ([]*domain.Revision, error) {
+	rows, err := r.queries.ListPasteRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*domain.Revision, len(rows))
+	for i, row := range rows {
+		revisions[i] = &domain.Revision{
+			PasteID:   row.PasteID,
+			Number:    int(row.Number),
+			Language:  row.Language,
+			Title:     row.Title.String,
+			Size:      row.Size.Int64,
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	return revisions, nil
+}
+
+func (r *PasteRepository) FindRevision(ctx context.Context, id string, number int) (*domain.Revision, error) {
+	row, err := r.queries.GetPasteRevision(ctx, GetPasteRevisionParams{PasteID: id, Number: int32(number)})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &domain.Revision{
+		PasteID:   row.PasteID,
+		Number:    int(row.Number),
+		Content:   row.Content,
+		Language:  row.Language,
+		Title:     row.Title.String,
+		Size:      row.Size.Int64,
+		CreatedAt: row.CreatedAt,
+	}, nil
+}
+
+func toRevisionParams(rev *domain.Revision) CreatePasteRevisionParams {
+	return CreatePasteRevisionParams{
+		PasteID:   rev.PasteID,
+		Number:    int32(rev.Number),
+		Content:   rev.Content,
+		Language:  rev.Language,
+		Title:     toNullString(rev.Title),
+		CreatedAt: rev.CreatedAt,
+	}
+}
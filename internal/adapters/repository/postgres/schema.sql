@@ -1,3 +1,7 @@
+-- The schema is applied on every startup by Migrate, so each statement must
+-- be idempotent. Evolve existing tables with ALTER ... IF NOT EXISTS below
+-- the CREATE statements rather than editing them.
+
 CREATE TABLE IF NOT EXISTS files (
     id VARCHAR(11) PRIMARY KEY,
     original_name VARCHAR(255) NOT NULL,
@@ -21,5 +25,124 @@ CREATE TABLE IF NOT EXISTS pastes (
     expires_at TIMESTAMP NOT NULL
 );
 
-CREATE INDEX idx_files_expires_at ON files(expires_at);
-CREATE INDEX idx_pastes_expires_at ON pastes(expires_at);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_files_expires_at ON files(expires_at);
+CREATE INDEX IF NOT EXISTS idx_pastes_expires_at ON pastes(expires_at);
+
+-- Permanent content has no expiry
+ALTER TABLE files ALTER COLUMN expires_at DROP NOT NULL;
+ALTER TABLE pastes ALTER COLUMN expires_at DROP NOT NULL;
+
+-- Accounts and the API keys that authenticate them
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(11) PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(12) PRIMARY KEY,
+    user_id VARCHAR(11) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    secret_hash CHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+
+-- Content owners, NULL for anonymous uploads, and the hashed manage tokens
+ALTER TABLE files ADD COLUMN IF NOT EXISTS owner_id VARCHAR(11) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS manage_token_hash CHAR(64);
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS owner_id VARCHAR(11) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS manage_token_hash CHAR(64);
+
+-- Paste sizes in bytes, kept by Postgres for quotas and sorting
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS size BIGINT GENERATED ALWAYS AS (octet_length(content)) STORED;
+
+-- Keyset pagination, one index per sort order of the listing queries. They
+-- lead with the owner since users list their own content, which makes the
+-- owner-only indexes of earlier versions redundant.
+DROP INDEX IF EXISTS idx_files_owner_id;
+DROP INDEX IF EXISTS idx_pastes_owner_id;
+CREATE INDEX IF NOT EXISTS idx_files_owner_created ON files(owner_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_files_owner_expires ON files(owner_id, (COALESCE(expires_at, '9999-12-31'::timestamp)), id);
+CREATE INDEX IF NOT EXISTS idx_files_owner_size ON files(owner_id, size DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_pastes_owner_created ON pastes(owner_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_pastes_owner_expires ON pastes(owner_id, (COALESCE(expires_at, '9999-12-31'::timestamp)), id);
+CREATE INDEX IF NOT EXISTS idx_pastes_owner_size ON pastes(owner_id, size DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_files_created ON files(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_pastes_created ON pastes(created_at DESC, id DESC);
+
+-- Full-text search over paste titles and content. The expression must match
+-- the one in SearchPastes for the index to be used. The 'simple'
+-- configuration does not stem, which suits code and config files, and only
+-- the first 262,144 characters of content are indexed, at most 1 MiB of
+-- UTF-8, to stay under the tsvector limit. Multi-file pastes are searched
+-- without an index, as their files live in paste_files.
+CREATE INDEX IF NOT EXISTS idx_pastes_search ON pastes USING GIN ((
+    setweight(to_tsvector('simple', COALESCE(title, '')), 'A')
+    || setweight(to_tsvector('simple', left(content, 262144)), 'B')
+));
+
+-- Paste revisions. The pastes row holds the latest revision, which is also
+-- kept here with every earlier one so that revisions never change. Pastes
+-- created before revisions existed get their first revision backfilled.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS revision INT NOT NULL DEFAULT 1;
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS paste_revisions (
+    paste_id VARCHAR(11) NOT NULL REFERENCES pastes(id) ON DELETE CASCADE,
+    number INT NOT NULL,
+    content TEXT NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    title VARCHAR(255),
+    size BIGINT GENERATED ALWAYS AS (octet_length(content)) STORED,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (paste_id, number)
+);
+
+INSERT INTO paste_revisions (paste_id, number, content, language, title, created_at)
+SELECT id, revision, content, language, title, COALESCE(updated_at, created_at) FROM pastes
+WHERE NOT EXISTS (SELECT 1 FROM paste_revisions r WHERE r.paste_id = pastes.id)
+ON CONFLICT DO NOTHING;
+
+-- Forks link to the paste they were copied from, which may since be gone
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS parent_id VARCHAR(11) REFERENCES pastes(id) ON DELETE SET NULL;
+
+-- Multi-file pastes. The first file is the paste's own content, named by
+-- filename, and the following ones are kept here. Single-file pastes have
+-- no filename and no rows here.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS filename VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS paste_files (
+    paste_id VARCHAR(11) NOT NULL REFERENCES pastes(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    size BIGINT GENERATED ALWAYS AS (octet_length(content)) STORED,
+    PRIMARY KEY (paste_id, position),
+    UNIQUE (paste_id, name)
+);
+
+-- Bundles group files uploaded together behind one link. Their files share
+-- the bundle's expiry and are swept with the other files; a file whose
+-- bundle is gone simply stands on its own.
+CREATE TABLE IF NOT EXISTS bundles (
+    id VARCHAR(11) PRIMARY KEY,
+    title VARCHAR(255),
+    downloads INT NOT NULL DEFAULT 0,
+    max_downloads INT NOT NULL DEFAULT -1,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP,
+    owner_id VARCHAR(11) REFERENCES users(id) ON DELETE SET NULL,
+    manage_token_hash CHAR(64)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bundles_expires_at ON bundles(expires_at);
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS bundle_id VARCHAR(11) REFERENCES bundles(id) ON DELETE SET NULL;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS bundle_position INT;
+CREATE INDEX IF NOT EXISTS idx_files_bundle_id ON files(bundle_id) WHERE bundle_id IS NOT NULL;
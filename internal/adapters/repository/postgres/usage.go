@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/ports"
+)
+
+// UsageRepository implementation
+type UsageRepository struct {
+	*Repository
+}
+
+var _ ports.UsageRepository = (*UsageRepository)(nil)
+
+func NewUsageRepository(db *sql.DB) *UsageRepository {
+	return &UsageRepository{
+		Repository: NewRepository(db),
+	}
+}
+
+func (r *UsageRepository) UsageByOwner(ctx context.Context, ownerID string) (domain.Usage, error) {
+	row, err := r.queries.GetOwnerUsage(ctx, toNullString(ownerID))
+	if err != nil {
+		return domain.Usage{}, err
+	}
+	return domain.Usage{Items: row.Items, Bytes: row.Bytes}, nil
+}
+
+func (r *UsageRepository) TotalUsage(ctx context.Context) (domain.Usage, error) {
+	row, err := r.queries.GetTotalUsage(ctx)
+	if err != nil {
+		return domain.Usage{}, err
+	}
+	return domain.Usage{Items: row.Items, Bytes: row.Bytes}, nil
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/ports"
+)
+
+// UserRepository implementation
+type UserRepository struct {
+	*Repository
+}
+
+var _ ports.UserRepository = (*UserRepository)(nil)
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{
+		Repository: NewRepository(db),
+	}
+}
+
+func (r *UserRepository) StoreUser(ctx context.Context, user *domain.User) error {
+	_, err := r.queries.CreateUser(ctx, CreateUserParams{
+		ID:        user.ID,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+	})
+	return err
+}
+
+func (r *UserRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	row, err := r.queries.GetUserByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return toUser(row), nil
+}
+
+func (r *UserRepository) FindUserByName(ctx context.Context, name string) (*domain.User, error) {
+	row, err := r.queries.GetUserByName(ctx, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return toUser(row), nil
+}
+
+func (r *UserRepository) ListUsers(ctx context.Context) ([]*domain.User, error) {
+	rows, err := r.queries.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*domain.User, len(rows))
+	for i, row := range rows {
+		users[i] = toUser(row)
+	}
+	return users, nil
+}
+
+func (r *UserRepository) StoreAPIKey(ctx context.Context, key *domain.APIKey) error {
+	scopes := make([]string, len(key.Scopes))
+	for i, s := range key.Scopes {
+		scopes[i] = string(s)
+	}
+
+	_, err := r.queries.CreateAPIKey(ctx, CreateAPIKeyParams{
+		ID:         key.ID,
+		UserID:     key.UserID,
+		Name:       key.Name,
+		SecretHash: key.SecretHash,
+		Scopes:     scopes,
+		CreatedAt:  key.CreatedAt,
+	})
+	return err
+}
+
+func (r *UserRepository) FindAPIKeyByID(ctx context.Context, id string) (*domain.APIKey, error) {
+	row, err := r.queries.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return toAPIKey(row), nil
+}
+
+func (r *UserRepository) ListAPIKeys(ctx context.Context, userID string) ([]*domain.APIKey, error) {
+	rows, err := r.queries.ListAPIKeysByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*domain.APIKey, len(rows))
+	for i, row := range rows {
+		keys[i] = toAPIKey(row)
+	}
+	return keys, nil
+}
+
+func (r *UserRepository) TouchAPIKey(ctx context.Context, id string) error {
+	return r.queries.TouchAPIKey(ctx, id)
+}
+
+func (r *UserRepository) RevokeAPIKey(ctx context.Context, id string) error {
+	n, err := r.queries.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func toUser(row User) *domain.User {
+	return &domain.User{
+		ID:        row.ID,
+		Name:      row.Name,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func toAPIKey(row ApiKey) *domain.APIKey {
+	scopes := make([]domain.Scope, len(row.Scopes))
+	for i, s := range row.Scopes {
+		scopes[i] = domain.Scope(s)
+	}
+
+	return &domain.APIKey{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		Name:       row.Name,
+		SecretHash: row.SecretHash,
+		Scopes:     scopes,
+		CreatedAt:  row.CreatedAt,
+		LastUsedAt: row.LastUsedAt.Time,
+		RevokedAt:  row.RevokedAt.Time,
+	}
+}
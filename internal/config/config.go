@@ -1 +1,207 @@
+// Package config defines the server configuration and loads it from, in
+// increasing order of precedence: built-in defaults, a YAML or TOML file,
+// environment variables and command line flags.
 package config
+
+import (
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/duration"
+)
+
+// Config is the complete server configuration.
+type Config struct {
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Storage  StorageConfig  `yaml:"storage" toml:"storage"`
+	TLS      TLSConfig      `yaml:"tls" toml:"tls"`
+	Limits   LimitsConfig   `yaml:"limits" toml:"limits"`
+	TTL      TTLConfig      `yaml:"ttl" toml:"ttl"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	Quota    QuotaConfig    `yaml:"quota" toml:"quota"`
+	Cleanup  CleanupConfig  `yaml:"cleanup" toml:"cleanup"`
+	Log      LogConfig      `yaml:"log" toml:"log"`
+	CORS     CORSConfig     `yaml:"cors" toml:"cors"`
+}
+
+// ServerConfig configures the HTTP listener. A zero timeout disables it.
+type ServerConfig struct {
+	Listen            string        `yaml:"listen" toml:"listen"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// PublicURL is where clients reach the server, used for absolute links
+	// such as those in QR codes. Requests tell it when empty.
+	PublicURL string `yaml:"public_url" toml:"public_url"`
+}
+
+// DatabaseConfig configures the PostgreSQL connection pool.
+type DatabaseConfig struct {
+	URL             string        `yaml:"url" toml:"url" env:"DATABASE_URL" secret:"true"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+}
+
+// StorageConfig selects and configures the object storage backend.
+type StorageConfig struct {
+	Backend string      `yaml:"backend" toml:"backend"`
+	Minio   MinioConfig `yaml:"minio" toml:"minio"`
+}
+
+// MinioConfig configures the MinIO (or any S3 compatible) backend.
+type MinioConfig struct {
+	Endpoint  string `yaml:"endpoint" toml:"endpoint" env:"MINIO_ENDPOINT"`
+	AccessKey string `yaml:"access_key" toml:"access_key" env:"MINIO_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" toml:"secret_key" env:"MINIO_SECRET_KEY" secret:"true"`
+	Bucket    string `yaml:"bucket" toml:"bucket" env:"MINIO_BUCKET"`
+	UseSSL    bool   `yaml:"use_ssl" toml:"use_ssl" env:"MINIO_USE_SSL"`
+}
+
+// TLSConfig enables HTTPS (and HTTP/2) on the listener. The certificate is
+// reloaded on SIGHUP and, every ReloadInterval, when its files change.
+type TLSConfig struct {
+	Enabled        bool          `yaml:"enabled" toml:"enabled"`
+	CertFile       string        `yaml:"cert_file" toml:"cert_file"`
+	KeyFile        string        `yaml:"key_file" toml:"key_file"`
+	MinVersion     string        `yaml:"min_version" toml:"min_version"`
+	ReloadInterval time.Duration `yaml:"reload_interval" toml:"reload_interval"`
+	// RedirectListen, when set, serves plain HTTP redirects to HTTPS there
+	RedirectListen string `yaml:"redirect_listen" toml:"redirect_listen"`
+}
+
+// LimitsConfig bounds the size of uploaded content.
+type LimitsConfig struct {
+	MaxFileSize  ByteSize `yaml:"max_file_size" toml:"max_file_size"`
+	MaxPasteSize ByteSize `yaml:"max_paste_size" toml:"max_paste_size"`
+	// MaxBundleSize bounds all the files of a bundle together
+	MaxBundleSize ByteSize `yaml:"max_bundle_size" toml:"max_bundle_size"`
+}
+
+// TTLConfig controls how long content lives. Durations accept days and
+// weeks, e.g. "7d" or "2w".
+type TTLConfig struct {
+	Default duration.Duration `yaml:"default" toml:"default"`
+	Min     duration.Duration `yaml:"min" toml:"min"`
+	Max     duration.Duration `yaml:"max" toml:"max"`
+	// Presets are the choices offered by clients; "never" offers permanent content
+	Presets        []string `yaml:"presets" toml:"presets"`
+	AllowPermanent bool     `yaml:"allow_permanent" toml:"allow_permanent"`
+}
+
+// Policy converts the configuration into the domain TTL policy.
+func (c TTLConfig) Policy() domain.TTLPolicy {
+	return domain.TTLPolicy{
+		Default:        time.Duration(c.Default),
+		Min:            time.Duration(c.Min),
+		Max:            time.Duration(c.Max),
+		Presets:        c.Presets,
+		AllowPermanent: c.AllowPermanent,
+	}
+}
+
+// AuthConfig controls what requests without an API key may do. Reading
+// shared links never needs one.
+type AuthConfig struct {
+	AllowAnonymous bool `yaml:"allow_anonymous" toml:"allow_anonymous"`
+}
+
+// QuotaConfig bounds stored content per user and for the whole server. Zero
+// means unlimited. Anonymous content only counts toward the global quota.
+type QuotaConfig struct {
+	UserMaxBytes   ByteSize `yaml:"user_max_bytes" toml:"user_max_bytes"`
+	UserMaxItems   int64    `yaml:"user_max_items" toml:"user_max_items"`
+	GlobalMaxBytes ByteSize `yaml:"global_max_bytes" toml:"global_max_bytes"`
+	GlobalMaxItems int64    `yaml:"global_max_items" toml:"global_max_items"`
+}
+
+// Policy converts the configuration into the domain quota policy.
+func (c QuotaConfig) Policy() domain.QuotaPolicy {
+	return domain.QuotaPolicy{
+		User:   domain.Quota{MaxItems: c.UserMaxItems, MaxBytes: c.UserMaxBytes.Bytes()},
+		Global: domain.Quota{MaxItems: c.GlobalMaxItems, MaxBytes: c.GlobalMaxBytes.Bytes()},
+	}
+}
+
+// CleanupConfig controls the expired content sweeper.
+type CleanupConfig struct {
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+}
+
+// LogConfig configures the server logger.
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+	Output string `yaml:"output" toml:"output"`
+	File   string `yaml:"file" toml:"file"`
+	Caller bool   `yaml:"caller" toml:"caller"`
+	Colors bool   `yaml:"colors" toml:"colors"`
+}
+
+// CORSConfig lists the browser origins allowed to call the API.
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+}
+
+// Default returns the configuration used when nothing overrides it. It has no
+// database or storage credentials: those must always be provided.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Listen:            ":8080",
+			ReadHeaderTimeout: 10 * time.Second,
+			// Bodies and responses can be large files on slow links, so the
+			// whole-request timeouts are generous rather than tight
+			ReadTimeout:     30 * time.Minute,
+			WriteTimeout:    30 * time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+		},
+		Storage: StorageConfig{
+			Backend: "minio",
+			Minio: MinioConfig{
+				Endpoint: "localhost:9000",
+				Bucket:   "uploads",
+			},
+		},
+		TLS: TLSConfig{
+			MinVersion:     "1.2",
+			ReloadInterval: time.Minute,
+		},
+		Limits: LimitsConfig{
+			MaxFileSize:   100 * MiB,
+			MaxPasteSize:  1 * MiB,
+			MaxBundleSize: 500 * MiB,
+		},
+		TTL: TTLConfig{
+			Default: duration.Duration(24 * time.Hour),
+			Min:     duration.Duration(time.Minute),
+			Max:     duration.Duration(30 * duration.Day),
+			Presets: []string{"1h", "1d", "3d", "7d"},
+		},
+		Auth: AuthConfig{
+			AllowAnonymous: true,
+		},
+		Cleanup: CleanupConfig{
+			Interval: time.Hour,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+			Output: "stdout",
+			File:   "quip.log",
+			Colors: true,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+		},
+	}
+}
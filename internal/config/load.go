@@ -0,0 +1,213 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variable of every key, so that
+// server.listen is read from QUIP_SERVER_LISTEN.
+const EnvPrefix = "QUIP_"
+
+// Flags are the command line overrides, applied last. Unset flags leave the
+// file and environment values untouched.
+type Flags struct {
+	Listen      *string           `help:"Address to listen on." placeholder:"ADDR"`
+	DatabaseURL *string           `name:"database-url" help:"PostgreSQL connection URL." placeholder:"URL"`
+	LogLevel    *string           `help:"Log level (debug, info, warn, error)." placeholder:"LEVEL"`
+	Set         map[string]string `help:"Override any configuration key, e.g. --set limits.max_file_size=1GiB." placeholder:"KEY=VALUE"`
+}
+
+// Load builds the configuration from the defaults, the file at path (skipped
+// when empty), the environment and flags. The result is not validated, so
+// callers can still inspect a broken configuration; call Validate before use.
+func Load(path string, flags Flags) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.loadFlags(flags); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Set assigns the key, written as a dotted path such as "server.listen",
+// parsing value the same way an environment variable is parsed.
+func (c *Config) Set(key, value string) error {
+	for _, f := range c.fields() {
+		if f.key == key {
+			if err := setValue(f.value, value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown configuration key %q", key)
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("parsing %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q, expected .yaml, .yml or .toml", path, ext)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv(lookup func(string) (string, bool)) error {
+	for _, f := range c.fields() {
+		// The QUIP_ name wins over the historical unprefixed one
+		names := []string{f.envName()}
+		if f.legacyEnv != "" {
+			names = append(names, f.legacyEnv)
+		}
+		for _, name := range names {
+			raw, ok := lookup(name)
+			if !ok {
+				continue
+			}
+			if err := setValue(f.value, raw); err != nil {
+				return fmt.Errorf("environment variable %s: %w", name, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func (c *Config) loadFlags(flags Flags) error {
+	if flags.Listen != nil {
+		c.Server.Listen = *flags.Listen
+	}
+	if flags.DatabaseURL != nil {
+		c.Database.URL = *flags.DatabaseURL
+	}
+	if flags.LogLevel != nil {
+		c.Log.Level = *flags.LogLevel
+	}
+	for key, value := range flags.Set {
+		if err := c.Set(key, value); err != nil {
+			return fmt.Errorf("flag --set: %w", err)
+		}
+	}
+	return nil
+}
+
+// field is a leaf of the configuration tree
+type field struct {
+	key       string // dotted path, e.g. "storage.minio.bucket"
+	legacyEnv string
+	secret    bool
+	value     reflect.Value
+}
+
+func (f field) envName() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(f.key, ".", "_"))
+}
+
+// fields lists every leaf of c, addressable so they can be assigned
+func (c *Config) fields() []field {
+	var fields []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+			key := prefix + name
+			if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Time{}) {
+				walk(v.Field(i), key+".")
+				continue
+			}
+			fields = append(fields, field{
+				key:       key,
+				legacyEnv: sf.Tag.Get("env"),
+				secret:    sf.Tag.Get("secret") == "true",
+				value:     v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), "")
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue parses raw into v according to its type
+func setValue(v reflect.Value, raw string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int, v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for item := range strings.SplitSeq(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
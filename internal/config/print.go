@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// Redacted returns a copy of the configuration with its secrets masked. A
+// secret URL keeps everything but its password so it stays recognisable.
+func (c *Config) Redacted() *Config {
+	out := *c
+	out.CORS.AllowedOrigins = append([]string(nil), c.CORS.AllowedOrigins...)
+	out.TTL.Presets = append([]string(nil), c.TTL.Presets...)
+
+	for _, f := range out.fields() {
+		if !f.secret || f.value.String() == "" {
+			continue
+		}
+		if u, err := url.Parse(f.value.String()); err == nil && u.User != nil {
+			if _, hasPassword := u.User.Password(); hasPassword {
+				u.User = url.UserPassword(u.User.Username(), redacted)
+			}
+			f.value.SetString(u.String())
+			continue
+		}
+		f.value.SetString(redacted)
+	}
+	return &out
+}
+
+// Print writes the configuration, secrets redacted, as "yaml" or "toml".
+func (c *Config) Print(w io.Writer, format string) error {
+	switch format {
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer enc.Close()
+		return enc.Encode(c.Redacted())
+	case "toml":
+		return toml.NewEncoder(w).Encode(c.Redacted())
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the configuration and reports all problems at once.
+func (c *Config) Validate() error {
+	v := &ValidationError{}
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			v.Problems = append(v.Problems, key+": "+fmt.Sprintf(format, args...))
+		}
+	}
+
+	// Server
+	_, _, err := net.SplitHostPort(c.Server.Listen)
+	check(err == nil, "server.listen", "must be a host:port address, got %q", c.Server.Listen)
+	check(c.Server.ReadHeaderTimeout >= 0, "server.read_header_timeout", "must not be negative")
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout", "must not be negative")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout", "must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout", "must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive")
+	if c.Server.PublicURL != "" {
+		u, err := url.Parse(c.Server.PublicURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"server.public_url", "must be an http:// or https:// URL, got %q", c.Server.PublicURL)
+	}
+
+	// Database
+	check(c.Database.URL != "", "database.url", "is required")
+	if c.Database.URL != "" {
+		// The driver takes postgres:// URLs and libpq's key=value strings. Its
+		// errors may quote the password, so they are not shown.
+		_, err := pq.NewConnector(c.Database.URL)
+		check(err == nil, "database.url", "must be a postgres:// URL or a key=value connection string")
+	}
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns", "must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns", "must not be negative")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime", "must not be negative")
+
+	// Storage
+	switch c.Storage.Backend {
+	case "minio":
+		check(c.Storage.Minio.Endpoint != "", "storage.minio.endpoint", "is required")
+		check(c.Storage.Minio.Bucket != "", "storage.minio.bucket", "is required")
+		check(c.Storage.Minio.AccessKey != "", "storage.minio.access_key", "is required")
+		check(c.Storage.Minio.SecretKey != "", "storage.minio.secret_key", "is required")
+	default:
+		check(false, "storage.backend", "unsupported backend %q, expected \"minio\"", c.Storage.Backend)
+	}
+
+	// TLS
+	if c.TLS.Enabled {
+		for _, f := range []struct{ key, path string }{
+			{"tls.cert_file", c.TLS.CertFile},
+			{"tls.key_file", c.TLS.KeyFile},
+		} {
+			check(f.path != "", f.key, "is required when tls.enabled is true")
+			if f.path != "" {
+				_, err := os.Stat(f.path)
+				check(err == nil, f.key, "%v", err)
+			}
+		}
+		check(c.TLS.MinVersion == "1.2" || c.TLS.MinVersion == "1.3",
+			"tls.min_version", "must be 1.2 or 1.3, got %q", c.TLS.MinVersion)
+		check(c.TLS.ReloadInterval >= 0, "tls.reload_interval", "must not be negative")
+		if c.TLS.RedirectListen != "" {
+			_, _, err := net.SplitHostPort(c.TLS.RedirectListen)
+			check(err == nil, "tls.redirect_listen", "must be a host:port address, got %q", c.TLS.RedirectListen)
+			check(c.TLS.RedirectListen != c.Server.Listen, "tls.redirect_listen", "must differ from server.listen")
+		}
+	} else {
+		check(c.TLS.RedirectListen == "", "tls.redirect_listen", "requires tls.enabled")
+	}
+
+	// Limits
+	check(c.Limits.MaxFileSize > 0, "limits.max_file_size", "must be positive")
+	check(c.Limits.MaxPasteSize > 0, "limits.max_paste_size", "must be positive")
+	check(c.Limits.MaxBundleSize > 0, "limits.max_bundle_size", "must be positive")
+
+	// Quota
+	for _, q := range []struct {
+		key   string
+		value int64
+	}{
+		{"quota.user_max_bytes", c.Quota.UserMaxBytes.Bytes()},
+		{"quota.user_max_items", c.Quota.UserMaxItems},
+		{"quota.global_max_bytes", c.Quota.GlobalMaxBytes.Bytes()},
+		{"quota.global_max_items", c.Quota.GlobalMaxItems},
+	} {
+		check(q.value >= 0, q.key, "must not be negative, use 0 for unlimited")
+	}
+
+	// TTL
+	check(c.TTL.Min > 0, "ttl.min", "must be positive")
+	check(c.TTL.Max >= c.TTL.Min, "ttl.max", "must not be below ttl.min (%s)", c.TTL.Min)
+	check(c.TTL.Default >= c.TTL.Min && c.TTL.Default <= c.TTL.Max,
+		"ttl.default", "must lie between ttl.min (%s) and ttl.max (%s), got %s", c.TTL.Min, c.TTL.Max, c.TTL.Default)
+	policy := c.TTL.Policy()
+	for _, preset := range c.TTL.Presets {
+		// Presets are offered to authenticated and anonymous users alike
+		_, err := policy.Resolve(preset, true)
+		check(err == nil, "ttl.presets", "%q: %v", preset, err)
+	}
+
+	// Cleanup
+	check(c.Cleanup.Interval > 0, "cleanup.interval", "must be positive")
+
+	// Log
+	check(slices.Contains([]string{"debug", "info", "warn", "error"}, c.Log.Level),
+		"log.level", "must be one of debug, info, warn, error, got %q", c.Log.Level)
+	check(slices.Contains([]string{"text", "json"}, c.Log.Format),
+		"log.format", "must be text or json, got %q", c.Log.Format)
+	check(slices.Contains([]string{"stdout", "stderr", "file"}, c.Log.Output),
+		"log.output", "must be one of stdout, stderr, file, got %q", c.Log.Output)
+	check(c.Log.Output != "file" || c.Log.File != "", "log.file", "is required when log.output is file")
+
+	// CORS
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "",
+			"cors.allowed_origins", "%q is not an origin such as https://example.com", origin)
+	}
+
+	if len(v.Problems) > 0 {
+		return v
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scope is a permission granted to an API key
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"  // list and read the user's content
+	ScopeWrite Scope = "write" // create and delete the user's content, implies read
+	ScopeAdmin Scope = "admin" // manage everyone's content, implies write
+)
+
+// includes reports whether holding s grants want
+func (s Scope) includes(want Scope) bool {
+	rank := map[Scope]int{ScopeRead: 1, ScopeWrite: 2, ScopeAdmin: 3}
+	return rank[s] > 0 && rank[s] >= rank[want]
+}
+
+// ParseScopes validates scope names such as "read" or "write"
+func ParseScopes(names []string) ([]Scope, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidInput)
+	}
+	scopes := make([]Scope, 0, len(names))
+	for _, name := range names {
+		s := Scope(strings.ToLower(strings.TrimSpace(name)))
+		if !s.includes(ScopeRead) {
+			return nil, fmt.Errorf("%w: unknown scope %q, want read, write or admin", ErrInvalidInput, name)
+		}
+		scopes = append(scopes, s)
+	}
+	return scopes, nil
+}
+
+// apiKeyPrefix starts every API key so leaked keys are easy to recognise
+const apiKeyPrefix = "quip_"
+
+// APIKey authenticates a user. Only the SHA-256 hash of the secret is kept;
+// the full key, "quip_<id>_<secret>", is shown once when it is created.
+type APIKey struct {
+	ID         string
+	UserID     string
+	Name       string
+	SecretHash string
+	Scopes     []Scope
+	CreatedAt  time.Time
+	LastUsedAt time.Time // zero if never used
+	RevokedAt  time.Time // zero while the key is valid
+}
+
+// NewAPIKey creates a key for the user and returns it with its plaintext form
+func NewAPIKey(userID, name string, scopes []Scope) (*APIKey, string) {
+	id, secret := randomHex(6), randomHex(24)
+	key := &APIKey{
+		ID:         id,
+		UserID:     userID,
+		Name:       name,
+		SecretHash: hashSecret(secret),
+		Scopes:     scopes,
+		CreatedAt:  time.Now(),
+	}
+	return key, apiKeyPrefix + id + "_" + secret
+}
+
+// SplitAPIKey extracts the key ID and secret from a plaintext API key
+func SplitAPIKey(token string) (id, secret string, ok bool) {
+	rest, found := strings.CutPrefix(token, apiKeyPrefix)
+	if !found {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	return id, secret, ok && id != "" && secret != ""
+}
+
+// Matches reports whether secret is the key's secret, in constant time
+func (k *APIKey) Matches(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(k.SecretHash), []byte(hashSecret(secret))) == 1
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// newManageToken returns a random token and its hash. The token lets whoever
+// created a piece of content delete it later, even without an account.
+func newManageToken() (token, hash string) {
+	token = randomHex(16)
+	return token, hashSecret(token)
+}
+
+// matchesToken reports whether token hashes to hash, in constant time
+func matchesToken(hash, token string) bool {
+	if hash == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashSecret(token))) == 1
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,70 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey(t *testing.T) {
+	key, plaintext := domain.NewAPIKey("user1", "laptop", []domain.Scope{domain.ScopeRead})
+	assert.True(t, strings.HasPrefix(plaintext, "quip_"))
+	assert.NotContains(t, key.SecretHash, plaintext)
+
+	id, secret, ok := domain.SplitAPIKey(plaintext)
+	require.True(t, ok)
+	assert.Equal(t, key.ID, id)
+	assert.True(t, key.Matches(secret))
+	assert.False(t, key.Matches(secret+"x"))
+
+	for _, malformed := range []string{"", "quip_", "quip_abc", "quip__secret", "token_abc_def"} {
+		_, _, ok := domain.SplitAPIKey(malformed)
+		assert.False(t, ok, malformed)
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := domain.ParseScopes([]string{"read", " Write "})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Scope{domain.ScopeRead, domain.ScopeWrite}, scopes)
+
+	_, err = domain.ParseScopes([]string{"read", "delete"})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	_, err = domain.ParseScopes(nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestPrincipalAllows(t *testing.T) {
+	reader := &domain.Principal{Scopes: []domain.Scope{domain.ScopeRead}}
+	admin := &domain.Principal{Scopes: []domain.Scope{domain.ScopeAdmin}}
+	var anonymous *domain.Principal
+
+	assert.True(t, reader.Allows(domain.ScopeRead))
+	assert.False(t, reader.Allows(domain.ScopeWrite))
+	assert.True(t, admin.Allows(domain.ScopeWrite), "admin implies write")
+	assert.False(t, anonymous.Allows(domain.ScopeRead))
+}
+
+func TestCanManage(t *testing.T) {
+	owner := &domain.Principal{UserID: "alice", Scopes: []domain.Scope{domain.ScopeWrite}}
+	readOnlyOwner := &domain.Principal{UserID: "alice", Scopes: []domain.Scope{domain.ScopeRead}}
+	other := &domain.Principal{UserID: "bob", Scopes: []domain.Scope{domain.ScopeWrite}}
+	admin := &domain.Principal{UserID: "root", Scopes: []domain.Scope{domain.ScopeAdmin}}
+
+	owned := domain.NewPaste("x", "Text", "", time.Hour, "alice")
+	assert.True(t, owned.CanManage(owner, ""))
+	assert.False(t, owned.CanManage(readOnlyOwner, ""))
+	assert.False(t, owned.CanManage(other, ""))
+	assert.True(t, owned.CanManage(admin, ""))
+	assert.True(t, owned.CanManage(nil, owned.ManageToken), "the manage token works without an account")
+
+	anonymous := domain.NewFile("a.txt", 1, "text/plain", time.Hour, "")
+	assert.False(t, anonymous.CanManage(other, ""), "nobody owns anonymous content")
+	assert.False(t, anonymous.CanManage(nil, ""))
+	assert.False(t, anonymous.CanManage(nil, "wrong"))
+	assert.True(t, anonymous.CanManage(nil, anonymous.ManageToken))
+}
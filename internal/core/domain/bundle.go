@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// MaxBundleFiles bounds the number of files in a bundle
+const MaxBundleFiles = 100
+
+// Bundle groups files uploaded together behind a single link. Its files
+// share its expiry, owner and manage token, and every download of the
+// bundle or of one of its files counts toward its download limit.
+type Bundle struct {
+	ID           string
+	Title        string
+	Files        []*File
+	Downloads    int
+	MaxDownloads int // -1 for unlimited
+	CreatedAt    time.Time
+	ExpiresAt    time.Time // zero for bundles that never expire
+	OwnerID      string    // empty for anonymous uploads
+	// ManageTokenHash is the hash of the token that lets the uploader delete the bundle
+	ManageTokenHash string
+	// ManageToken is the plaintext token, only known right after upload
+	ManageToken string
+}
+
+// NewBundle returns an empty bundle. maxDownloads of zero or less means
+// unlimited.
+func NewBundle(title string, ttl time.Duration, maxDownloads int, ownerID string) *Bundle {
+	if maxDownloads <= 0 {
+		maxDownloads = -1
+	}
+	token, hash := newManageToken()
+	now := time.Now()
+	return &Bundle{
+		ID:           generateID(),
+		Title:        title,
+		MaxDownloads: maxDownloads,
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt(now, ttl),
+		OwnerID:      ownerID,
+
+		ManageTokenHash: hash,
+		ManageToken:     token,
+	}
+}
+
+// Add creates a file in the bundle
+func (b *Bundle) Add(originalName string, size int64, contentType string) (*File, error) {
+	if len(b.Files) >= MaxBundleFiles {
+		return nil, fmt.Errorf("%w: bundles hold at most %d files", ErrInvalidInput, MaxBundleFiles)
+	}
+	file := &File{
+		ID:           generateID(),
+		OriginalName: originalName,
+		Size:         size,
+		ContentType:  contentType,
+		StorageKey:   generateStorageKey(),
+		MaxDownloads: -1, // the bundle keeps the count
+		CreatedAt:    b.CreatedAt,
+		ExpiresAt:    b.ExpiresAt,
+		OwnerID:      b.OwnerID,
+		BundleID:     b.ID,
+
+		ManageTokenHash: b.ManageTokenHash,
+	}
+	b.Files = append(b.Files, file)
+	return file, nil
+}
+
+// Size returns the size of all the files of the bundle
+func (b *Bundle) Size() int64 {
+	var size int64
+	for _, f := range b.Files {
+		size += f.Size
+	}
+	return size
+}
+
+func (b *Bundle) IsExpired() bool {
+	return !b.ExpiresAt.IsZero() && time.Now().After(b.ExpiresAt)
+}
+
+func (b *Bundle) CanDownload() bool {
+	if b.IsExpired() {
+		return false
+	}
+	if b.MaxDownloads > 0 && b.Downloads >= b.MaxDownloads {
+		return false
+	}
+	return true
+}
+
+// CanManage reports whether the principal, or the holder of the manage
+// token, may delete the bundle
+func (b *Bundle) CanManage(p *Principal, token string) bool {
+	return p.owns(b.OwnerID) || matchesToken(b.ManageTokenHash, token)
+}
+
+// ArchiveNames returns the names of the bundle's files in an archive, in
+// order. Files uploaded under the same name are told apart with a counter,
+// e.g. "app (2).log".
+func (b *Bundle) ArchiveNames() []string {
+	names := make([]string, len(b.Files))
+	taken := make(map[string]bool, len(b.Files))
+	for i, f := range b.Files {
+		name := path.Base(strings.ReplaceAll(f.OriginalName, `\`, "/"))
+		if name == "." || name == "/" || name == ".." {
+			name = f.ID
+		}
+		ext := path.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		for n := 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+		}
+		taken[name] = true
+		names[i] = name
+	}
+	return names
+}
@@ -0,0 +1,34 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundle(t *testing.T) {
+	b := domain.NewBundle("logs", time.Hour, 0, "alice")
+	assert.Equal(t, -1, b.MaxDownloads, "zero means unlimited")
+
+	for _, name := range []string{"app.log", "app.log", "../etc/app.log", `C:\logs\db.log`, "app (2).log"} {
+		f, err := b.Add(name, 10, "text/plain")
+		require.NoError(t, err)
+		assert.Equal(t, b.ID, f.BundleID)
+		assert.Equal(t, b.ManageTokenHash, f.ManageTokenHash, "the bundle's token manages its files")
+	}
+	assert.Equal(t, int64(50), b.Size())
+	assert.Equal(t, []string{"app.log", "app (2).log", "app (3).log", "db.log", "app (2) (2).log"}, b.ArchiveNames())
+
+	b.MaxDownloads, b.Downloads = 2, 2
+	assert.False(t, b.CanDownload())
+
+	for len(b.Files) < domain.MaxBundleFiles {
+		_, err := b.Add("more", 1, "text/plain")
+		require.NoError(t, err)
+	}
+	_, err := b.Add("one too many", 1, "text/plain")
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// DiffContext is the number of unchanged lines kept around each change
+const DiffContext = 3
+
+// DiffOp says what happened to a line, with the character a unified diff
+// prefixes it with
+type DiffOp byte
+
+const (
+	DiffEqual  DiffOp = ' '
+	DiffDelete DiffOp = '-'
+	DiffInsert DiffOp = '+'
+)
+
+// DiffLine is a line of a hunk, without its line break
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// Hunk is a run of changes with its surrounding context. Lines are numbered
+// from 1; an empty range starts at the line before it.
+type Hunk struct {
+	FromLine, FromCount int
+	ToLine, ToCount     int
+	Lines               []DiffLine
+}
+
+// Diff holds the line changes from one text to another
+type Diff struct {
+	From, To string // names of the compared texts
+	Hunks    []Hunk // empty when the texts are the same
+}
+
+// NewDiff compares the texts from and to line by line
+func NewDiff(fromName, from, toName, to string) *Diff {
+	a, b := splitLines(from), splitLines(to)
+	diff := &Diff{From: fromName, To: toName, Hunks: []Hunk{}}
+
+	matcher := difflib.NewMatcher(a, b)
+	for _, group := range matcher.GetGroupedOpCodes(DiffContext) {
+		first, last := group[0], group[len(group)-1]
+		hunk := Hunk{
+			FromLine: rangeStart(first.I1, last.I2), FromCount: last.I2 - first.I1,
+			ToLine: rangeStart(first.J1, last.J2), ToCount: last.J2 - first.J1,
+		}
+		for _, code := range group {
+			if code.Tag == 'e' {
+				hunk.Lines = appendLines(hunk.Lines, DiffEqual, a[code.I1:code.I2])
+				continue
+			}
+			// Replacements are a deletion followed by an insertion
+			hunk.Lines = appendLines(hunk.Lines, DiffDelete, a[code.I1:code.I2])
+			hunk.Lines = appendLines(hunk.Lines, DiffInsert, b[code.J1:code.J2])
+		}
+		diff.Hunks = append(diff.Hunks, hunk)
+	}
+	return diff
+}
+
+// Unified formats the diff in unified diff format, empty when there are no
+// changes
+func (d *Diff) Unified() string {
+	if len(d.Hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", d.From, d.To)
+	for _, h := range d.Hunks {
+		b.WriteString(h.Header())
+		b.WriteByte('\n')
+		for _, line := range h.Lines {
+			b.WriteByte(byte(line.Op))
+			b.WriteString(line.Text)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// Header returns the "@@ -1,3 +1,4 @@" line of the hunk
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", formatRange(h.FromLine, h.FromCount), formatRange(h.ToLine, h.ToCount))
+}
+
+// splitLines splits text into lines without their line breaks
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func appendLines(lines []DiffLine, op DiffOp, texts []string) []DiffLine {
+	for _, text := range texts {
+		lines = append(lines, DiffLine{Op: op, Text: text})
+	}
+	return lines
+}
+
+// rangeStart numbers the first line of [start, stop), following the diff
+// convention that an empty range starts at the line before it
+func rangeStart(start, stop int) int {
+	if start == stop {
+		return start
+	}
+	return start + 1
+}
+
+func formatRange(line, count int) string {
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
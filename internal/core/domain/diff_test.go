@@ -0,0 +1,35 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	to := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+
+	diff := domain.NewDiff("a", from, "b", to)
+	require.Len(t, diff.Hunks, 2, "changes far apart get their own hunks")
+	assert.Equal(t, "@@ -1,6 +1,6 @@", diff.Hunks[0].Header())
+	assert.Equal(t, []domain.DiffLine{
+		{Op: domain.DiffEqual, Text: "1"},
+		{Op: domain.DiffEqual, Text: "2"},
+		{Op: domain.DiffDelete, Text: "3"},
+		{Op: domain.DiffInsert, Text: "three"},
+		{Op: domain.DiffEqual, Text: "4"},
+		{Op: domain.DiffEqual, Text: "5"},
+		{Op: domain.DiffEqual, Text: "6"},
+	}, diff.Hunks[0].Lines)
+	assert.Equal(t, "@@ -10,3 +10,4 @@", diff.Hunks[1].Header())
+
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n", domain.NewDiff("a", "", "b", "new").Unified())
+	assert.Equal(t, "@@ -1 +0,0 @@", domain.NewDiff("a", "old\n", "b", "").Hunks[0].Header())
+
+	same := domain.NewDiff("a", from, "b", from)
+	assert.Empty(t, same.Hunks)
+	assert.Empty(t, same.Unified())
+}
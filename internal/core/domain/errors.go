@@ -3,8 +3,14 @@ package domain
 import "errors"
 
 var (
-    ErrNotFound      = errors.New("not found")
-    ErrExpired       = errors.New("content has expired")
-    ErrLimitExceeded = errors.New("download/view limit exceeded")
-    ErrInvalidInput  = errors.New("invalid input")
-)
\ No newline at end of file
+	ErrNotFound      = errors.New("not found")
+	ErrExpired       = errors.New("content has expired")
+	ErrLimitExceeded = errors.New("download/view limit exceeded")
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrTooLarge      = errors.New("content too large")
+	ErrUnauthorized  = errors.New("authentication required")
+	ErrForbidden     = errors.New("permission denied")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrStorageFull   = errors.New("server storage is full")
+	ErrConflict      = errors.New("conflicting change")
+)
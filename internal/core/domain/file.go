@@ -16,10 +16,17 @@ type File struct {
 	Downloads    int
 	MaxDownloads int
 	CreatedAt    time.Time
-	ExpiresAt    time.Time
+	ExpiresAt    time.Time // zero for content that never expires
+	OwnerID      string    // empty for anonymous uploads
+	BundleID     string    // empty for files uploaded on their own
+	// ManageTokenHash is the hash of the token that lets the uploader delete the file
+	ManageTokenHash string
+	// ManageToken is the plaintext token, only known right after upload
+	ManageToken string
 }
 
-func NewFile(originalName string, size int64, contentType string, ttl time.Duration) *File {
+func NewFile(originalName string, size int64, contentType string, ttl time.Duration, ownerID string) *File {
+	token, hash := newManageToken()
 	return &File{
 		ID:           generateID(),
 		OriginalName: originalName,
@@ -29,12 +36,16 @@ func NewFile(originalName string, size int64, contentType string, ttl time.Durat
 		Downloads:    0,
 		MaxDownloads: -1, // unlimited
 		CreatedAt:    time.Now(),
-		ExpiresAt:    time.Now().Add(ttl),
+		ExpiresAt:    expiresAt(time.Now(), ttl),
+		OwnerID:      ownerID,
+
+		ManageTokenHash: hash,
+		ManageToken:     token,
 	}
 }
 
 func (f *File) IsExpired() bool {
-	return time.Now().After(f.ExpiresAt)
+	return !f.ExpiresAt.IsZero() && time.Now().After(f.ExpiresAt)
 }
 
 func (f *File) CanDownload() bool {
@@ -47,6 +58,12 @@ func (f *File) CanDownload() bool {
 	return true
 }
 
+// CanManage reports whether the principal, or the holder of the manage
+// token, may delete the file
+func (f *File) CanManage(p *Principal, token string) bool {
+	return p.owns(f.OwnerID) || matchesToken(f.ManageTokenHash, token)
+}
+
 func (f *File) IncrementDownloads() {
 	f.Downloads++
 }
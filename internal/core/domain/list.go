@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SortKey orders listings
+type SortKey string
+
+const (
+	SortCreated SortKey = "created" // newest first
+	SortExpires SortKey = "expires" // soonest to expire first, permanent content last
+	SortSize    SortKey = "size"    // largest first
+)
+
+// ParseSortKey validates a sort key, defaulting to SortCreated
+func ParseSortKey(s string) (SortKey, error) {
+	switch key := SortKey(s); key {
+	case "":
+		return SortCreated, nil
+	case SortCreated, SortExpires, SortSize:
+		return key, nil
+	default:
+		return "", fmt.Errorf("%w: unknown sort %q, want created, expires or size", ErrInvalidInput, s)
+	}
+}
+
+// ListFilter narrows a listing. Zero fields do not filter.
+type ListFilter struct {
+	OwnerID       string
+	Kind          string // files only: the MIME major type, e.g. "image"
+	ContentType   string // files only
+	Language      string // pastes only
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// ExpiresBefore keeps content expiring soon; permanent content never matches
+	ExpiresBefore time.Time
+}
+
+// Page selects a window of a listing
+type Page struct {
+	Sort  SortKey
+	After *Cursor // nil for the first page
+	Limit int
+}
+
+// Cursor marks the last item of a page, so the next page starts right after
+// it even when content is added or removed in between
+type Cursor struct {
+	Sort SortKey
+	Time time.Time // creation or expiry, for SortCreated and SortExpires
+	Size int64     // for SortSize
+	ID   string
+}
+
+// NoExpirySortKey is where permanent content sorts by expiry
+var NoExpirySortKey = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+func newCursor(sort SortKey, id string, size int64, createdAt, expiresAt time.Time) *Cursor {
+	c := &Cursor{Sort: sort, ID: id}
+	switch sort {
+	case SortCreated:
+		c.Time = createdAt
+	case SortExpires:
+		c.Time = expiresAt
+		if expiresAt.IsZero() {
+			c.Time = NoExpirySortKey
+		}
+	case SortSize:
+		c.Size = size
+	}
+	return c
+}
+
+// CursorAfter returns the cursor of the page that follows f
+func (f *File) CursorAfter(sort SortKey) *Cursor {
+	return newCursor(sort, f.ID, f.Size, f.CreatedAt, f.ExpiresAt)
+}
+
+// CursorAfter returns the cursor of the page that follows p
+func (p *Paste) CursorAfter(sort SortKey) *Cursor {
+	return newCursor(sort, p.ID, int64(len(p.Content)), p.CreatedAt, p.ExpiresAt)
+}
+
+// String encodes the cursor as an opaque, URL-safe token
+func (c *Cursor) String() string {
+	key := strconv.FormatInt(c.Size, 10)
+	if c.Sort != SortSize {
+		key = strconv.FormatInt(c.Time.UnixMicro(), 10)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(string(c.Sort) + ":" + key + ":" + c.ID))
+}
+
+// ParseCursor decodes a token produced by Cursor.String
+func ParseCursor(token string) (*Cursor, error) {
+	invalid := fmt.Errorf("%w: invalid cursor", ErrInvalidInput)
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid
+	}
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return nil, invalid
+	}
+	sort, err := ParseSortKey(parts[0])
+	if err != nil || parts[0] == "" {
+		return nil, invalid
+	}
+	key, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+
+	c := &Cursor{Sort: sort, ID: parts[2]}
+	if sort == SortSize {
+		c.Size = key
+	} else {
+		c.Time = time.UnixMicro(key).UTC()
+	}
+	return c, nil
+}
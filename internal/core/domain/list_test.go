@@ -0,0 +1,49 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	file := domain.NewFile("a.txt", 42, "text/plain", time.Hour, "")
+	// Postgres keeps microseconds
+	file.CreatedAt = file.CreatedAt.Truncate(time.Microsecond)
+	file.ExpiresAt = file.ExpiresAt.Truncate(time.Microsecond)
+	permanent := domain.NewPaste("x", "Text", "", domain.NoExpiry, "")
+
+	for _, c := range []*domain.Cursor{
+		file.CursorAfter(domain.SortCreated),
+		file.CursorAfter(domain.SortExpires),
+		file.CursorAfter(domain.SortSize),
+		permanent.CursorAfter(domain.SortExpires),
+	} {
+		parsed, err := domain.ParseCursor(c.String())
+		require.NoError(t, err)
+		assert.Equal(t, c.Sort, parsed.Sort)
+		assert.Equal(t, c.ID, parsed.ID)
+		assert.Equal(t, c.Size, parsed.Size)
+		assert.True(t, c.Time.Equal(parsed.Time), "%s != %s", c.Time, parsed.Time)
+	}
+
+	assert.Equal(t, domain.NoExpirySortKey, permanent.CursorAfter(domain.SortExpires).Time)
+	assert.Equal(t, int64(42), file.CursorAfter(domain.SortSize).Size)
+
+	for _, bad := range []string{"", "!!!", "c2l6ZTp4OmFiYw", "Ym9ndXM6MTphYmM"} {
+		_, err := domain.ParseCursor(bad)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput, bad)
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	key, err := domain.ParseSortKey("")
+	require.NoError(t, err)
+	assert.Equal(t, domain.SortCreated, key)
+
+	_, err = domain.ParseSortKey("name")
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
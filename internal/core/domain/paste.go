@@ -1,9 +1,11 @@
 package domain
 
 import (
+	"slices"
 	"time"
 
 	"github.com/go-enry/go-enry/v2"
+	"github.com/go-enry/go-enry/v2/data"
 )
 
 type Paste struct {
@@ -14,14 +16,26 @@ type Paste struct {
 	Views     int
 	MaxViews  int
 	CreatedAt time.Time
-	ExpiresAt time.Time
+	ExpiresAt time.Time // zero for content that never expires
+	OwnerID   string    // empty for anonymous pastes
+	Revision  int       // number of the latest revision, from 1
+	UpdatedAt time.Time // zero until the paste is first edited
+	ParentID  string    // the paste this one was forked from, if any
+	// Files are the named files of a multi-file paste, nil otherwise. The
+	// first one is also the paste's Content and Language.
+	Files []PasteFile
+	// ManageTokenHash is the hash of the token that lets the author edit and delete the paste
+	ManageTokenHash string
+	// ManageToken is the plaintext token, only known right after creation
+	ManageToken string
 }
 
-func NewPaste(content, language, title string, ttl time.Duration) *Paste {
+func NewPaste(content, language, title string, ttl time.Duration, ownerID string) *Paste {
 	if language == "" {
 		language = detectLanguage(content)
 	}
 
+	token, hash := newManageToken()
 	return &Paste{
 		ID:        generateID(),
 		Content:   content,
@@ -30,12 +44,26 @@ func NewPaste(content, language, title string, ttl time.Duration) *Paste {
 		Views:     0,
 		MaxViews:  -1, // unlimited
 		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(ttl),
+		ExpiresAt: expiresAt(time.Now(), ttl),
+		OwnerID:   ownerID,
+		Revision:  1,
+
+		ManageTokenHash: hash,
+		ManageToken:     token,
 	}
 }
 
+// Fork returns a new paste seeded from the latest revision of p, linked to
+// it as its parent
+func (p *Paste) Fork(ttl time.Duration, ownerID string) *Paste {
+	fork := NewPaste(p.Content, p.Language, p.Title, ttl, ownerID)
+	fork.Files = append([]PasteFile(nil), p.Files...)
+	fork.ParentID = p.ID
+	return fork
+}
+
 func (p *Paste) IsExpired() bool {
-	return time.Now().After(p.ExpiresAt)
+	return !p.ExpiresAt.IsZero() && time.Now().After(p.ExpiresAt)
 }
 
 func (p *Paste) CanView() bool {
@@ -48,6 +76,12 @@ func (p *Paste) CanView() bool {
 	return true
 }
 
+// CanManage reports whether the principal, or the holder of the manage
+// token, may edit or delete the paste
+func (p *Paste) CanManage(principal *Principal, token string) bool {
+	return principal.owns(p.OwnerID) || matchesToken(p.ManageTokenHash, token)
+}
+
 func (p *Paste) IncrementViews() {
 	p.Views++
 }
@@ -55,3 +89,13 @@ func (p *Paste) IncrementViews() {
 func detectLanguage(content string) string {
 	return enry.GetLanguage("", []byte(content))
 }
+
+// Languages returns the names of the languages detection knows, sorted
+func Languages() []string {
+	names := make([]string, 0, len(data.IDByLanguage))
+	for name := range data.IDByLanguage {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
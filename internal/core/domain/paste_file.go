@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-enry/go-enry/v2"
+)
+
+// MaxPasteFiles bounds the number of files of a multi-file paste
+const MaxPasteFiles = 50
+
+// PasteFile is a named file of a multi-file paste
+type PasteFile struct {
+	Name     string
+	Content  string
+	Language string
+}
+
+// NewMultiFilePaste creates a paste made of several named files, like a gist.
+// Files without a language get one detected from their name and content.
+func NewMultiFilePaste(files []PasteFile, title string, ttl time.Duration, ownerID string) (*Paste, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: a paste needs at least one file", ErrInvalidInput)
+	}
+	if len(files) > MaxPasteFiles {
+		return nil, fmt.Errorf("%w: a paste has at most %d files", ErrInvalidInput, MaxPasteFiles)
+	}
+
+	files = append([]PasteFile(nil), files...)
+	seen := make(map[string]bool, len(files))
+	for i := range files {
+		f := &files[i]
+		if err := validateFileName(f.Name); err != nil {
+			return nil, err
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("%w: file %q appears twice", ErrInvalidInput, f.Name)
+		}
+		seen[f.Name] = true
+		if f.Content == "" {
+			return nil, fmt.Errorf("%w: file %q is empty", ErrInvalidInput, f.Name)
+		}
+		if f.Language == "" {
+			f.Language = enry.GetLanguage(f.Name, []byte(f.Content))
+		}
+	}
+
+	paste := NewPaste(files[0].Content, files[0].Language, title, ttl, ownerID)
+	paste.Files = files
+	return paste, nil
+}
+
+// IsMultiFile reports whether the paste is made of named files
+func (p *Paste) IsMultiFile() bool {
+	return len(p.Files) > 0
+}
+
+// File returns the file of a multi-file paste with the given name
+func (p *Paste) File(name string) (PasteFile, bool) {
+	for _, f := range p.Files {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return PasteFile{}, false
+}
+
+// Size returns the size of the paste's content in bytes, all files included
+func (p *Paste) Size() int64 {
+	if !p.IsMultiFile() {
+		return int64(len(p.Content))
+	}
+	var size int64
+	for _, f := range p.Files {
+		size += int64(len(f.Content))
+	}
+	return size
+}
+
+// AllFiles returns the files of the paste. A single-file paste is one file
+// named after its ID and language, such as "aBc123.go".
+func (p *Paste) AllFiles() []PasteFile {
+	if p.IsMultiFile() {
+		return p.Files
+	}
+	name := p.ID + ".txt"
+	if exts := enry.GetLanguageExtensions(p.Language); len(exts) > 0 {
+		name = p.ID + exts[0]
+	}
+	return []PasteFile{{Name: name, Content: p.Content, Language: p.Language}}
+}
+
+// validateFileName keeps file names usable in URLs and archives
+func validateFileName(name string) error {
+	switch {
+	case name == "" || name == "." || name == "..":
+		return fmt.Errorf("%w: invalid file name %q", ErrInvalidInput, name)
+	case len(name) > 255:
+		return fmt.Errorf("%w: file name %q is longer than 255 bytes", ErrInvalidInput, name[:32]+"...")
+	case strings.ContainsAny(name, "/\\\x00"):
+		return fmt.Errorf("%w: file name %q must not contain slashes", ErrInvalidInput, name)
+	}
+	return nil
+}
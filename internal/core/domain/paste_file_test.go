@@ -0,0 +1,57 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMultiFilePaste(t *testing.T) {
+	paste, err := domain.NewMultiFilePaste([]domain.PasteFile{
+		{Name: "main.go", Content: "package main\n\nfunc main() {}\n"},
+		{Name: "Makefile", Content: "all:\n\tgo build\n"},
+		{Name: "notes", Content: "plain words", Language: "Markdown"},
+	}, "demo", time.Hour, "alice")
+	require.NoError(t, err)
+
+	assert.True(t, paste.IsMultiFile())
+	assert.Equal(t, "Go", paste.Files[0].Language, "detected from the file name")
+	assert.Equal(t, "Makefile", paste.Files[1].Language)
+	assert.Equal(t, "Markdown", paste.Files[2].Language, "given languages are kept")
+	assert.Equal(t, paste.Files[0].Content, paste.Content, "the first file is the paste's content")
+	assert.Equal(t, "Go", paste.Language)
+	assert.Equal(t, int64(29+15+11), paste.Size())
+	assert.Equal(t, paste.Files, paste.AllFiles())
+
+	file, ok := paste.File("Makefile")
+	assert.True(t, ok)
+	assert.Equal(t, "all:\n\tgo build\n", file.Content)
+	_, ok = paste.File("missing")
+	assert.False(t, ok)
+
+	for name, files := range map[string][]domain.PasteFile{
+		"no files":       nil,
+		"duplicate name": {{Name: "a", Content: "1"}, {Name: "a", Content: "2"}},
+		"slash in name":  {{Name: "dir/a", Content: "1"}},
+		"dot dot":        {{Name: "..", Content: "1"}},
+		"empty file":     {{Name: "a", Content: ""}},
+		"long name":      {{Name: strings.Repeat("x", 256), Content: "1"}},
+	} {
+		_, err := domain.NewMultiFilePaste(files, "", time.Hour, "")
+		assert.ErrorIs(t, err, domain.ErrInvalidInput, name)
+	}
+}
+
+func TestSingleFilePasteFiles(t *testing.T) {
+	paste := domain.NewPaste("print('hi')\n", "Python", "", time.Hour, "")
+	assert.False(t, paste.IsMultiFile())
+
+	files := paste.AllFiles()
+	require.Len(t, files, 1)
+	assert.Equal(t, paste.ID+".py", files[0].Name)
+	assert.Equal(t, paste.Content, files[0].Content)
+}
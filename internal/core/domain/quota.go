@@ -0,0 +1,49 @@
+package domain
+
+import "fmt"
+
+// Usage is the content stored by a user or by everyone
+type Usage struct {
+	Items int64
+	Bytes int64
+}
+
+// Quota bounds a Usage. A zero maximum means unlimited.
+type Quota struct {
+	MaxItems int64
+	MaxBytes int64
+}
+
+// QuotaPolicy holds the quota of each user and the quota of the whole server
+type QuotaPolicy struct {
+	User   Quota
+	Global Quota
+}
+
+// exceededBy reports why storing more on top of usage would break the
+// quota, or "" if it fits
+func (q Quota) exceededBy(usage, more Usage) string {
+	if q.MaxItems > 0 && more.Items > 0 && usage.Items+more.Items > q.MaxItems {
+		return fmt.Sprintf("%d of %d items used", usage.Items, q.MaxItems)
+	}
+	if q.MaxBytes > 0 && more.Bytes > 0 && usage.Bytes+more.Bytes > q.MaxBytes {
+		return fmt.Sprintf("%d more bytes do not fit, %d of %d bytes used", more.Bytes, usage.Bytes, q.MaxBytes)
+	}
+	return ""
+}
+
+// CheckUser returns ErrQuotaExceeded if the user cannot store more
+func (p QuotaPolicy) CheckUser(usage, more Usage) error {
+	if reason := p.User.exceededBy(usage, more); reason != "" {
+		return fmt.Errorf("%w: %s", ErrQuotaExceeded, reason)
+	}
+	return nil
+}
+
+// CheckGlobal returns ErrStorageFull if the server cannot store more
+func (p QuotaPolicy) CheckGlobal(usage, more Usage) error {
+	if reason := p.Global.exceededBy(usage, more); reason != "" {
+		return fmt.Errorf("%w: %s", ErrStorageFull, reason)
+	}
+	return nil
+}
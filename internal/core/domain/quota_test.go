@@ -0,0 +1,30 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaPolicy(t *testing.T) {
+	policy := domain.QuotaPolicy{
+		User:   domain.Quota{MaxItems: 10, MaxBytes: 1000},
+		Global: domain.Quota{MaxBytes: 5000},
+	}
+	item := func(size int64) domain.Usage { return domain.Usage{Items: 1, Bytes: size} }
+
+	assert.NoError(t, policy.CheckUser(domain.Usage{Items: 9, Bytes: 900}, item(100)), "exactly full is fine")
+	assert.ErrorIs(t, policy.CheckUser(domain.Usage{Items: 9, Bytes: 900}, item(101)), domain.ErrQuotaExceeded)
+	assert.ErrorIs(t, policy.CheckUser(domain.Usage{Items: 10}, item(1)), domain.ErrQuotaExceeded)
+
+	// Growing existing content, e.g. editing a paste, adds no item
+	assert.NoError(t, policy.CheckUser(domain.Usage{Items: 10, Bytes: 900}, domain.Usage{Bytes: 100}))
+	assert.ErrorIs(t, policy.CheckUser(domain.Usage{Items: 10, Bytes: 900}, domain.Usage{Bytes: 101}), domain.ErrQuotaExceeded)
+
+	assert.NoError(t, policy.CheckGlobal(domain.Usage{Items: 1_000_000, Bytes: 4000}, item(1000)), "no global item limit")
+	assert.ErrorIs(t, policy.CheckGlobal(domain.Usage{Bytes: 4000}, item(1001)), domain.ErrStorageFull)
+
+	var unlimited domain.QuotaPolicy
+	assert.NoError(t, unlimited.CheckUser(domain.Usage{Items: 1 << 40, Bytes: 1 << 60}, item(1<<30)))
+}
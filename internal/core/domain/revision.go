@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Revision is an immutable version of a paste. Revisions are numbered from 1
+// and the paste itself always holds its latest one.
+type Revision struct {
+	PasteID   string
+	Number    int
+	Content   string // not loaded when revisions are listed
+	Language  string
+	Title     string
+	Size      int64
+	CreatedAt time.Time
+}
+
+// PasteEdit changes a paste. Nil fields are left as they are.
+type PasteEdit struct {
+	Content  *string
+	Language *string
+	Title    *string
+	// Revision is the revision the edit was made from, if known. The edit
+	// conflicts if the paste has moved on since.
+	Revision *int
+}
+
+// Edit applies the edit to the paste and returns the revision it creates, or
+// nil if the edit changes nothing
+func (p *Paste) Edit(edit PasteEdit) (*Revision, error) {
+	if edit.Revision != nil && *edit.Revision != p.Revision {
+		return nil, fmt.Errorf("%w: the paste is at revision %d, not %d", ErrConflict, p.Revision, *edit.Revision)
+	}
+	content, language, title := p.Content, p.Language, p.Title
+	if edit.Content != nil {
+		if *edit.Content == "" {
+			return nil, fmt.Errorf("%w: content cannot be empty", ErrInvalidInput)
+		}
+		content = *edit.Content
+	}
+	if edit.Language != nil {
+		// Unlike on creation, an edit keeps the language unless told otherwise
+		language = *edit.Language
+		if language == "" {
+			language = detectLanguage(content)
+		}
+	}
+	if edit.Title != nil {
+		title = *edit.Title
+	}
+	if content == p.Content && language == p.Language && title == p.Title {
+		return nil, nil
+	}
+
+	p.Content, p.Language, p.Title = content, language, title
+	if p.IsMultiFile() {
+		// Revisions only track the first file of multi-file pastes
+		p.Files = append([]PasteFile(nil), p.Files...)
+		p.Files[0].Content, p.Files[0].Language = content, language
+	}
+	p.Revision++
+	p.UpdatedAt = time.Now()
+	return p.LatestRevision(), nil
+}
+
+// LatestRevision returns the revision the paste currently holds
+func (p *Paste) LatestRevision() *Revision {
+	createdAt := p.UpdatedAt
+	if createdAt.IsZero() {
+		createdAt = p.CreatedAt
+	}
+	return &Revision{
+		PasteID:   p.ID,
+		Number:    p.Revision,
+		Content:   p.Content,
+		Language:  p.Language,
+		Title:     p.Title,
+		Size:      int64(len(p.Content)),
+		CreatedAt: createdAt,
+	}
+}
+
+// Name identifies the revision in diffs, e.g. "aBc123@2"
+func (r *Revision) Name() string {
+	return fmt.Sprintf("%s@%d", r.PasteID, r.Number)
+}
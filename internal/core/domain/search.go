@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchResult is a paste matching a search, with an excerpt of its content.
+// The paste's Content is not loaded.
+type SearchResult struct {
+	Paste   *Paste
+	Rank    float64
+	Snippet string
+	// Highlights are the byte ranges of Snippet that matched the query
+	Highlights []Span
+}
+
+// Span is the byte range [Start, End) of a string
+type Span struct {
+	Start int
+	End   int
+}
+
+// SearchTerms splits a query into lowercase terms. Quoted phrases stay one
+// term, so `"proxy_pass" nginx` yields ["proxy_pass", "nginx"].
+func SearchTerms(query string) []string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if i%2 == 1 {
+			terms = append(terms, part)
+			continue
+		}
+		terms = append(terms, strings.FieldsFunc(part, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' && r != '-'
+		})...)
+	}
+	return terms
+}
+
+// snippetRadius is how much context MatchPaste keeps around the first match
+const snippetRadius = 80
+
+// MatchPaste searches a paste and all its files for every term,
+// case-insensitively, and ranks title matches above content matches. It backs
+// search on repositories without a full-text index of their own.
+func MatchPaste(p *Paste, terms []string) (SearchResult, bool) {
+	if len(terms) == 0 {
+		return SearchResult{}, false
+	}
+	// The first file of multi-file pastes is their content
+	text := p.Content
+	for _, f := range p.Files[min(1, len(p.Files)):] {
+		text += "\n" + f.Content
+	}
+	title, content := strings.ToLower(p.Title), strings.ToLower(text)
+
+	var rank float64
+	for _, term := range terms {
+		inTitle, inContent := strings.Count(title, term), strings.Count(content, term)
+		if inTitle+inContent == 0 {
+			return SearchResult{}, false
+		}
+		rank += float64(4*inTitle + inContent)
+	}
+
+	// Excerpt the content around the first match of any term
+	first := len(content)
+	for _, term := range terms {
+		if i := strings.Index(content, term); i >= 0 && i < first {
+			first = i
+		}
+	}
+	if first >= len(text) {
+		first = 0
+	}
+	start, end := max(first-snippetRadius, 0), min(first+snippetRadius, len(text))
+	for start > 0 && !isRuneStart(text, start) {
+		start--
+	}
+	for end < len(text) && !isRuneStart(text, end) {
+		end++
+	}
+	snippet := text[start:end]
+
+	// Lowercasing can change byte lengths outside ASCII, so only highlight
+	// when the offsets still line up
+	var highlights []Span
+	lower := strings.ToLower(snippet)
+	if len(lower) == len(snippet) {
+		for _, term := range terms {
+			for i := 0; ; {
+				j := strings.Index(lower[i:], term)
+				if j < 0 {
+					break
+				}
+				highlights = append(highlights, Span{Start: i + j, End: i + j + len(term)})
+				i += j + len(term)
+			}
+		}
+		highlights = mergeSpans(highlights)
+	}
+
+	return SearchResult{Paste: p, Rank: rank, Snippet: snippet, Highlights: highlights}, true
+}
+
+// mergeSpans sorts spans and joins the overlapping ones
+func mergeSpans(spans []Span) []Span {
+	sort.Slice(spans, func(a, b int) bool { return spans[a].Start < spans[b].Start })
+	var merged []Span
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, s.End)
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+func isRuneStart(s string, i int) bool {
+	return s[i]&0xC0 != 0x80
+}
@@ -0,0 +1,43 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchTerms(t *testing.T) {
+	assert.Equal(t, []string{"proxy_pass", "nginx", "upstream"}, domain.SearchTerms(`"proxy_pass" Nginx, upstream`))
+	assert.Equal(t, []string{"server name"}, domain.SearchTerms(`"Server Name"`))
+	assert.Empty(t, domain.SearchTerms("  ,; "))
+}
+
+func TestMatchPaste(t *testing.T) {
+	paste := domain.NewPaste("server {\n    listen 80;\n    proxy_pass http://app;\n}\n", "Nginx", "nginx config", time.Hour, "")
+
+	result, ok := domain.MatchPaste(paste, domain.SearchTerms("nginx proxy_pass"))
+	require.True(t, ok)
+	assert.Greater(t, result.Rank, 0.0)
+	require.Len(t, result.Highlights, 1)
+	h := result.Highlights[0]
+	assert.Equal(t, "proxy_pass", result.Snippet[h.Start:h.End])
+
+	_, ok = domain.MatchPaste(paste, domain.SearchTerms("nginx apache"))
+	assert.False(t, ok, "every term must match")
+
+	titled, _ := domain.MatchPaste(domain.NewPaste("x", "Text", "proxy notes", time.Hour, ""), []string{"proxy"})
+	untitled, _ := domain.MatchPaste(domain.NewPaste("proxy", "Text", "", time.Hour, ""), []string{"proxy"})
+	assert.Greater(t, titled.Rank, untitled.Rank, "title matches rank higher")
+
+	multi, err := domain.NewMultiFilePaste([]domain.PasteFile{
+		{Name: "main.go", Content: "package main"},
+		{Name: "nginx.conf", Content: "proxy_pass http://app;"},
+	}, "", time.Hour, "")
+	require.NoError(t, err)
+	result, ok = domain.MatchPaste(multi, domain.SearchTerms("main proxy_pass"))
+	require.True(t, ok, "terms may match different files")
+	assert.Contains(t, result.Snippet, "proxy_pass")
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/duration"
+)
+
+// NoExpiry is the TTL of permanent content. Such content has a zero ExpiresAt.
+const NoExpiry time.Duration = math.MaxInt64
+
+// PermanentTTL is the TTL keyword requesting content that never expires.
+const PermanentTTL = "never"
+
+// TTLPolicy decides how long new content lives.
+type TTLPolicy struct {
+	Default time.Duration
+	Min     time.Duration
+	Max     time.Duration
+	// Presets are the TTLs offered to users, e.g. in the web UI
+	Presets []string
+	// AllowPermanent lets authenticated users create content that never expires
+	AllowPermanent bool
+}
+
+// Resolve turns a requested TTL into a lifetime. An empty request gets the
+// default, "never" gets NoExpiry, and anything else must parse and fall
+// within the policy bounds.
+func (p TTLPolicy) Resolve(requested string, authenticated bool) (time.Duration, error) {
+	requested = strings.TrimSpace(requested)
+	if requested == "" {
+		return p.Default, nil
+	}
+
+	if strings.EqualFold(requested, PermanentTTL) {
+		if !p.AllowPermanent {
+			return 0, fmt.Errorf("%w: permanent content is disabled on this server", ErrInvalidInput)
+		}
+		if !authenticated {
+			return 0, fmt.Errorf("%w: permanent content requires authentication", ErrInvalidInput)
+		}
+		return NoExpiry, nil
+	}
+
+	ttl, err := duration.Parse(requested)
+	if err != nil {
+		return 0, fmt.Errorf("%w: ttl: %v", ErrInvalidInput, err)
+	}
+	if ttl < p.Min {
+		return 0, fmt.Errorf("%w: ttl %s is below the minimum of %s", ErrInvalidInput, requested, duration.Format(p.Min))
+	}
+	if ttl > p.Max {
+		return 0, fmt.Errorf("%w: ttl %s is above the maximum of %s", ErrInvalidInput, requested, duration.Format(p.Max))
+	}
+	return ttl, nil
+}
+
+// expiresAt is the expiry of content created at now with the given TTL
+func expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl == NoExpiry {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
@@ -0,0 +1,60 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLPolicyResolve(t *testing.T) {
+	policy := domain.TTLPolicy{
+		Default:        24 * time.Hour,
+		Min:            time.Minute,
+		Max:            30 * 24 * time.Hour,
+		AllowPermanent: true,
+	}
+
+	for requested, want := range map[string]time.Duration{
+		"":      24 * time.Hour,
+		"1h":    time.Hour,
+		"7d":    7 * 24 * time.Hour,
+		"1m":    time.Minute,
+		"30d":   30 * 24 * time.Hour,
+		"never": domain.NoExpiry,
+		"NEVER": domain.NoExpiry,
+	} {
+		got, err := policy.Resolve(requested, true)
+		require.NoError(t, err, requested)
+		assert.Equal(t, want, got, requested)
+	}
+
+	for _, requested := range []string{"100000h", "31d", "30s", "-1h", "tomorrow"} {
+		_, err := policy.Resolve(requested, true)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput, requested)
+	}
+
+	t.Run("permanent needs authentication", func(t *testing.T) {
+		_, err := policy.Resolve("never", false)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("permanent can be disabled", func(t *testing.T) {
+		policy := policy
+		policy.AllowPermanent = false
+		_, err := policy.Resolve("never", true)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
+
+func TestPermanentContentNeverExpires(t *testing.T) {
+	paste := domain.NewPaste("echo hi", "Shell", "", domain.NoExpiry, "")
+	assert.True(t, paste.ExpiresAt.IsZero())
+	assert.False(t, paste.IsExpired())
+	assert.True(t, paste.CanView())
+
+	file := domain.NewFile("a.txt", 1, "text/plain", domain.NoExpiry, "")
+	assert.False(t, file.IsExpired())
+}
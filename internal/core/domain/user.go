@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// validUserName keeps names usable as CLI arguments and in URLs
+var validUserName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+type User struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+}
+
+func NewUser(name string) (*User, error) {
+	if !validUserName.MatchString(name) {
+		return nil, fmt.Errorf("%w: user name %q must be 1-64 lowercase letters, digits, '.', '_' or '-'", ErrInvalidInput, name)
+	}
+
+	return &User{
+		ID:        generateID(),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Principal is the authenticated identity behind a request
+type Principal struct {
+	UserID   string
+	UserName string
+	KeyID    string
+	Scopes   []Scope
+}
+
+// Allows reports whether the principal's key grants scope. A nil principal,
+// i.e. an anonymous request, is granted nothing.
+func (p *Principal) Allows(scope Scope) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s.includes(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// owns reports whether the principal may manage content owned by ownerID
+func (p *Principal) owns(ownerID string) bool {
+	if p.Allows(ScopeAdmin) {
+		return true
+	}
+	return ownerID != "" && p.Allows(ScopeWrite) && p.UserID == ownerID
+}
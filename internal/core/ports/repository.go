@@ -7,15 +7,71 @@ import (
 )
 
 type FileRepository interface {
-    Store(ctx context.Context, file *domain.File) error
-    FindByID(ctx context.Context, id string) (*domain.File, error)
-    IncrementDownloads(ctx context.Context, id string) error
-    DeleteExpired(ctx context.Context) error
+	Store(ctx context.Context, file *domain.File) error
+	FindByID(ctx context.Context, id string) (*domain.File, error)
+	List(ctx context.Context, filter domain.ListFilter, page domain.Page) ([]*domain.File, error)
+	IncrementDownloads(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
+	// DeleteExpired removes expired files and returns them, so their
+	// objects can be removed from storage too
+	DeleteExpired(ctx context.Context) ([]*domain.File, error)
+}
+
+type BundleRepository interface {
+	// StoreBundle saves a bundle along with its files
+	StoreBundle(ctx context.Context, bundle *domain.Bundle) error
+	// FindBundleByID returns a bundle with its files, in upload order
+	FindBundleByID(ctx context.Context, id string) (*domain.Bundle, error)
+	IncrementBundleDownloads(ctx context.Context, id string) error
+	// DeleteBundle removes a bundle and its files and returns the files, so
+	// their objects can be removed from storage too
+	DeleteBundle(ctx context.Context, id string) ([]*domain.File, error)
+	// DeleteExpiredBundles removes expired bundles. Their files expire with
+	// them and are removed by FileRepository.DeleteExpired.
+	DeleteExpiredBundles(ctx context.Context) error
 }
 
 type PasteRepository interface {
-    Store(ctx context.Context, paste *domain.Paste) error
-    FindByID(ctx context.Context, id string) (*domain.Paste, error)
-    IncrementViews(ctx context.Context, id string) error
-    DeleteExpired(ctx context.Context) error
-}
\ No newline at end of file
+	Store(ctx context.Context, paste *domain.Paste) error
+	FindByID(ctx context.Context, id string) (*domain.Paste, error)
+	List(ctx context.Context, filter domain.ListFilter, page domain.Page) ([]*domain.Paste, error)
+	IncrementViews(ctx context.Context, id string) error
+	// Update saves an edited paste along with its new latest revision. It
+	// fails with domain.ErrConflict if the paste was edited since it was read.
+	Update(ctx context.Context, paste *domain.Paste) error
+	// ListRevisions lists the revisions of a paste, oldest first, without
+	// their content
+	ListRevisions(ctx context.Context, id string) ([]*domain.Revision, error)
+	FindRevision(ctx context.Context, id string, number int) (*domain.Revision, error)
+	Delete(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context) error
+}
+
+// PasteSearcher is implemented by paste repositories with a full-text index
+// of their own. PasteService falls back to scanning pastes otherwise.
+type PasteSearcher interface {
+	// Search returns the owner's live pastes matching query, best match first
+	Search(ctx context.Context, ownerID, query string, limit int) ([]domain.SearchResult, error)
+}
+
+// UsageRepository measures stored content. Usage is computed from the
+// content itself rather than kept in counters, so it stays right whatever
+// removes content.
+type UsageRepository interface {
+	UsageByOwner(ctx context.Context, ownerID string) (domain.Usage, error)
+	TotalUsage(ctx context.Context) (domain.Usage, error)
+}
+
+type UserRepository interface {
+	StoreUser(ctx context.Context, user *domain.User) error
+	FindUserByID(ctx context.Context, id string) (*domain.User, error)
+	FindUserByName(ctx context.Context, name string) (*domain.User, error)
+	ListUsers(ctx context.Context) ([]*domain.User, error)
+
+	StoreAPIKey(ctx context.Context, key *domain.APIKey) error
+	FindAPIKeyByID(ctx context.Context, id string) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]*domain.APIKey, error)
+	// TouchAPIKey records that the key was just used
+	TouchAPIKey(ctx context.Context, id string) error
+	RevokeAPIKey(ctx context.Context, id string) error
+}
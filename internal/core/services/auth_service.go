@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/ports"
+)
+
+type AuthService struct {
+	repo ports.UserRepository
+	log  *slog.Logger
+}
+
+func NewAuthService(repo ports.UserRepository, log *slog.Logger) *AuthService {
+	return &AuthService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (s *AuthService) CreateUser(ctx context.Context, name string) (*domain.User, error) {
+	user, err := domain.NewUser(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.FindUserByName(ctx, name); err == nil {
+		return nil, fmt.Errorf("%w: user %q already exists", domain.ErrInvalidInput, name)
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	if err := s.repo.StoreUser(ctx, user); err != nil {
+		s.log.Error("Failed to store user", "user", name, "error", err)
+		return nil, err
+	}
+	s.log.Info("User created", "user_id", user.ID, "user", user.Name)
+	return user, nil
+}
+
+func (s *AuthService) GetUser(ctx context.Context, name string) (*domain.User, error) {
+	return s.repo.FindUserByName(ctx, name)
+}
+
+func (s *AuthService) ListUsers(ctx context.Context) ([]*domain.User, error) {
+	return s.repo.ListUsers(ctx)
+}
+
+// CreateAPIKey issues a key for the named user. The plaintext key is returned
+// once and cannot be recovered afterwards.
+func (s *AuthService) CreateAPIKey(ctx context.Context, userName, keyName string, scopes []domain.Scope) (*domain.APIKey, string, error) {
+	user, err := s.repo.FindUserByName(ctx, userName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, plaintext := domain.NewAPIKey(user.ID, keyName, scopes)
+	if err := s.repo.StoreAPIKey(ctx, key); err != nil {
+		s.log.Error("Failed to store API key", "user_id", user.ID, "error", err)
+		return nil, "", err
+	}
+	s.log.Info("API key created", "user_id", user.ID, "key_id", key.ID, "scopes", key.Scopes)
+	return key, plaintext, nil
+}
+
+func (s *AuthService) ListAPIKeys(ctx context.Context, userName string) ([]*domain.APIKey, error) {
+	user, err := s.repo.FindUserByName(ctx, userName)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListAPIKeys(ctx, user.ID)
+}
+
+func (s *AuthService) RevokeAPIKey(ctx context.Context, keyID string) error {
+	if err := s.repo.RevokeAPIKey(ctx, keyID); err != nil {
+		return err
+	}
+	s.log.Info("API key revoked", "key_id", keyID)
+	return nil
+}
+
+// Authenticate resolves a plaintext API key to the principal it belongs to.
+// Unknown, malformed and revoked keys all yield ErrUnauthorized.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	id, secret, ok := domain.SplitAPIKey(token)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed API key", domain.ErrUnauthorized)
+	}
+	logger := s.log.With("key_id", id)
+
+	key, err := s.repo.FindAPIKeyByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			logger.Warn("Unknown API key")
+			return nil, fmt.Errorf("%w: invalid API key", domain.ErrUnauthorized)
+		}
+		return nil, err
+	}
+	if !key.Matches(secret) || key.IsRevoked() {
+		logger.Warn("Rejected API key", "revoked", key.IsRevoked())
+		return nil, fmt.Errorf("%w: invalid API key", domain.ErrUnauthorized)
+	}
+
+	user, err := s.repo.FindUserByID(ctx, key.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		logger.Warn("Failed to record API key use", "error", err)
+	}
+
+	return &domain.Principal{
+		UserID:   user.ID,
+		UserName: user.Name,
+		KeyID:    key.ID,
+		Scopes:   key.Scopes,
+	}, nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+)
+
+// FileUpload is a file to upload, read from Reader
+type FileUpload struct {
+	Reader      io.Reader
+	Name        string
+	Size        int64
+	ContentType string
+}
+
+// UploadBundle uploads files under a single bundle sharing their TTL, owner
+// and download limit. maxDownloads of zero means unlimited.
+func (s *FileService) UploadBundle(ctx context.Context, title string, uploads []FileUpload, ttl time.Duration, maxDownloads int, ownerID string) (*domain.Bundle, error) {
+	if len(uploads) == 0 {
+		return nil, fmt.Errorf("%w: a bundle needs at least one file", domain.ErrInvalidInput)
+	}
+	if len(title) > 255 {
+		return nil, fmt.Errorf("%w: titles are limited to 255 bytes", domain.ErrInvalidInput)
+	}
+	bundle := domain.NewBundle(title, ttl, maxDownloads, ownerID)
+	for _, u := range uploads {
+		if _, err := bundle.Add(u.Name, u.Size, u.ContentType); err != nil {
+			return nil, err
+		}
+	}
+	logger := s.log.With("bundle_id", bundle.ID, "files", len(bundle.Files))
+
+	// Refuse before any byte reaches storage
+	if err := s.quota.CheckBatch(ctx, ownerID, len(bundle.Files), bundle.Size()); err != nil {
+		logger.Warn("Bundle upload refused by quota", "error", err)
+		return nil, err
+	}
+
+	for i, file := range bundle.Files {
+		u := uploads[i]
+		if err := s.storage.Upload(ctx, file.StorageKey, u.Reader, u.Size, u.ContentType); err != nil {
+			logger.Error("Failed to upload bundle file to storage", "file_id", file.ID, "error", err)
+			s.deleteObjects(ctx, bundle.Files[:i])
+			return nil, err
+		}
+	}
+	logger.Debug("Bundle files uploaded to storage")
+
+	if err := s.bundles.StoreBundle(ctx, bundle); err != nil {
+		logger.Error("Failed to store bundle metadata, cleaning up storage", "error", err)
+		s.deleteObjects(ctx, bundle.Files)
+		return nil, err
+	}
+	logger.Info("Bundle uploaded successfully", "size", bundle.Size())
+	return bundle, nil
+}
+
+// GetBundle returns a bundle and its files without counting a download
+func (s *FileService) GetBundle(ctx context.Context, id string) (*domain.Bundle, error) {
+	s.log.Debug("Fetching bundle", "bundle_id", id)
+	bundle, err := s.bundles.FindBundleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if bundle.IsExpired() {
+		return nil, domain.ErrExpired
+	}
+	return bundle, nil
+}
+
+// OpenBundle returns a bundle about to be downloaded as a whole, counting
+// the download. Its files are then read with ReadBundle.
+func (s *FileService) OpenBundle(ctx context.Context, id string) (*domain.Bundle, error) {
+	logger := s.log.With("bundle_id", id)
+	bundle, err := s.bundles.FindBundleByID(ctx, id)
+	if err != nil {
+		logger.Warn("Bundle not found in repository", "error", err)
+		return nil, err
+	}
+	if err := s.countBundleDownload(ctx, bundle); err != nil {
+		logger.Warn("Refused bundle download", "error", err)
+		return nil, err
+	}
+	logger.Info("Bundle download started", "files", len(bundle.Files), "size", bundle.Size())
+	return bundle, nil
+}
+
+// ReadBundle calls fn with each file of the bundle, in order, and its content
+// streamed from storage. Only one file is open at a time.
+func (s *FileService) ReadBundle(ctx context.Context, bundle *domain.Bundle, fn func(file *domain.File, content io.Reader) error) error {
+	for _, file := range bundle.Files {
+		reader, err := s.storage.Download(ctx, file.StorageKey)
+		if err != nil {
+			s.log.Error("Failed to download bundle file from storage", "bundle_id", bundle.ID, "file_id", file.ID, "error", err)
+			return err
+		}
+		err = fn(file, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteBundle removes a bundle and its files on behalf of its owner, an
+// admin or the holder of its manage token
+func (s *FileService) DeleteBundle(ctx context.Context, id string, principal *domain.Principal, token string) error {
+	logger := s.log.With("bundle_id", id)
+	bundle, err := s.bundles.FindBundleByID(ctx, id)
+	if err != nil {
+		logger.Warn("Failed to find bundle for deletion", "error", err)
+		return err
+	}
+
+	if !bundle.CanManage(principal, token) {
+		logger.Warn("Refused to delete bundle not managed by the caller")
+		return domain.ErrForbidden
+	}
+
+	files, err := s.bundles.DeleteBundle(ctx, id)
+	if err != nil {
+		logger.Error("Failed to delete bundle metadata", "error", err)
+		return err
+	}
+	s.deleteObjects(ctx, files)
+	logger.Info("Bundle deleted successfully", "files", len(files))
+	return nil
+}
+
+// countBundleDownload counts a download toward the bundle's limit, or
+// refuses it
+func (s *FileService) countBundleDownload(ctx context.Context, bundle *domain.Bundle) error {
+	if !bundle.CanDownload() {
+		if bundle.IsExpired() {
+			return domain.ErrExpired
+		}
+		return domain.ErrLimitExceeded
+	}
+	return s.bundles.IncrementBundleDownloads(ctx, bundle.ID)
+}
+
+// countInBundle counts the download of a file toward the limit of the
+// bundle it belongs to, if any
+func (s *FileService) countInBundle(ctx context.Context, file *domain.File) error {
+	if file.BundleID == "" {
+		return nil
+	}
+	bundle, err := s.bundles.FindBundleByID(ctx, file.BundleID)
+	if errors.Is(err, domain.ErrNotFound) {
+		// The bundle is gone, the file stands on its own
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.countBundleDownload(ctx, bundle)
+}
+
+// deleteObjects removes the objects of files from storage, logging failures:
+// a stray object is harmless
+func (s *FileService) deleteObjects(ctx context.Context, files []*domain.File) {
+	for _, file := range files {
+		if err := s.storage.Delete(ctx, file.StorageKey); err != nil {
+			s.log.Error("Failed to delete file from storage", "file_id", file.ID, "storage_key", file.StorageKey, "error", err)
+		}
+	}
+}
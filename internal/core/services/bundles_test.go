@@ -0,0 +1,229 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/ports"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStorage is an in-memory ports.Storage
+type memoryStorage map[string][]byte
+
+var _ ports.Storage = memoryStorage(nil)
+
+func (m memoryStorage) Upload(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	data, err := io.ReadAll(r)
+	m[key] = data
+	return err
+}
+
+func (m memoryStorage) Download(_ context.Context, key string) (io.ReadCloser, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m memoryStorage) Delete(_ context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memoryStorage) GetURL(_ context.Context, key string) (string, error) {
+	return "memory://" + key, nil
+}
+
+// memoryFiles is an in-memory ports.FileRepository and ports.BundleRepository
+type memoryFiles struct {
+	files   map[string]*domain.File
+	order   []string // file IDs in upload order
+	bundles map[string]*domain.Bundle
+}
+
+var (
+	_ ports.FileRepository   = (*memoryFiles)(nil)
+	_ ports.BundleRepository = (*memoryFiles)(nil)
+)
+
+func newMemoryFiles() *memoryFiles {
+	return &memoryFiles{files: map[string]*domain.File{}, bundles: map[string]*domain.Bundle{}}
+}
+
+func (m *memoryFiles) Store(_ context.Context, f *domain.File) error {
+	stored := *f
+	m.files[f.ID] = &stored
+	m.order = append(m.order, f.ID)
+	return nil
+}
+
+func (m *memoryFiles) FindByID(_ context.Context, id string) (*domain.File, error) {
+	f, ok := m.files[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	found := *f
+	return &found, nil
+}
+
+func (m *memoryFiles) List(context.Context, domain.ListFilter, domain.Page) ([]*domain.File, error) {
+	return nil, nil
+}
+
+func (m *memoryFiles) IncrementDownloads(_ context.Context, id string) error {
+	m.files[id].Downloads++
+	return nil
+}
+
+func (m *memoryFiles) Delete(_ context.Context, id string) error {
+	delete(m.files, id)
+	return nil
+}
+
+func (m *memoryFiles) DeleteExpired(context.Context) ([]*domain.File, error) { return nil, nil }
+
+func (m *memoryFiles) StoreBundle(ctx context.Context, b *domain.Bundle) error {
+	stored := *b
+	stored.Files = nil
+	m.bundles[b.ID] = &stored
+	for _, f := range b.Files {
+		if err := m.Store(ctx, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryFiles) FindBundleByID(_ context.Context, id string) (*domain.Bundle, error) {
+	b, ok := m.bundles[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	found := *b
+	for _, fid := range m.order {
+		if f, ok := m.files[fid]; ok && f.BundleID == id {
+			found.Files = append(found.Files, f)
+		}
+	}
+	return &found, nil
+}
+
+func (m *memoryFiles) IncrementBundleDownloads(_ context.Context, id string) error {
+	m.bundles[id].Downloads++
+	return nil
+}
+
+func (m *memoryFiles) DeleteBundle(_ context.Context, id string) ([]*domain.File, error) {
+	var files []*domain.File
+	for fid, f := range m.files {
+		if f.BundleID == id {
+			files = append(files, f)
+			delete(m.files, fid)
+		}
+	}
+	delete(m.bundles, id)
+	return files, nil
+}
+
+func (m *memoryFiles) DeleteExpiredBundles(context.Context) error { return nil }
+
+func TestDownloadLimit(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	quota := services.NewQuotaService(fixedUsage{}, domain.QuotaPolicy{}, log)
+	repo, storage := newMemoryFiles(), memoryStorage{}
+	files := services.NewFileService(repo, repo, storage, quota, log)
+
+	file, err := files.Upload(ctx, strings.NewReader("0123456789"), "report.bin", 10, "application/octet-stream", time.Hour, "")
+	require.NoError(t, err)
+	other, err := files.Upload(ctx, strings.NewReader("other"), "other.bin", 5, "application/octet-stream", time.Hour, "")
+	require.NoError(t, err)
+	repo.files[file.ID].MaxDownloads = 2
+
+	// Transfers without a valid resume token count, whatever their range
+	reader, _, token, err := files.Download(ctx, file.ID, "")
+	require.NoError(t, err)
+	reader.Close()
+	require.NotEmpty(t, token)
+	reader, _, otherToken, err := files.Download(ctx, other.ID, "")
+	require.NoError(t, err)
+	reader.Close()
+	reader, _, _, err = files.Download(ctx, file.ID, "99999999999.forged")
+	require.NoError(t, err)
+	reader.Close()
+	for _, forged := range []string{"99999999999.forged", otherToken, ""} {
+		_, _, _, err = files.Download(ctx, file.ID, forged)
+		assert.ErrorIs(t, err, domain.ErrLimitExceeded, "token %q", forged)
+	}
+	assert.Equal(t, 2, repo.files[file.ID].Downloads, "repeated ranged downloads stop at the limit")
+
+	// The token of a counted transfer resumes it without counting again
+	reader, _, resumed, err := files.Download(ctx, file.ID, token)
+	require.NoError(t, err)
+	reader.Close()
+	assert.Equal(t, token, resumed)
+	assert.Equal(t, 2, repo.files[file.ID].Downloads)
+}
+
+func TestUploadBundle(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	quota := services.NewQuotaService(fixedUsage{}, domain.QuotaPolicy{}, log)
+	repo, storage := newMemoryFiles(), memoryStorage{}
+	files := services.NewFileService(repo, repo, storage, quota, log)
+
+	bundle, err := files.UploadBundle(ctx, "logs", []services.FileUpload{
+		{Reader: strings.NewReader("first"), Name: "app.log", Size: 5, ContentType: "text/plain"},
+	}, time.Hour, 2, "alice")
+	require.NoError(t, err)
+	require.Len(t, bundle.Files, 1)
+	assert.Equal(t, bundle.ID, bundle.Files[0].BundleID)
+	assert.Equal(t, bundle.ExpiresAt, bundle.Files[0].ExpiresAt, "files share the bundle's TTL")
+	assert.NotEmpty(t, bundle.ManageToken)
+
+	// The archive download and the file download both count toward the limit
+	opened, err := files.OpenBundle(ctx, bundle.ID)
+	require.NoError(t, err)
+	var read []string
+	err = files.ReadBundle(ctx, opened, func(f *domain.File, content io.Reader) error {
+		data, err := io.ReadAll(content)
+		read = append(read, f.OriginalName+"="+string(data))
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.log=first"}, read)
+
+	reader, _, token, err := files.Download(ctx, bundle.Files[0].ID, "")
+	require.NoError(t, err)
+	reader.Close()
+
+	_, err = files.OpenBundle(ctx, bundle.ID)
+	assert.ErrorIs(t, err, domain.ErrLimitExceeded)
+	_, _, _, err = files.Download(ctx, bundle.Files[0].ID, "")
+	assert.ErrorIs(t, err, domain.ErrLimitExceeded)
+
+	// The last download allowed can still be resumed
+	reader, _, _, err = files.Download(ctx, bundle.Files[0].ID, token)
+	require.NoError(t, err)
+	reader.Close()
+
+	// The bundle's manage token deletes it along with its objects
+	assert.ErrorIs(t, files.DeleteBundle(ctx, bundle.ID, nil, "wrong"), domain.ErrForbidden)
+	require.NoError(t, files.DeleteBundle(ctx, bundle.ID, nil, bundle.ManageToken))
+	assert.Empty(t, storage)
+	_, err = files.GetInfo(ctx, bundle.Files[0].ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = files.UploadBundle(ctx, "", nil, time.Hour, 0, "")
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
@@ -2,10 +2,13 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"io"
 	"log/slog"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,22 +18,42 @@ import (
 
 type FileService struct {
 	repo    ports.FileRepository
+	bundles ports.BundleRepository
 	storage ports.Storage
+	quota   *QuotaService
 	log     *slog.Logger
+	// resumeKey signs resume tokens. It is drawn on start, so that tokens do
+	// not outlive the process: resumes then count like new downloads.
+	resumeKey []byte
 }
 
-func NewFileService(repo ports.FileRepository, storage ports.Storage, log *slog.Logger) *FileService {
+// resumeTokenTTL is how long a counted transfer can be resumed without
+// counting again
+const resumeTokenTTL = 24 * time.Hour
+
+func NewFileService(repo ports.FileRepository, bundles ports.BundleRepository, storage ports.Storage, quota *QuotaService, log *slog.Logger) *FileService {
+	key := make([]byte, 32)
+	rand.Read(key)
 	return &FileService{
-		repo:    repo,
-		storage: storage,
-		log:     log,
+		repo:      repo,
+		bundles:   bundles,
+		storage:   storage,
+		quota:     quota,
+		log:       log,
+		resumeKey: key,
 	}
 }
 
-func (s *FileService) Upload(ctx context.Context, reader io.Reader, filename string, size int64, contentType string, ttl time.Duration) (*domain.File, error) {
-	file := domain.NewFile(filename, size, contentType, ttl)
+func (s *FileService) Upload(ctx context.Context, reader io.Reader, filename string, size int64, contentType string, ttl time.Duration, ownerID string) (*domain.File, error) {
+	file := domain.NewFile(filename, size, contentType, ttl, ownerID)
 	logger := s.log.With("file_id", file.ID, "storage_key", file.StorageKey)
 
+	// Refuse before any byte reaches storage
+	if err := s.quota.Check(ctx, ownerID, size); err != nil {
+		logger.Warn("Upload refused by quota", "error", err)
+		return nil, err
+	}
+
 	// Upload to storage
 	if err := s.storage.Upload(ctx, file.StorageKey, reader, size, contentType); err != nil {
 		logger.Error("Failed to upload file to storage", "error", err)
@@ -52,12 +75,15 @@ func (s *FileService) Upload(ctx context.Context, reader io.Reader, filename str
 	return file, nil
 }
 
-func (s *FileService) Download(ctx context.Context, id string) (io.ReadCloser, *domain.File, error) {
+// Download opens a file for a transfer, which counts as a download unless
+// resume is the token of a counted transfer of the file being resumed. It
+// returns the token resuming this transfer.
+func (s *FileService) Download(ctx context.Context, id, resume string) (io.ReadCloser, *domain.File, string, error) {
 	logger := s.log.With("file_id", id)
 	file, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		logger.Warn("File not found in repository", "error", err)
-		return nil, nil, err
+		return nil, nil, "", err
 	}
 
 	logger.Debug("File found in repository",
@@ -76,33 +102,66 @@ func (s *FileService) Download(ctx context.Context, id string) (io.ReadCloser, *
 
 	logger.Debug("Attempting to download from storage", "storage_key", file.StorageKey)
 
-	if !file.CanDownload() {
-		if file.IsExpired() {
-			logger.Warn("Attempt to download expired file")
-			return nil, nil, domain.ErrExpired
-		}
+	// A resumed transfer was counted when it started, possibly as the last
+	// download allowed, so it only needs the file to be live
+	resumed := resume != "" && s.validResume(id, resume)
+	switch {
+	case file.IsExpired():
+		logger.Warn("Attempt to download expired file")
+		return nil, nil, "", domain.ErrExpired
+	case !resumed && !file.CanDownload():
 		logger.Warn("Attempt to download file over limit")
-		return nil, nil, domain.ErrLimitExceeded
+		return nil, nil, "", domain.ErrLimitExceeded
+	}
+
+	if !resumed {
+		// Files of a bundle share its download limit
+		if err := s.countInBundle(ctx, file); err != nil {
+			logger.Warn("Refused download of bundle file", "bundle_id", file.BundleID, "error", err)
+			return nil, nil, "", err
+		}
 	}
 
 	reader, err := s.storage.Download(ctx, file.StorageKey)
 	if err != nil {
 		logger.Error("Failed to download file from storage", "storage_key", file.StorageKey, "error", err)
-		return nil, nil, err
+		return nil, nil, "", err
 	}
 	logger.Debug("File downloaded from storage")
 
+	if resumed {
+		logger.Info("File download resumed")
+		return reader, file, resume, nil
+	}
+
 	// Increment download counter
 	if err := s.repo.IncrementDownloads(ctx, id); err != nil {
 		reader.Close()
 		logger.Error("Failed to increment file download count", "error", err)
-		return nil, nil, err
+		return nil, nil, "", err
 	}
 	logger.Info("File downloaded successfully")
 
-    file.OriginalName = appendTimestamp(file.OriginalName)
+	return reader, file, s.resumeToken(id, time.Now().Add(resumeTokenTTL)), nil
+}
+
+// resumeToken returns the token resuming transfers of a file until expires
+func (s *FileService) resumeToken(id string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, s.resumeKey)
+	mac.Write([]byte(id + "." + exp))
+	return exp + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
 
-	return reader, file, nil
+// validResume tells whether token was issued by resumeToken for the file and
+// is still valid
+func (s *FileService) validResume(id, token string) bool {
+	exp, _, _ := strings.Cut(token, ".")
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(s.resumeToken(id, time.Unix(unix, 0))))
 }
 
 func (s *FileService) GetInfo(ctx context.Context, id string) (*domain.File, error) {
@@ -110,39 +169,97 @@ func (s *FileService) GetInfo(ctx context.Context, id string) (*domain.File, err
 	return s.repo.FindByID(ctx, id)
 }
 
-func (s *FileService) Delete(ctx context.Context, id string) error {
+// Downloadable checks that a file exists and can still be downloaded,
+// without counting a download
+func (s *FileService) Downloadable(ctx context.Context, id string) error {
+	file, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !file.CanDownload() {
+		if file.IsExpired() {
+			return domain.ErrExpired
+		}
+		return domain.ErrLimitExceeded
+	}
+	return nil
+}
+
+// List returns a page of files and the cursor of the next page, nil on the
+// last page
+func (s *FileService) List(ctx context.Context, filter domain.ListFilter, page domain.Page) ([]*domain.File, *domain.Cursor, error) {
+	page, err := checkPage(page)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.log.Debug("Listing files", "owner_id", filter.OwnerID, "sort", page.Sort, "limit", page.Limit)
+
+	// Fetch one extra row to learn whether another page follows
+	limit := page.Limit
+	page.Limit++
+	files, err := s.repo.List(ctx, filter, page)
+	if err != nil {
+		s.log.Error("Failed to list files", "error", err)
+		return nil, nil, err
+	}
+	if len(files) <= limit {
+		return files, nil, nil
+	}
+	files = files[:limit]
+	return files, files[limit-1].CursorAfter(page.Sort), nil
+}
+
+// Delete removes a file on behalf of its owner, an admin or the holder of
+// its manage token
+func (s *FileService) Delete(ctx context.Context, id string, principal *domain.Principal, token string) error {
 	logger := s.log.With("file_id", id)
 	file, err := s.repo.FindByID(ctx, id)
 	if err != nil {
-		logger.Error("Failed to find file for deletion", "error", err)
+		logger.Warn("Failed to find file for deletion", "error", err)
 		return err
 	}
 
-	// Delete from storage
-	if err := s.storage.Delete(ctx, file.StorageKey); err != nil {
-		logger.Error("Failed to delete file from storage", "storage_key", file.StorageKey, "error", err)
+	if !file.CanManage(principal, token) {
+		logger.Warn("Refused to delete file not managed by the caller")
+		return domain.ErrForbidden
+	}
+
+	// Delete the metadata first: a stray object is harmless, a row pointing
+	// at a missing object is not
+	if err := s.repo.Delete(ctx, id); err != nil {
+		logger.Error("Failed to delete file metadata", "error", err)
 		return err
 	}
-	logger.Debug("File deleted from storage")
 
-	// Delete from repository
-	// Implementation depends on your repository
+	if err := s.storage.Delete(ctx, file.StorageKey); err != nil {
+		logger.Error("Failed to delete file from storage", "storage_key", file.StorageKey, "error", err)
+	}
 	logger.Info("File deleted successfully")
 	return nil
 }
 
 func (s *FileService) CleanupExpired(ctx context.Context) error {
 	s.log.Debug("Cleaning up expired files")
-	err := s.repo.DeleteExpired(ctx)
+	files, err := s.repo.DeleteExpired(ctx)
 	if err != nil {
 		s.log.Error("Failed to cleanup expired files", "error", err)
+		return err
 	}
-	return err
-}
-func appendTimestamp(filename string) string {
-	now := time.Now()
-	ext := filepath.Ext(filename)
-	name := strings.TrimSuffix(filename, ext)
-	timestamp := now.Format("20060102_150405")
-	return fmt.Sprintf("%s_%s%s", name, timestamp, ext)
+
+	// Free the storage too, or it would drift from the usage quotas measure
+	for _, file := range files {
+		if err := s.storage.Delete(ctx, file.StorageKey); err != nil {
+			s.log.Error("Failed to delete expired file from storage", "file_id", file.ID, "storage_key", file.StorageKey, "error", err)
+		}
+	}
+	if len(files) > 0 {
+		s.log.Info("Expired files cleaned up", "count", len(files))
+	}
+
+	// Bundles expire along with their files, so these are empty by now
+	if err := s.bundles.DeleteExpiredBundles(ctx); err != nil {
+		s.log.Error("Failed to cleanup expired bundles", "error", err)
+		return err
+	}
+	return nil
 }
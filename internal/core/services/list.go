@@ -0,0 +1,33 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+)
+
+// Page sizes of listings
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+
+	DefaultSearchResults = 20
+)
+
+// checkPage applies the default page size and rejects cursors minted for
+// another sort order
+func checkPage(page domain.Page) (domain.Page, error) {
+	if page.Sort == "" {
+		page.Sort = domain.SortCreated
+	}
+	if page.After != nil && page.After.Sort != page.Sort {
+		return page, fmt.Errorf("%w: cursor was issued for sort %q", domain.ErrInvalidInput, page.After.Sort)
+	}
+	switch {
+	case page.Limit == 0:
+		page.Limit = DefaultPageSize
+	case page.Limit < 0 || page.Limit > MaxPageSize:
+		return page, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidInput, MaxPageSize)
+	}
+	return page, nil
+}
@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
@@ -10,26 +13,33 @@ import (
 )
 
 type PasteService struct {
-	repo ports.PasteRepository
-	log  *slog.Logger
+	repo  ports.PasteRepository
+	quota *QuotaService
+	log   *slog.Logger
 }
 
-func NewPasteService(repo ports.PasteRepository, log *slog.Logger) *PasteService {
+func NewPasteService(repo ports.PasteRepository, quota *QuotaService, log *slog.Logger) *PasteService {
 	return &PasteService{
-		repo: repo,
-		log:  log,
+		repo:  repo,
+		quota: quota,
+		log:   log,
 	}
 }
 
-func (s *PasteService) Create(ctx context.Context, content, language, title string, ttl time.Duration) (*domain.Paste, error) {
+func (s *PasteService) Create(ctx context.Context, content, language, title string, ttl time.Duration, ownerID string) (*domain.Paste, error) {
 	if content == "" {
 		s.log.Warn("Attempt to create paste with empty content")
 		return nil, domain.ErrInvalidInput
 	}
 
-	paste := domain.NewPaste(content, language, title, ttl)
+	paste := domain.NewPaste(content, language, title, ttl, ownerID)
 	logger := s.log.With("paste_id", paste.ID)
 
+	if err := s.quota.Check(ctx, ownerID, int64(len(content))); err != nil {
+		logger.Warn("Paste refused by quota", "error", err)
+		return nil, err
+	}
+
 	if err := s.repo.Store(ctx, paste); err != nil {
 		logger.Error("Failed to store paste", "error", err)
 		return nil, err
@@ -39,6 +49,54 @@ func (s *PasteService) Create(ctx context.Context, content, language, title stri
 	return paste, nil
 }
 
+// CreateMultiFile creates a paste made of several named files
+func (s *PasteService) CreateMultiFile(ctx context.Context, files []domain.PasteFile, title string, ttl time.Duration, ownerID string) (*domain.Paste, error) {
+	paste, err := domain.NewMultiFilePaste(files, title, ttl, ownerID)
+	if err != nil {
+		s.log.Warn("Rejected multi-file paste", "error", err)
+		return nil, err
+	}
+	logger := s.log.With("paste_id", paste.ID)
+
+	if err := s.quota.Check(ctx, ownerID, paste.Size()); err != nil {
+		logger.Warn("Paste refused by quota", "error", err)
+		return nil, err
+	}
+
+	if err := s.repo.Store(ctx, paste); err != nil {
+		logger.Error("Failed to store paste", "error", err)
+		return nil, err
+	}
+
+	logger.Info("Multi-file paste created successfully", "files", len(paste.Files), "title", paste.Title)
+	return paste, nil
+}
+
+// Fork creates a paste for ownerID seeded from paste id. Reading the source
+// counts as a view of it, so forking is refused once it can't be viewed.
+func (s *PasteService) Fork(ctx context.Context, id string, ttl time.Duration, ownerID string) (*domain.Paste, error) {
+	source, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fork := source.Fork(ttl, ownerID)
+	logger := s.log.With("paste_id", fork.ID, "parent_id", source.ID)
+
+	if err := s.quota.Check(ctx, ownerID, fork.Size()); err != nil {
+		logger.Warn("Fork refused by quota", "error", err)
+		return nil, err
+	}
+
+	if err := s.repo.Store(ctx, fork); err != nil {
+		logger.Error("Failed to store fork", "error", err)
+		return nil, err
+	}
+
+	logger.Info("Paste forked successfully")
+	return fork, nil
+}
+
 func (s *PasteService) Get(ctx context.Context, id string) (*domain.Paste, error) {
 	logger := s.log.With("paste_id", id)
 	paste, err := s.repo.FindByID(ctx, id)
@@ -66,6 +124,19 @@ func (s *PasteService) Get(ctx context.Context, id string) (*domain.Paste, error
 	return paste, nil
 }
 
+// GetFile returns a file of a multi-file paste, read like Get reads the paste
+func (s *PasteService) GetFile(ctx context.Context, id, name string) (domain.PasteFile, error) {
+	paste, err := s.Get(ctx, id)
+	if err != nil {
+		return domain.PasteFile{}, err
+	}
+	file, ok := paste.File(name)
+	if !ok {
+		return domain.PasteFile{}, fmt.Errorf("%w: paste %s has no file %q", domain.ErrNotFound, id, name)
+	}
+	return file, nil
+}
+
 func (s *PasteService) GetRaw(ctx context.Context, id string) (string, error) {
 	paste, err := s.Get(ctx, id)
 	if err != nil {
@@ -74,9 +145,279 @@ func (s *PasteService) GetRaw(ctx context.Context, id string) (string, error) {
 	return paste.Content, nil
 }
 
-func (s *PasteService) Delete(ctx context.Context, id string) error {
-	// Implementation depends on your repository
-	s.log.Warn("Delete paste not implemented", "paste_id", id)
+// List returns a page of pastes and the cursor of the next page, nil on the
+// last page
+func (s *PasteService) List(ctx context.Context, filter domain.ListFilter, page domain.Page) ([]*domain.Paste, *domain.Cursor, error) {
+	page, err := checkPage(page)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.log.Debug("Listing pastes", "owner_id", filter.OwnerID, "sort", page.Sort, "limit", page.Limit)
+
+	// Fetch one extra row to learn whether another page follows
+	limit := page.Limit
+	page.Limit++
+	pastes, err := s.repo.List(ctx, filter, page)
+	if err != nil {
+		s.log.Error("Failed to list pastes", "error", err)
+		return nil, nil, err
+	}
+	if len(pastes) <= limit {
+		return pastes, nil, nil
+	}
+	pastes = pastes[:limit]
+	return pastes, pastes[limit-1].CursorAfter(page.Sort), nil
+}
+
+// Search finds the owner's pastes matching query, best match first
+func (s *PasteService) Search(ctx context.Context, ownerID, query string, limit int) ([]domain.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: empty search query", domain.ErrInvalidInput)
+	}
+	switch {
+	case limit == 0:
+		limit = DefaultSearchResults
+	case limit < 0 || limit > MaxPageSize:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidInput, MaxPageSize)
+	}
+	logger := s.log.With("owner_id", ownerID, "query", query)
+
+	if searcher, ok := s.repo.(ports.PasteSearcher); ok {
+		results, err := searcher.Search(ctx, ownerID, query, limit)
+		if err != nil {
+			logger.Error("Failed to search pastes", "error", err)
+			return nil, err
+		}
+		logger.Debug("Searched pastes", "results", len(results))
+		return results, nil
+	}
+
+	results, err := s.scan(ctx, ownerID, domain.SearchTerms(query))
+	if err != nil {
+		logger.Error("Failed to scan pastes for search", "error", err)
+		return nil, err
+	}
+	logger.Debug("Searched pastes without an index", "results", len(results))
+	return results[:min(limit, len(results))], nil
+}
+
+// scan is the search of repositories without a full-text index: it reads
+// every live paste of the owner and matches it in memory
+func (s *PasteService) scan(ctx context.Context, ownerID string, terms []string) ([]domain.SearchResult, error) {
+	var results []domain.SearchResult
+	page := domain.Page{Sort: domain.SortCreated, Limit: MaxPageSize}
+	for {
+		pastes, err := s.repo.List(ctx, domain.ListFilter{OwnerID: ownerID}, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pastes {
+			if result, ok := domain.MatchPaste(p, terms); ok {
+				found := *p
+				found.Content = ""
+				result.Paste = &found
+				results = append(results, result)
+			}
+		}
+		if len(pastes) < page.Limit {
+			break
+		}
+		page.After = pastes[len(pastes)-1].CursorAfter(page.Sort)
+	}
+
+	// Pastes were listed newest first, so equal ranks stay in that order
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Rank > results[j].Rank })
+	return results, nil
+}
+
+// Edit changes a paste on behalf of its owner, an admin or the holder of its
+// manage token, saving the result as a new revision
+func (s *PasteService) Edit(ctx context.Context, id string, principal *domain.Principal, token string, edit domain.PasteEdit) (*domain.Paste, error) {
+	logger := s.log.With("paste_id", id)
+	paste, err := s.viewable(ctx, id)
+	if err != nil {
+		logger.Warn("Failed to find paste to edit", "error", err)
+		return nil, err
+	}
+
+	if !paste.CanManage(principal, token) {
+		logger.Warn("Refused to edit paste not managed by the caller")
+		return nil, domain.ErrForbidden
+	}
+
+	// Earlier revisions are kept, so the new content counts in full
+	previous := paste.Content
+	revision, err := paste.Edit(edit)
+	if err != nil {
+		return nil, err
+	}
+	if revision == nil {
+		logger.Debug("Edit left paste unchanged")
+		return paste, nil
+	}
+	if paste.Content != previous {
+		if err := s.quota.CheckGrowth(ctx, paste.OwnerID, revision.Size); err != nil {
+			logger.Warn("Paste edit refused by quota", "error", err)
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(ctx, paste); err != nil {
+		logger.Warn("Failed to update paste", "error", err)
+		return nil, err
+	}
+	logger.Info("Paste edited successfully", "revision", paste.Revision)
+	return paste, nil
+}
+
+// Revisions lists the revisions of a paste, oldest first and without their
+// content. Unlike Get, it does not count as a view.
+func (s *PasteService) Revisions(ctx context.Context, id string) ([]*domain.Revision, error) {
+	if _, err := s.viewable(ctx, id); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.repo.ListRevisions(ctx, id)
+	if err != nil {
+		s.log.Error("Failed to list paste revisions", "paste_id", id, "error", err)
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// Revision returns one revision of a paste with its content, counting a view
+// unless the caller manages the paste
+func (s *PasteService) Revision(ctx context.Context, id string, number int, principal *domain.Principal, token string) (*domain.Revision, error) {
+	if _, err := s.read(ctx, id, principal, token); err != nil {
+		return nil, err
+	}
+	return s.repo.FindRevision(ctx, id, number)
+}
+
+// Diff compares two revisions of a paste. A zero from means the revision
+// before to, and a zero to the latest revision. Like Revision, it counts a
+// view unless the caller manages the paste.
+func (s *PasteService) Diff(ctx context.Context, id string, from, to int, principal *domain.Principal, token string) (*domain.Diff, error) {
+	paste, err := s.read(ctx, id, principal, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if to == 0 {
+		to = paste.Revision
+	}
+	if from == 0 {
+		from = max(to-1, 1)
+	}
+	if from < 1 || to < 1 || from > paste.Revision || to > paste.Revision {
+		return nil, fmt.Errorf("%w: paste %s has revisions 1 to %d", domain.ErrNotFound, id, paste.Revision)
+	}
+
+	a, err := s.revision(ctx, paste, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.revision(ctx, paste, to)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewDiff(a.Name(), a.Content, b.Name(), b.Content), nil
+}
+
+// DiffPastes compares the latest revisions of two pastes. Each paste is read
+// like Get reads it, so its expiry and view limit apply and a view is counted.
+func (s *PasteService) DiffPastes(ctx context.Context, a, b string) (*domain.Diff, error) {
+	from, err := s.Get(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	to := from
+	if b != a {
+		if to, err = s.Get(ctx, b); err != nil {
+			return nil, err
+		}
+	}
+	return domain.NewDiff(from.ID, from.Content, to.ID, to.Content), nil
+}
+
+// DiffText compares the latest revision of a paste with text, named name in
+// the diff. The paste is read like Get reads it.
+func (s *PasteService) DiffText(ctx context.Context, id, name, text string) (*domain.Diff, error) {
+	paste, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewDiff(paste.ID, paste.Content, name, text), nil
+}
+
+// revision returns revision number of paste, without a lookup for the latest
+func (s *PasteService) revision(ctx context.Context, paste *domain.Paste, number int) (*domain.Revision, error) {
+	if number == paste.Revision {
+		return paste.LatestRevision(), nil
+	}
+	return s.repo.FindRevision(ctx, paste.ID, number)
+}
+
+// Viewable checks that a paste exists and can still be viewed, without
+// counting a view
+func (s *PasteService) Viewable(ctx context.Context, id string) error {
+	_, err := s.viewable(ctx, id)
+	return err
+}
+
+// viewable returns the paste if it can still be viewed, without counting a view
+func (s *PasteService) viewable(ctx context.Context, id string) (*domain.Paste, error) {
+	paste, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !paste.CanView() {
+		if paste.IsExpired() {
+			return nil, domain.ErrExpired
+		}
+		return nil, domain.ErrLimitExceeded
+	}
+	return paste, nil
+}
+
+// read returns the paste if its content can be read by the caller. Those who
+// manage it read it freely, anyone else counts a view as with Get.
+func (s *PasteService) read(ctx context.Context, id string, principal *domain.Principal, token string) (*domain.Paste, error) {
+	paste, err := s.viewable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if paste.CanManage(principal, token) {
+		return paste, nil
+	}
+	if err := s.repo.IncrementViews(ctx, id); err != nil {
+		s.log.Error("Failed to increment paste views", "paste_id", id, "error", err)
+		return nil, err
+	}
+	return paste, nil
+}
+
+// Delete removes a paste on behalf of its owner, an admin or the holder of
+// its manage token
+func (s *PasteService) Delete(ctx context.Context, id string, principal *domain.Principal, token string) error {
+	logger := s.log.With("paste_id", id)
+	paste, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		logger.Warn("Failed to find paste for deletion", "error", err)
+		return err
+	}
+
+	if !paste.CanManage(principal, token) {
+		logger.Warn("Refused to delete paste not managed by the caller")
+		return domain.ErrForbidden
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		logger.Error("Failed to delete paste", "error", err)
+		return err
+	}
+	logger.Info("Paste deleted successfully")
 	return nil
 }
 
@@ -0,0 +1,317 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/ports"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPastes is an in-memory ports.PasteRepository without a search index
+type memoryPastes struct {
+	pastes    []*domain.Paste // newest first
+	revisions []*domain.Revision
+}
+
+var _ ports.PasteRepository = (*memoryPastes)(nil)
+
+func (m *memoryPastes) Store(_ context.Context, p *domain.Paste) error {
+	stored := *p
+	m.pastes = append([]*domain.Paste{&stored}, m.pastes...)
+	m.revisions = append(m.revisions, p.LatestRevision())
+	return nil
+}
+
+// FindByID returns a copy, so that callers must Update to change a paste
+func (m *memoryPastes) FindByID(_ context.Context, id string) (*domain.Paste, error) {
+	for _, p := range m.pastes {
+		if p.ID == id {
+			found := *p
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *memoryPastes) Update(_ context.Context, p *domain.Paste) error {
+	for _, stored := range m.pastes {
+		if stored.ID == p.ID {
+			if stored.Revision != p.Revision-1 {
+				return domain.ErrConflict
+			}
+			*stored = *p
+			m.revisions = append(m.revisions, p.LatestRevision())
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (m *memoryPastes) ListRevisions(_ context.Context, id string) ([]*domain.Revision, error) {
+	var out []*domain.Revision
+	for _, r := range m.revisions {
+		if r.PasteID == id {
+			listed := *r
+			listed.Content = ""
+			out = append(out, &listed)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryPastes) FindRevision(_ context.Context, id string, number int) (*domain.Revision, error) {
+	for _, r := range m.revisions {
+		if r.PasteID == id && r.Number == number {
+			return r, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+// List only supports the filters and pages the service uses to search
+func (m *memoryPastes) List(_ context.Context, filter domain.ListFilter, page domain.Page) ([]*domain.Paste, error) {
+	var out []*domain.Paste
+	started := page.After == nil
+	for _, p := range m.pastes {
+		if !started {
+			started = p.ID == page.After.ID
+			continue
+		}
+		if p.OwnerID == filter.OwnerID && len(out) < page.Limit {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryPastes) IncrementViews(_ context.Context, id string) error {
+	for _, p := range m.pastes {
+		if p.ID == id {
+			p.Views++
+		}
+	}
+	return nil
+}
+
+func (m *memoryPastes) Delete(context.Context, string) error { return nil }
+func (m *memoryPastes) DeleteExpired(context.Context) error  { return nil }
+
+func TestPasteSearchWithoutIndex(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	quota := services.NewQuotaService(fixedUsage{}, domain.QuotaPolicy{}, log)
+	pastes := services.NewPasteService(&memoryPastes{}, quota, log)
+
+	for _, p := range []struct{ owner, title, content string }{
+		{"alice", "nginx config", "proxy_pass http://app;"},
+		{"alice", "", "upstream app { server 10.0.0.1; }\nproxy_pass http://app;"},
+		{"alice", "notes", "nothing to see"},
+		{"bob", "nginx config", "proxy_pass http://bob;"},
+	} {
+		_, err := pastes.Create(ctx, p.content, "Text", p.title, time.Hour, p.owner)
+		require.NoError(t, err)
+	}
+
+	results, err := pastes.Search(ctx, "alice", "proxy_pass", 0)
+	require.NoError(t, err)
+	assert.Len(t, results, 2, "only alice's matching pastes")
+
+	results, err = pastes.Search(ctx, "alice", "app", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Contains(t, results[0].Snippet, "upstream", "more matches rank higher")
+
+	results, err = pastes.Search(ctx, "alice", "nginx app", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "nginx config", results[0].Paste.Title)
+	assert.Empty(t, results[0].Paste.Content)
+
+	results, err = pastes.Search(ctx, "alice", "proxy_pass", 1)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	_, err = pastes.Search(ctx, "alice", "  ", 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestPasteEdit(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	quota := services.NewQuotaService(fixedUsage{}, domain.QuotaPolicy{}, log)
+	repo := &memoryPastes{}
+	pastes := services.NewPasteService(repo, quota, log)
+
+	alice := &domain.Principal{UserID: "alice", Scopes: []domain.Scope{domain.ScopeWrite}}
+	bob := &domain.Principal{UserID: "bob", Scopes: []domain.Scope{domain.ScopeWrite}}
+	text := func(s string) *string { return &s }
+
+	paste, err := pastes.Create(ctx, "one\ntwo\n", "Text", "draft", time.Hour, "alice")
+	require.NoError(t, err)
+
+	_, err = pastes.Edit(ctx, paste.ID, bob, "", domain.PasteEdit{Content: text("mine now\n")})
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+
+	edited, err := pastes.Edit(ctx, paste.ID, alice, "", domain.PasteEdit{Content: text("one\n2\n")})
+	require.NoError(t, err)
+	assert.Equal(t, 2, edited.Revision)
+	assert.Equal(t, "Text", edited.Language, "the language is kept")
+	assert.Equal(t, "draft", edited.Title)
+
+	edited, err = pastes.Edit(ctx, paste.ID, nil, paste.ManageToken, domain.PasteEdit{Title: text("final")})
+	require.NoError(t, err)
+	assert.Equal(t, 3, edited.Revision, "the manage token also allows editing")
+
+	unchanged, err := pastes.Edit(ctx, paste.ID, alice, "", domain.PasteEdit{Title: text("final")})
+	require.NoError(t, err)
+	assert.Equal(t, 3, unchanged.Revision, "a no-op edit creates no revision")
+
+	_, err = pastes.Edit(ctx, paste.ID, alice, "", domain.PasteEdit{Content: text("")})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	base := 2
+	_, err = pastes.Edit(ctx, paste.ID, alice, "", domain.PasteEdit{Content: text("stale\n"), Revision: &base})
+	assert.ErrorIs(t, err, domain.ErrConflict, "an edit made from an older revision does not overwrite the latest")
+	base = 3
+	_, err = pastes.Edit(ctx, paste.ID, alice, "", domain.PasteEdit{Title: text("final"), Revision: &base})
+	require.NoError(t, err)
+
+	latest, err := pastes.Get(ctx, paste.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "one\n2\n", latest.Content)
+	assert.Equal(t, "final", latest.Title)
+
+	revisions, err := pastes.Revisions(ctx, paste.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, []string{"draft", "draft", "final"}, []string{revisions[0].Title, revisions[1].Title, revisions[2].Title})
+
+	first, err := pastes.Revision(ctx, paste.ID, 1, alice, "")
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n", first.Content)
+
+	diff, err := pastes.Diff(ctx, paste.ID, 1, 0, alice, "")
+	require.NoError(t, err)
+	assert.Equal(t, paste.ID+"@1", diff.From)
+	assert.Equal(t, paste.ID+"@3", diff.To)
+	assert.Contains(t, diff.Unified(), "-two\n+2\n")
+
+	diff, err = pastes.Diff(ctx, paste.ID, 0, 0, nil, paste.ManageToken)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Hunks, "the last edit only changed the title")
+
+	_, err = pastes.Diff(ctx, paste.ID, 1, 4, alice, "")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	// Others count a view, so that revisions do not get around the limit
+	repo.pastes[0].Views, repo.pastes[0].MaxViews = 0, 2
+	_, err = pastes.Revision(ctx, paste.ID, 1, bob, "")
+	require.NoError(t, err)
+	_, err = pastes.Diff(ctx, paste.ID, 1, 2, nil, "")
+	require.NoError(t, err)
+	_, err = pastes.Revision(ctx, paste.ID, 1, bob, "")
+	assert.ErrorIs(t, err, domain.ErrLimitExceeded)
+	_, err = pastes.Revision(ctx, paste.ID, 1, alice, "")
+	assert.ErrorIs(t, err, domain.ErrLimitExceeded, "the limit applies to everyone once reached")
+}
+
+func TestPasteFork(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := &memoryPastes{}
+	pastes := services.NewPasteService(repo, services.NewQuotaService(fixedUsage{}, domain.QuotaPolicy{}, log), log)
+
+	source, err := pastes.Create(ctx, "SELECT 1;", "SQL", "query", time.Hour, "alice")
+	require.NoError(t, err)
+	repo.pastes[0].MaxViews = 1
+
+	fork, err := pastes.Fork(ctx, source.ID, 2*time.Hour, "bob")
+	require.NoError(t, err)
+	assert.NotEqual(t, source.ID, fork.ID)
+	assert.Equal(t, source.ID, fork.ParentID)
+	assert.Equal(t, "bob", fork.OwnerID)
+	assert.Equal(t, []string{"SELECT 1;", "SQL", "query"}, []string{fork.Content, fork.Language, fork.Title})
+	assert.Equal(t, 1, fork.Revision)
+	assert.NotEmpty(t, fork.ManageToken)
+
+	stored, err := repo.FindByID(ctx, source.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Views, "forking counts as a view")
+
+	_, err = pastes.Fork(ctx, source.ID, time.Hour, "bob")
+	assert.ErrorIs(t, err, domain.ErrLimitExceeded, "the source's view limit applies")
+
+	_, err = pastes.Fork(ctx, "missing", time.Hour, "bob")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestPasteDiff(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := &memoryPastes{}
+	pastes := services.NewPasteService(repo, services.NewQuotaService(fixedUsage{}, domain.QuotaPolicy{}, log), log)
+
+	a, err := pastes.Create(ctx, "a\nb\n", "Text", "", time.Hour, "")
+	require.NoError(t, err)
+	b, err := pastes.Create(ctx, "a\nc\n", "Text", "", time.Hour, "")
+	require.NoError(t, err)
+
+	diff, err := pastes.DiffPastes(ctx, a.ID, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "--- "+a.ID+"\n+++ "+b.ID+"\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n", diff.Unified())
+
+	diff, err = pastes.DiffText(ctx, a.ID, "local.txt", "a\nb\n")
+	require.NoError(t, err)
+	assert.Equal(t, "local.txt", diff.To)
+	assert.Empty(t, diff.Hunks)
+
+	views := func(id string) int {
+		p, err := repo.FindByID(ctx, id)
+		require.NoError(t, err)
+		return p.Views
+	}
+	assert.Equal(t, []int{2, 1}, []int{views(a.ID), views(b.ID)}, "diffing counts as viewing")
+
+	// Once a paste has used up its views, it can't be diffed either
+	for _, p := range repo.pastes {
+		if p.ID == b.ID {
+			p.MaxViews = 1
+		}
+	}
+	_, err = pastes.DiffPastes(ctx, a.ID, b.ID)
+	assert.ErrorIs(t, err, domain.ErrLimitExceeded)
+}
+
+func TestMultiFilePaste(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := &memoryPastes{}
+	policy := domain.QuotaPolicy{User: domain.Quota{MaxBytes: 10}}
+	pastes := services.NewPasteService(repo, services.NewQuotaService(fixedUsage{}, policy, log), log)
+
+	files := []domain.PasteFile{{Name: "a.txt", Content: "12345"}, {Name: "b.txt", Content: "678901"}}
+	_, err := pastes.CreateMultiFile(ctx, files, "", time.Hour, "alice")
+	assert.ErrorIs(t, err, domain.ErrQuotaExceeded, "every file counts toward the quota")
+
+	files[1].Content = "67890"
+	paste, err := pastes.CreateMultiFile(ctx, files, "pair", time.Hour, "alice")
+	require.NoError(t, err)
+
+	file, err := pastes.GetFile(ctx, paste.ID, "b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "67890", file.Content)
+
+	_, err = pastes.GetFile(ctx, paste.ID, "c.txt")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	fork, err := pastes.Fork(ctx, paste.ID, time.Hour, "")
+	require.NoError(t, err)
+	assert.Equal(t, paste.Files, fork.Files, "forks keep every file")
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/ports"
+)
+
+type QuotaService struct {
+	repo   ports.UsageRepository
+	policy domain.QuotaPolicy
+	log    *slog.Logger
+}
+
+func NewQuotaService(repo ports.UsageRepository, policy domain.QuotaPolicy, log *slog.Logger) *QuotaService {
+	return &QuotaService{
+		repo:   repo,
+		policy: policy,
+		log:    log,
+	}
+}
+
+// Check returns ErrQuotaExceeded if the owner, or ErrStorageFull if the
+// server, has no room left for size more bytes. Anonymous content, with an
+// empty ownerID, only counts toward the global quota.
+//
+// The check is not atomic with the upload that follows, so concurrent
+// uploads may overshoot a quota by their own sizes.
+func (s *QuotaService) Check(ctx context.Context, ownerID string, size int64) error {
+	return s.check(ctx, ownerID, domain.Usage{Items: 1, Bytes: size})
+}
+
+// CheckBatch is Check for several new items of size bytes in total, such
+// as the files of a bundle
+func (s *QuotaService) CheckBatch(ctx context.Context, ownerID string, items int, size int64) error {
+	return s.check(ctx, ownerID, domain.Usage{Items: int64(items), Bytes: size})
+}
+
+// CheckGrowth is Check for content that grows by size bytes rather than a
+// new item, such as an edited paste
+func (s *QuotaService) CheckGrowth(ctx context.Context, ownerID string, size int64) error {
+	return s.check(ctx, ownerID, domain.Usage{Bytes: size})
+}
+
+func (s *QuotaService) check(ctx context.Context, ownerID string, more domain.Usage) error {
+	logger := s.log.With("owner_id", ownerID, "size", more.Bytes)
+
+	if ownerID != "" && s.policy.User != (domain.Quota{}) {
+		usage, err := s.repo.UsageByOwner(ctx, ownerID)
+		if err != nil {
+			logger.Error("Failed to measure user usage", "error", err)
+			return err
+		}
+		if err := s.policy.CheckUser(usage, more); err != nil {
+			logger.Warn("User quota exceeded", "items", usage.Items, "bytes", usage.Bytes)
+			return err
+		}
+	}
+
+	if s.policy.Global != (domain.Quota{}) {
+		usage, err := s.repo.TotalUsage(ctx)
+		if err != nil {
+			logger.Error("Failed to measure total usage", "error", err)
+			return err
+		}
+		if err := s.policy.CheckGlobal(usage, more); err != nil {
+			logger.Warn("Global quota exceeded", "items", usage.Items, "bytes", usage.Bytes)
+			return err
+		}
+	}
+	return nil
+}
+
+// Usage returns what the owner stores along with their quota
+func (s *QuotaService) Usage(ctx context.Context, ownerID string) (domain.Usage, domain.Quota, error) {
+	usage, err := s.repo.UsageByOwner(ctx, ownerID)
+	return usage, s.policy.User, err
+}
+
+// TotalUsage returns what the server stores along with its quota
+func (s *QuotaService) TotalUsage(ctx context.Context) (domain.Usage, domain.Quota, error) {
+	usage, err := s.repo.TotalUsage(ctx)
+	return usage, s.policy.Global, err
+}
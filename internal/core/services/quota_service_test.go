@@ -0,0 +1,42 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/core/domain"
+	"github.com/Gandalf-Le-Dev/quip/internal/core/services"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedUsage reports the same usage for every owner
+type fixedUsage struct {
+	owner, total domain.Usage
+}
+
+func (u fixedUsage) UsageByOwner(context.Context, string) (domain.Usage, error) {
+	return u.owner, nil
+}
+
+func (u fixedUsage) TotalUsage(context.Context) (domain.Usage, error) {
+	return u.total, nil
+}
+
+func TestQuotaServiceCheck(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	policy := domain.QuotaPolicy{
+		User:   domain.Quota{MaxBytes: 100},
+		Global: domain.Quota{MaxBytes: 1000},
+	}
+
+	quota := services.NewQuotaService(fixedUsage{owner: domain.Usage{Bytes: 90}, total: domain.Usage{Bytes: 500}}, policy, log)
+	assert.ErrorIs(t, quota.Check(ctx, "alice", 20), domain.ErrQuotaExceeded)
+	assert.NoError(t, quota.Check(ctx, "", 20), "anonymous content has no user quota")
+
+	quota = services.NewQuotaService(fixedUsage{owner: domain.Usage{}, total: domain.Usage{Bytes: 990}}, policy, log)
+	assert.ErrorIs(t, quota.Check(ctx, "alice", 20), domain.ErrStorageFull)
+	assert.ErrorIs(t, quota.Check(ctx, "", 20), domain.ErrStorageFull)
+}
@@ -0,0 +1,132 @@
+// Package certreload serves a TLS certificate that can be replaced on disk
+// without restarting the server.
+package certreload
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Reloader holds the current certificate and key pair. Hand GetCertificate to
+// a tls.Config and call Reload, or run Watch, to pick up renewed files.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	log      *slog.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// New loads the pair once, failing if it is unreadable or mismatched.
+func New(certFile, keyFile string, log *slog.Logger) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		log:      log.With("cert_file", certFile, "key_file", keyFile),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the pair from disk. On failure the previous certificate stays
+// in use, so a half-written renewal never takes the server down.
+func (r *Reloader) Reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading TLS key pair: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+
+	r.log.Info("TLS certificate loaded", "not_after", cert.Leaf.NotAfter)
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Watch reloads the pair on SIGHUP and, when interval is positive, whenever
+// either file changes on disk. It returns once ctx is cancelled.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.log.Info("SIGHUP received, reloading TLS certificate")
+			if err := r.Reload(); err != nil {
+				r.log.Error("Failed to reload TLS certificate, keeping the current one", "error", err)
+			}
+		case <-tick:
+			if err := r.reloadIfChanged(); err != nil {
+				r.log.Error("Failed to reload TLS certificate, keeping the current one", "error", err)
+			}
+		}
+	}
+}
+
+// reloadIfChanged reloads the pair when either file is newer than the loaded one
+func (r *Reloader) reloadIfChanged() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	changed := modTime.After(r.modTime)
+	r.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+
+	r.log.Info("TLS certificate changed on disk, reloading")
+	return r.Reload()
+}
+
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,180 @@
+package certreload_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/certreload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA is a throwaway certificate authority generated for each test
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "quip test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// issue writes a localhost leaf certificate with the given serial to dir
+func (ca *testCA) issue(t *testing.T, dir string, serial int64) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	// Make each issue visibly newer, whatever the filesystem's mtime resolution
+	modTime := time.Now().Add(time.Duration(serial) * time.Second)
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+	return certFile, keyFile
+}
+
+func servedSerial(t *testing.T, r *certreload.Reloader) int64 {
+	t.Helper()
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	return cert.Leaf.SerialNumber.Int64()
+}
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestReload(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.issue(t, dir, 1)
+
+	r, err := certreload.New(certFile, keyFile, discard)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, servedSerial(t, r))
+
+	ca.issue(t, dir, 2)
+	require.NoError(t, r.Reload())
+	assert.EqualValues(t, 2, servedSerial(t, r))
+
+	// A broken renewal keeps the previous certificate
+	require.NoError(t, os.WriteFile(certFile, []byte("garbage"), 0o600))
+	assert.Error(t, r.Reload())
+	assert.EqualValues(t, 2, servedSerial(t, r))
+}
+
+func TestNewRejectsMissingFiles(t *testing.T) {
+	_, err := certreload.New("missing.pem", "missing-key.pem", discard)
+	assert.Error(t, err)
+}
+
+func TestWatchPicksUpChangedFiles(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.issue(t, dir, 1)
+
+	r, err := certreload.New(certFile, keyFile, discard)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, 10*time.Millisecond)
+
+	ca.issue(t, dir, 2)
+	assert.Eventually(t, func() bool { return servedSerial(t, r) == 2 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServesHTTP2WithReloadedCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.issue(t, dir, 1)
+
+	r, err := certreload.New(certFile, keyFile, discard)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: &tls.Config{
+			GetCertificate: r.GetCertificate,
+			NextProtos:     []string{"h2", "http/1.1"},
+		},
+	}
+	go srv.ServeTLS(ln, "", "")
+	defer srv.Close()
+
+	// Fresh connections each time, so every request sees the current certificate
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: ca.pool, ServerName: "localhost"},
+		ForceAttemptHTTP2: true,
+		DisableKeepAlives: true,
+	}}
+	get := func() *http.Response {
+		resp, err := client.Get("https://" + ln.Addr().String())
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := get()
+	assert.Equal(t, "HTTP/2.0", resp.Proto)
+	assert.EqualValues(t, 1, resp.TLS.PeerCertificates[0].SerialNumber.Int64())
+
+	ca.issue(t, dir, 2)
+	require.NoError(t, r.Reload())
+	assert.EqualValues(t, 2, get().TLS.PeerCertificates[0].SerialNumber.Int64())
+}
@@ -0,0 +1,100 @@
+// Package duration parses and formats human friendly durations. It accepts
+// everything time.ParseDuration does plus days ("d") and weeks ("w"), so a
+// TTL can be written "7d" instead of "168h".
+package duration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+// Parse parses a duration such as "90s", "1h30m", "7d" or "2w3d". Negative
+// durations are rejected.
+func Parse(s string) (time.Duration, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	if s == "0" {
+		return 0, nil
+	}
+
+	// Peel off leading day and week components, leaving the rest to time.ParseDuration
+	var total time.Duration
+	for s != "" {
+		i := 0
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+			i++
+		}
+		if i == 0 || i == len(s) || (s[i] != 'd' && s[i] != 'w') {
+			break
+		}
+		n, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		unit := Day
+		if s[i] == 'w' {
+			unit = Week
+		}
+		total += time.Duration(n * float64(unit))
+		s = s[i+1:]
+	}
+
+	if s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		total += d
+	}
+	return total, nil
+}
+
+// Format writes d with the largest units first, e.g. "7d", "1d12h" or
+// "1h30m". The result always round-trips through Parse.
+func Format(d time.Duration) string {
+	if d < time.Second || d%time.Second != 0 {
+		return d.String()
+	}
+
+	var b strings.Builder
+	for _, u := range []struct {
+		size   time.Duration
+		suffix string
+	}{{Day, "d"}, {time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}} {
+		if n := d / u.size; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, u.suffix)
+			d -= n * u.size
+		}
+	}
+	return b.String()
+}
+
+// Duration is a time.Duration that reads and writes in the human format.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return Format(time.Duration(d))
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
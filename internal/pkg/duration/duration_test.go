@@ -0,0 +1,50 @@
+package duration_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gandalf-Le-Dev/quip/internal/pkg/duration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"0":      0,
+		"90s":    90 * time.Second,
+		"1h30m":  90 * time.Minute,
+		"7d":     7 * duration.Day,
+		"1.5d":   36 * time.Hour,
+		"2w3d":   17 * duration.Day,
+		"1d12h":  36 * time.Hour,
+		" 24h ":  24 * time.Hour,
+		"1w1d1h": 8*duration.Day + time.Hour,
+	} {
+		got, err := duration.Parse(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "d", "7", "-1h", "1h-5m", "seven days", "1y", "3dd"} {
+		_, err := duration.Parse(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                       "0s",
+		90 * time.Second:        "1m30s",
+		90 * time.Minute:        "1h30m",
+		24 * time.Hour:          "1d",
+		168 * time.Hour:         "7d",
+		36 * time.Hour:          "1d12h",
+		1500 * time.Millisecond: "1.5s",
+	} {
+		assert.Equal(t, want, duration.Format(d))
+		parsed, err := duration.Parse(want)
+		require.NoError(t, err, want)
+		assert.Equal(t, d, parsed, "round trip of %s", want)
+	}
+}
@@ -0,0 +1,294 @@
+package qr
+
+// builder draws the modules of a code. Coordinates are x for the column
+// and y for the row, from the top left corner.
+type builder struct {
+	version  int
+	size     int
+	dark     []bool
+	function []bool // modules of the function patterns, never masked
+}
+
+func newBuilder(version int) *builder {
+	size := 4*version + 17
+	return &builder{version: version, size: size, dark: make([]bool, size*size), function: make([]bool, size*size)}
+}
+
+func (b *builder) setFunction(x, y int, dark bool) {
+	b.dark[y*b.size+x] = dark
+	b.function[y*b.size+x] = true
+}
+
+// drawFunctionPatterns draws the finder, timing and alignment patterns, and
+// reserves the format and version areas
+func (b *builder) drawFunctionPatterns() {
+	for i := range b.size {
+		b.setFunction(6, i, i%2 == 0)
+		b.setFunction(i, 6, i%2 == 0)
+	}
+
+	b.drawFinder(3, 3)
+	b.drawFinder(b.size-4, 3)
+	b.drawFinder(3, b.size-4)
+
+	positions := alignmentPositions(b.version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// The corners are taken by finders
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			b.drawAlignment(x, y)
+		}
+	}
+
+	b.drawFormatBits(Low, 0) // reserved, drawn for real once masked
+	b.drawVersion()
+}
+
+// drawFinder draws a finder pattern centered on x, y, with its separator
+func (b *builder) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= b.size || yy >= b.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			b.setFunction(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+// drawAlignment draws an alignment pattern centered on x, y
+func (b *builder) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			b.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPositions returns the rows and columns of the alignment patterns
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	}
+	result := make([]int, n)
+	result[0] = 6
+	for i, pos := n-1, 4*version+10; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+// formatBits returns the 15 bits telling the level and mask, protected by a
+// BCH code and masked so that they are never all light
+func formatBits(level Level, mask int) int {
+	// The levels are not numbered in order
+	data := []int{Low: 1, Medium: 0, Quartile: 3, High: 2}[level]<<3 | mask
+	rem := data
+	for range 10 {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawFormatBits draws both copies of the format bits
+func (b *builder) drawFormatBits(level Level, mask int) {
+	bits := formatBits(level, mask)
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+
+	// Around the top left finder
+	for i := 0; i <= 5; i++ {
+		b.setFunction(8, i, bit(i))
+	}
+	b.setFunction(8, 7, bit(6))
+	b.setFunction(8, 8, bit(7))
+	b.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		b.setFunction(14-i, 8, bit(i))
+	}
+
+	// Split between the other two finders
+	for i := range 8 {
+		b.setFunction(b.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		b.setFunction(8, b.size-15+i, bit(i))
+	}
+	b.setFunction(8, b.size-8, true) // always dark
+}
+
+// versionBits returns the 18 bits telling the version, protected by a BCH
+// code
+func versionBits(version int) int {
+	rem := version
+	for range 12 {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// drawVersion draws both copies of the version bits, from version 7 on
+func (b *builder) drawVersion() {
+	if b.version < 7 {
+		return
+	}
+	bits := versionBits(b.version)
+	for i := range 18 {
+		dark := (bits>>i)&1 != 0
+		x, y := b.size-11+i%3, i/3
+		b.setFunction(x, y, dark)
+		b.setFunction(y, x, dark)
+	}
+}
+
+// drawCodewords fills the modules left by the function patterns with the
+// codewords, in two-module wide columns zigzagging from the bottom right
+func (b *builder) drawCodewords(codewords []byte) {
+	i := 0
+	for right := b.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := range b.size {
+			y := vert
+			if upward {
+				y = b.size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if b.function[y*b.size+x] {
+					continue
+				}
+				// Remainder bits past the codewords stay light
+				if i < len(codewords)*8 {
+					b.dark[y*b.size+x] = (codewords[i/8]>>(7-i%8))&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the modules outside the function patterns where the mask
+// pattern is set. Applying a mask twice undoes it.
+func (b *builder) applyMask(mask int) {
+	for y := range b.size {
+		for x := range b.size {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !b.function[y*b.size+x] {
+				b.dark[y*b.size+x] = !b.dark[y*b.size+x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the modules are to scan, with the rules of the
+// standard: long runs, 2x2 blocks, finder-like patterns and imbalance
+func (b *builder) penalty() int {
+	at := func(x, y int) bool { return b.dark[y*b.size+x] }
+	score := 0
+	for _, transpose := range []bool{false, true} {
+		for i := range b.size {
+			line := make([]bool, b.size)
+			for j := range b.size {
+				if transpose {
+					line[j] = at(i, j)
+				} else {
+					line[j] = at(j, i)
+				}
+			}
+			score += linePenalty(line)
+		}
+	}
+
+	dark := 0
+	for y := range b.size {
+		for x := range b.size {
+			if at(x, y) {
+				dark++
+			}
+			if x+1 < b.size && y+1 < b.size {
+				c := at(x, y)
+				if at(x+1, y) == c && at(x, y+1) == c && at(x+1, y+1) == c {
+					score += 3
+				}
+			}
+		}
+	}
+	total := b.size * b.size
+	score += ((abs(dark*20-total*10)+total-1)/total - 1) * 10
+	return score
+}
+
+// finderLike is the 1:1:3:1:1 pattern of finders, with four light modules
+// on one side
+var finderLike = [][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// linePenalty scores runs and finder-like patterns of a row or column
+func linePenalty(line []bool) int {
+	score := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			score += 3 + run - 5
+		}
+		run = 1
+	}
+	for i := 0; i+11 <= len(line); i++ {
+		for _, pattern := range finderLike {
+			match := true
+			for j, dark := range pattern {
+				if line[i+j] != dark {
+					match = false
+					break
+				}
+			}
+			if match {
+				score += 40
+			}
+		}
+	}
+	return score
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,219 @@
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// HELLO WORLD at 1-M, the example of the thonky.com QR tutorial
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, rsRemainder(data, rsDivisor(10)))
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	// From the tables of the standard
+	assert.Equal(t, 0b111011111000100, formatBits(Low, 0))
+	assert.Equal(t, 0b101010000010010, formatBits(Medium, 0))
+	assert.Equal(t, 0b011010101011111, formatBits(Quartile, 0))
+	assert.Equal(t, 0b001011010001001, formatBits(High, 0))
+	assert.Equal(t, 0b100000011001110, formatBits(Medium, 5))
+	assert.Equal(t, 0b000111110010010100, versionBits(7))
+	assert.Equal(t, 0b101000110001101001, versionBits(40))
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	assert.Empty(t, alignmentPositions(1))
+	assert.Equal(t, []int{6, 18}, alignmentPositions(2))
+	assert.Equal(t, []int{6, 22, 38}, alignmentPositions(7))
+	assert.Equal(t, []int{6, 26, 46, 66}, alignmentPositions(14))
+	assert.Equal(t, []int{6, 34, 60, 86, 112, 138}, alignmentPositions(32))
+	assert.Equal(t, []int{6, 30, 58, 86, 114, 142, 170}, alignmentPositions(40))
+}
+
+func TestCapacity(t *testing.T) {
+	// Bytes each version holds, from the tables of the standard
+	capacity := func(version int, level Level) int {
+		return (8*dataCodewords(version, level) - 4 - countBits(version)) / 8
+	}
+	assert.Equal(t, 17, capacity(1, Low))
+	assert.Equal(t, 14, capacity(1, Medium))
+	assert.Equal(t, 11, capacity(1, Quartile))
+	assert.Equal(t, 7, capacity(1, High))
+	assert.Equal(t, 213, capacity(10, Medium))
+	assert.Equal(t, 2953, capacity(40, Low))
+	assert.Equal(t, 1273, capacity(40, High))
+
+	_, err := Encode(strings.Repeat("a", 2954), Low)
+	assert.ErrorIs(t, err, ErrTooLong)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"https://quip.example/api/v1/view/V1StGXR8_Z5jdHi6B-myT",
+		strings.Repeat("quip ", 60),
+		strings.Repeat("é", 600),
+	} {
+		for level := Low; level <= High; level++ {
+			c, err := Encode(text, level)
+			require.NoError(t, err)
+			assert.Equal(t, 4*c.Version+17, c.Size)
+			assert.Equal(t, text, decode(t, c, level), "version %d level %d", c.Version, level)
+		}
+	}
+}
+
+func TestImages(t *testing.T) {
+	c, err := Encode("https://quip.example", Medium)
+	require.NoError(t, err)
+
+	data, err := c.PNG(2)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	side := (c.Size + 2*QuietZone) * 2
+	assert.Equal(t, side, img.Bounds().Dx())
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.NotZero(t, r, "the quiet zone is light")
+	r, _, _, _ = img.At(QuietZone*2, QuietZone*2).RGBA()
+	assert.Zero(t, r, "finders start dark")
+
+	var svg bytes.Buffer
+	require.NoError(t, c.WriteSVG(&svg))
+	assert.True(t, strings.HasPrefix(svg.String(), `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 33 33"`))
+	assert.Contains(t, svg.String(), "M4 4h7v1h-7z", "the top row of the top left finder")
+}
+
+// decode reads a code back the way a scanner does once it found the modules,
+// checking the error correction along the way
+func decode(t *testing.T, c *Code, level Level) string {
+	t.Helper()
+
+	// Format bits, both copies
+	var first, second int
+	for i, p := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		if c.Dark(p[0], p[1]) {
+			first |= 1 << i
+		}
+	}
+	for i := range 15 {
+		x, y := c.Size-1-i, 8
+		if i >= 8 {
+			x, y = 8, c.Size-15+i
+		}
+		if c.Dark(x, y) {
+			second |= 1 << i
+		}
+	}
+	require.Equal(t, first, second, "both copies of the format bits match")
+	mask := -1
+	for m := range 8 {
+		if formatBits(level, m) == first {
+			mask = m
+		}
+	}
+	require.NotEqual(t, -1, mask, "format bits of level %d", level)
+
+	// Modules holding data, in reading order, unmasked
+	b := newBuilder(c.Version)
+	b.drawFunctionPatterns()
+	var bits []bool
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for vert := range c.Size {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = c.Size - 1 - vert
+			}
+			for x := right; x >= right-1; x-- {
+				if b.function[y*c.Size+x] {
+					continue
+				}
+				bits = append(bits, c.Dark(x, y) != maskBit(mask, x, y))
+			}
+		}
+	}
+	raw := rawModules(c.Version) / 8
+	require.Len(t, bits, rawModules(c.Version))
+	codewords := make([]byte, raw)
+	for i := range raw * 8 {
+		if bits[i] {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	// Deinterleave the blocks, checking that their syndromes are zero
+	blocks, eccLen := eccBlocks[level][c.Version], eccPerBlock[level][c.Version]
+	shortBlocks := blocks - raw%blocks
+	shortLen := raw / blocks
+	all := make([][]byte, blocks)
+	k := 0
+	for i := range shortLen + 1 {
+		for j := range blocks {
+			if i == shortLen-eccLen && j < shortBlocks {
+				continue
+			}
+			all[j] = append(all[j], codewords[k])
+			k++
+		}
+	}
+	var data []byte
+	for j, block := range all {
+		// ECC codewords come after the data, the last eccLen of the block
+		for i := range eccLen {
+			root, s := byte(1), byte(0)
+			for range i {
+				root = gfMultiply(root, 2)
+			}
+			for _, cw := range block {
+				s = gfMultiply(s, root) ^ cw
+			}
+			require.Zero(t, s, "syndrome %d of block %d", i, j)
+		}
+		data = append(data, block[:len(block)-eccLen]...)
+	}
+
+	// Byte mode segment
+	read := func(pos, n int) int {
+		v := 0
+		for i := range n {
+			v = v<<1 | int(data[(pos+i)/8]>>(7-(pos+i)%8)&1)
+		}
+		return v
+	}
+	require.Equal(t, 0b0100, read(0, 4), "byte mode")
+	n := read(4, countBits(c.Version))
+	text := make([]byte, n)
+	for i := range n {
+		text[i] = byte(read(4+countBits(c.Version)+8*i, 8))
+	}
+	return string(text)
+}
+
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	}
+	return ((x+y)%2+x*y%3)%2 == 0
+}
@@ -0,0 +1,459 @@
+// Package v1 holds the request and response bodies of the /api/v1 HTTP API.
+//
+// The types are shared by the server, the CLI and any other client, so a
+// breaking change here means a new API version rather than an edit.
+package v1
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Prefix is the path prefix every v1 route is mounted under.
+const Prefix = "/api/v1"
+
+// Request headers understood by the API.
+const (
+	// HeaderAPIKey carries an API key, as an alternative to "Authorization: Bearer <key>".
+	HeaderAPIKey = "X-API-Key"
+	// HeaderManageToken carries the manage token returned on creation, which
+	// authorizes editing and deleting that content without an account.
+	HeaderManageToken = "X-Manage-Token"
+	// HeaderResumeToken is returned with every file download, and sent back
+	// with a range request resuming it so that it does not count again.
+	HeaderResumeToken = "X-Resume-Token"
+)
+
+// FileUploaded is returned by POST /api/v1/file.
+type FileUploaded struct {
+	ID        string     `json:"id"`
+	Filename  string     `json:"filename"`
+	Size      int64      `json:"size"`
+	Download  string     `json:"download"`
+	View      string     `json:"view"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil if the file never expires
+	// ManageToken authorizes deleting the file. It is only ever returned here.
+	ManageToken string `json:"manage_token"`
+}
+
+// File describes an uploaded file, as returned by GET /api/v1/file/{id}/info.
+type File struct {
+	ID           string     `json:"id"`
+	Filename     string     `json:"filename"`
+	Size         int64      `json:"size"`
+	ContentType  string     `json:"content_type"`
+	Downloads    int        `json:"downloads"`
+	MaxDownloads int        `json:"max_downloads"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at"` // nil if the file never expires
+	Download     string     `json:"download"`
+	View         string     `json:"view"`
+	// BundleID is set for files uploaded as part of a bundle
+	BundleID string `json:"bundle_id,omitempty"`
+}
+
+// Bundle describes files uploaded together, as returned by POST and GET
+// /api/v1/bundle/{id}. Its files share its expiry, and every download of
+// the bundle or of one of its files counts toward MaxDownloads.
+type Bundle struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title,omitempty"`
+	Files        []File     `json:"files"`
+	Size         int64      `json:"size"`
+	Downloads    int        `json:"downloads"`
+	MaxDownloads int        `json:"max_downloads"` // -1 if unlimited
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at"` // nil if the bundle never expires
+	View         string     `json:"view"`
+	Zip          string     `json:"zip"`
+	TarGz        string     `json:"tar_gz"`
+	// ManageToken authorizes deleting the bundle and its files. It is only
+	// ever returned on upload.
+	ManageToken string `json:"manage_token,omitempty"`
+}
+
+// CreatePasteRequest is the body of POST /api/v1/paste. It has either
+// Content, with an optional Language, or Files for a multi-file paste.
+type CreatePasteRequest struct {
+	Content  string      `json:"content,omitempty"`
+	Language string      `json:"language,omitempty"`
+	Files    []PasteFile `json:"files,omitempty"`
+	Title    string      `json:"title,omitempty"`
+	TTL      string      `json:"ttl,omitempty"`
+}
+
+// PasteFile is a named file of a multi-file paste. Requests give its Name,
+// Content and optionally Language; responses fill in the rest.
+type PasteFile struct {
+	Name     string `json:"name"`
+	Content  string `json:"content,omitempty"`
+	Language string `json:"language,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Raw      string `json:"raw,omitempty"`
+}
+
+// PasteCreated is returned by POST /api/v1/paste.
+type PasteCreated struct {
+	ID        string     `json:"id"`
+	Language  string     `json:"language"`
+	Raw       string     `json:"raw"`
+	View      string     `json:"view"`
+	ExpiresAt *time.Time `json:"expires_at"`          // nil if the paste never expires
+	ParentID  string     `json:"parent_id,omitempty"` // set on forks
+	// Files describes the files of a multi-file paste, without their content
+	Files []PasteFile `json:"files,omitempty"`
+	// ManageToken authorizes editing and deleting the paste. It is only ever
+	// returned here.
+	ManageToken string `json:"manage_token"`
+}
+
+// ForkPasteRequest is the optional body of POST /api/v1/paste/{id}/fork.
+type ForkPasteRequest struct {
+	TTL string `json:"ttl,omitempty"`
+}
+
+// Paste is a paste with its content, as returned by GET /api/v1/paste/{id}.
+// It holds the latest revision.
+type Paste struct {
+	ID        string     `json:"id"`
+	Content   string     `json:"content"`
+	Language  string     `json:"language"`
+	Title     string     `json:"title,omitempty"`
+	Views     int        `json:"views"`
+	MaxViews  int        `json:"max_views"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil if the paste never expires
+	Revision  int        `json:"revision"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"` // nil until the paste is edited
+	ParentID  string     `json:"parent_id,omitempty"`  // the paste this one was forked from
+	Raw       string     `json:"raw"`
+	View      string     `json:"view"`
+	// Files are the files of a multi-file paste, the first of which is also
+	// Content
+	Files []PasteFile `json:"files,omitempty"`
+	Zip   string      `json:"zip"`
+}
+
+// PasteSummary describes a paste listed by GET /api/v1/pastes, without its
+// content.
+type PasteSummary struct {
+	ID        string     `json:"id"`
+	Language  string     `json:"language"`
+	Title     string     `json:"title,omitempty"`
+	Size      int64      `json:"size"` // of all the files of multi-file pastes
+	Views     int        `json:"views"`
+	MaxViews  int        `json:"max_views"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil if the paste never expires
+	Revision  int        `json:"revision"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	ParentID  string     `json:"parent_id,omitempty"`
+	Raw       string     `json:"raw"`
+	View      string     `json:"view"`
+	// Files are the files of a multi-file paste, without their content
+	Files []PasteFile `json:"files,omitempty"`
+	Zip   string      `json:"zip"`
+}
+
+// EditPasteRequest is the body of PATCH /api/v1/paste/{id}. Omitted fields
+// are left unchanged; an empty language is detected again from the content.
+// Revision is the revision the edit was made from: when set, the edit fails
+// with CodeConflict if the paste was edited since.
+type EditPasteRequest struct {
+	Content  *string `json:"content,omitempty"`
+	Language *string `json:"language,omitempty"`
+	Title    *string `json:"title,omitempty"`
+	Revision *int    `json:"revision,omitempty"`
+}
+
+// RevisionList is returned by GET /api/v1/paste/{id}/revisions, oldest first.
+type RevisionList struct {
+	Revisions []Revision `json:"revisions"`
+}
+
+// Revision describes an immutable version of a paste, without its content.
+type Revision struct {
+	Number    int       `json:"number"`
+	Language  string    `json:"language"`
+	Title     string    `json:"title,omitempty"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	Raw       string    `json:"raw"`
+}
+
+// SearchResults is returned by GET /api/v1/pastes/search, best match first.
+type SearchResults struct {
+	Results []SearchResult `json:"results"`
+}
+
+// SearchResult is a paste matching a search, without its content.
+type SearchResult struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title,omitempty"`
+	Language  string     `json:"language"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil if the paste never expires
+	Raw       string     `json:"raw"`
+	View      string     `json:"view"`
+	Rank      float64    `json:"rank"`
+	// Snippet is an excerpt of the content around the matches
+	Snippet string `json:"snippet"`
+	// Highlights are the [start, end) byte offsets of the matches in Snippet
+	Highlights [][2]int `json:"highlights"`
+}
+
+// Formats of the diff endpoints, chosen with the format query parameter.
+const (
+	DiffFormatText = "text" // unified diff, the default
+	DiffFormatJSON = "json" // a Diff
+	DiffFormatHTML = "html" // side-by-side viewer page
+)
+
+// Diff is the JSON form of a diff between two pastes, a paste and uploaded
+// text, or two revisions of a paste.
+type Diff struct {
+	From  string     `json:"from"`
+	To    string     `json:"to"`
+	Hunks []DiffHunk `json:"hunks"` // empty when there are no changes
+}
+
+// DiffHunk is a run of changes with its surrounding context. Lines start
+// with " ", "-" or "+" as in a unified diff, and have no line break.
+type DiffHunk struct {
+	FromLine  int      `json:"from_line"`
+	FromCount int      `json:"from_count"`
+	ToLine    int      `json:"to_line"`
+	ToCount   int      `json:"to_count"`
+	Lines     []string `json:"lines"`
+}
+
+// User is the authenticated caller, as returned by GET /api/v1/me.
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"` // of the API key used for the request
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FileList is a page of GET /api/v1/files. Pass NextCursor as the cursor
+// query parameter to get the next page; it is empty on the last page.
+type FileList struct {
+	Files      []File `json:"files"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PasteList is a page of GET /api/v1/pastes, paginated like FileList.
+type PasteList struct {
+	Pastes     []PasteSummary `json:"pastes"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// Usage is returned by GET /api/v1/me/usage.
+type Usage struct {
+	User   UsageStats  `json:"user"`
+	Global *UsageStats `json:"global,omitempty"` // only reported to admins
+}
+
+// UsageStats is the stored content against its quota. A zero maximum means
+// unlimited.
+type UsageStats struct {
+	Items    int64 `json:"items"`
+	Bytes    int64 `json:"bytes"`
+	MaxItems int64 `json:"max_items"`
+	MaxBytes int64 `json:"max_bytes"`
+}
+
+// ServerConfig is returned by GET /api/v1/config, so clients can offer the
+// choices the server accepts.
+type ServerConfig struct {
+	TTL    TTLConfig    `json:"ttl"`
+	Limits LimitsConfig `json:"limits"`
+	// AllowAnonymous tells whether uploads work without an API key
+	AllowAnonymous bool `json:"allow_anonymous"`
+}
+
+// TTLConfig describes the accepted TTLs. Durations use the "30m", "24h",
+// "7d" notation that the ttl fields of requests accept.
+type TTLConfig struct {
+	Default string   `json:"default"`
+	Min     string   `json:"min"`
+	Max     string   `json:"max"`
+	Presets []string `json:"presets"`
+	// AllowPermanent tells whether authenticated users may send ttl "never"
+	AllowPermanent bool `json:"allow_permanent"`
+}
+
+// LimitsConfig gives the size limits, in bytes.
+type LimitsConfig struct {
+	MaxFileSize   int64 `json:"max_file_size"`
+	MaxPasteSize  int64 `json:"max_paste_size"`
+	MaxBundleSize int64 `json:"max_bundle_size"`
+}
+
+// LanguageList is returned by GET /api/v1/languages: the paste languages the
+// server detects, sorted. Pastes may name others.
+type LanguageList struct {
+	Languages []string `json:"languages"`
+}
+
+// Content kinds reported by GET /api/v1/content/{id}.
+const (
+	KindFile   = "file"
+	KindPaste  = "paste"
+	KindBundle = "bundle"
+)
+
+// Content is returned by GET /api/v1/content/{id}, which resolves an ID
+// without knowing upfront whether it names a file, a paste or a bundle.
+type Content struct {
+	Kind   string  `json:"kind"`
+	File   *File   `json:"file,omitempty"`
+	Paste  *Paste  `json:"paste,omitempty"`
+	Bundle *Bundle `json:"bundle,omitempty"`
+}
+
+// Error codes carried by Error.Code.
+const (
+	CodeNotFound      = "not_found"
+	CodeExpired       = "expired"
+	CodeLimitExceeded = "limit_exceeded"
+	CodeInvalidInput  = "invalid_input"
+	CodeTooLarge      = "too_large"
+	CodeUnauthorized  = "unauthorized"
+	CodeForbidden     = "forbidden"
+	CodeQuotaExceeded = "quota_exceeded"
+	CodeStorageFull   = "storage_full"
+	CodeConflict      = "conflict"
+	CodeInternal      = "internal"
+)
+
+// Error is the body of every non-2xx v1 response.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// FilePath returns the download path of a file.
+func FilePath(id string) string {
+	return Prefix + "/file/" + id
+}
+
+// FileInfoPath returns the metadata path of a file.
+func FileInfoPath(id string) string {
+	return FilePath(id) + "/info"
+}
+
+// PastePath returns the JSON path of a paste.
+func PastePath(id string) string {
+	return Prefix + "/paste/" + id
+}
+
+// PasteRawPath returns the plain text path of a paste.
+func PasteRawPath(id string) string {
+	return PastePath(id) + "/raw"
+}
+
+// PasteFileRawPath returns the plain text path of a file of a multi-file paste.
+func PasteFileRawPath(id, name string) string {
+	return PasteRawPath(id) + "/" + url.PathEscape(name)
+}
+
+// PasteZipPath returns the path downloading all files of a paste as a zip.
+func PasteZipPath(id string) string {
+	return PastePath(id) + "/zip"
+}
+
+// PasteForkPath returns the path forking a paste.
+func PasteForkPath(id string) string {
+	return PastePath(id) + "/fork"
+}
+
+// PasteRevisionsPath returns the path listing the revisions of a paste.
+func PasteRevisionsPath(id string) string {
+	return PastePath(id) + "/revisions"
+}
+
+// PasteRevisionRawPath returns the plain text path of a paste revision.
+func PasteRevisionRawPath(id string, number int) string {
+	return PasteRevisionsPath(id) + "/" + strconv.Itoa(number) + "/raw"
+}
+
+// PasteDiffPath returns the path of the diff between revisions of a paste,
+// chosen with the from and to query parameters.
+func PasteDiffPath(id string) string {
+	return PastePath(id) + "/diff"
+}
+
+// DiffPath returns the path comparing two pastes, chosen with the a and b
+// query parameters, or a paste and the text posted to it.
+func DiffPath() string {
+	return Prefix + "/diff"
+}
+
+// BundlePath returns the JSON path of a bundle.
+func BundlePath(id string) string {
+	return Prefix + "/bundle/" + id
+}
+
+// BundleZipPath returns the path downloading all files of a bundle as a zip.
+func BundleZipPath(id string) string {
+	return BundlePath(id) + "/zip"
+}
+
+// BundleTarGzPath returns the path downloading all files of a bundle as a
+// gzipped tarball.
+func BundleTarGzPath(id string) string {
+	return BundlePath(id) + "/tar.gz"
+}
+
+// ViewPath returns the HTML viewer path of a file, paste or bundle.
+func ViewPath(id string) string {
+	return Prefix + "/view/" + id
+}
+
+// ConfigPath returns the path of the public server configuration.
+func ConfigPath() string {
+	return Prefix + "/config"
+}
+
+// LanguagesPath returns the path listing the languages of pastes.
+func LanguagesPath() string {
+	return Prefix + "/languages"
+}
+
+// ContentPath returns the kind-agnostic lookup path of a file or paste.
+func ContentPath(id string) string {
+	return Prefix + "/content/" + id
+}
+
+// ContentQRPath returns the path of a QR code linking to the viewer of a
+// file, paste or bundle, format being "png" or "svg".
+func ContentQRPath(id, format string) string {
+	return ContentPath(id) + "/qr." + format
+}
+
+// MePath returns the path describing the authenticated caller.
+func MePath() string {
+	return Prefix + "/me"
+}
+
+// MyUsagePath returns the path reporting the caller's storage usage.
+func MyUsagePath() string {
+	return MePath() + "/usage"
+}
+
+// FileListPath returns the path listing files.
+func FileListPath() string {
+	return Prefix + "/files"
+}
+
+// PasteListPath returns the path listing pastes.
+func PasteListPath() string {
+	return Prefix + "/pastes"
+}
+
+// PasteSearchPath returns the path searching pastes, without its query string.
+func PasteSearchPath() string {
+	return PasteListPath() + "/search"
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"net/http"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+// Authenticator adds credentials to requests, before each attempt. It may
+// refresh them, such as a token obtained from a secrets manager.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthFunc adapts a function to an Authenticator.
+type AuthFunc func(req *http.Request) error
+
+func (f AuthFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// BearerToken authenticates with an API key sent as
+// "Authorization: Bearer <key>".
+func BearerToken(key string) Authenticator {
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+key)
+		return nil
+	})
+}
+
+// APIKey authenticates with an API key sent in the X-API-Key header, for
+// proxies that keep the Authorization header for themselves.
+func APIKey(key string) Authenticator {
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set(apiv1.HeaderAPIKey, key)
+		return nil
+	})
+}
@@ -0,0 +1,340 @@
+// Package client is a Go client for the quip /api/v1 HTTP API.
+//
+// It has a typed method for every endpoint, streams uploads and downloads
+// with optional progress callbacks, retries idempotent requests that fail
+// with a 5xx status or a network error, and returns errors that can be tested with
+// errors.Is against ErrNotFound, ErrExpired and the other sentinels of this
+// package:
+//
+//	c := client.New("https://quip.example", client.WithToken(os.Getenv("QUIP_TOKEN")))
+//	paste, err := c.CreatePaste(ctx, apiv1.CreatePasteRequest{Content: "hello"})
+//	if errors.Is(err, client.ErrQuotaExceeded) {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+// Defaults of the retry policy
+const (
+	DefaultRetries = 3
+	DefaultBackoff = 500 * time.Millisecond
+	maxBackoff     = 30 * time.Second
+)
+
+// Client calls a quip server. It is safe for concurrent use.
+type Client struct {
+	server    string
+	http      *http.Client
+	auth      Authenticator
+	retries   int
+	backoff   time.Duration
+	userAgent string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests through hc rather than http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithAuth authenticates every request with auth.
+func WithAuth(auth Authenticator) Option {
+	return func(c *Client) { c.auth = auth }
+}
+
+// WithToken authenticates every request with an API key sent as a bearer
+// token. An empty token leaves requests anonymous.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		if token != "" {
+			c.auth = BearerToken(token)
+		}
+	}
+}
+
+// WithRetries retries a failed request up to retries times, waiting backoff
+// and then twice as long after each attempt. Zero retries turns retrying off.
+// Requests counting a view or a download are only retried when they could
+// not be sent, so that a lost response does not count twice.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.backoff = retries, backoff }
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// New returns a client for the server at server, such as
+// "https://quip.example" or "https://example.com/quip".
+func New(server string, opts ...Option) *Client {
+	c := &Client{
+		server:    strings.TrimRight(server, "/"),
+		http:      http.DefaultClient,
+		retries:   DefaultRetries,
+		backoff:   DefaultBackoff,
+		userAgent: "quip-go-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Server returns the URL of the server the client calls.
+func (c *Client) Server() string {
+	return c.server
+}
+
+// URL returns the absolute URL of an API path, such as the View or Raw
+// paths of responses.
+func (c *Client) URL(path string) string {
+	return c.server + path
+}
+
+// request describes a call to the API
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	// body returns the body of one attempt, which is retried only when the
+	// body can be produced again. It may return an io.ReadCloser, which is
+	// closed once the attempt is over.
+	body        func() (io.Reader, error)
+	replayable  bool
+	contentType string
+	// manageToken authorizes managing the content instead of, or on top of,
+	// the client's authentication
+	manageToken string
+	// counted requests count a view or a download of the content, so they
+	// are retried like requests that are not idempotent
+	counted bool
+}
+
+// jsonRequest returns a request sending v as JSON
+func jsonRequest(method, path string, v any) (*request, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &request{
+		method:      method,
+		path:        path,
+		body:        func() (io.Reader, error) { return bytes.NewReader(body), nil },
+		replayable:  true,
+		contentType: "application/json",
+	}, nil
+}
+
+// do sends the request, retrying it on network errors and 5xx statuses, and
+// returns the response of the first attempt that succeeds. Non-2xx
+// responses are returned as an *Error. Requests that are not idempotent,
+// such as uploads, and the ones counting a view or a download are only
+// retried when they could not be sent at all, as the server may have acted
+// on them before failing.
+func (c *Client) do(ctx context.Context, r *request) (*http.Response, error) {
+	if r.body == nil {
+		r.replayable = true
+	}
+	idempotent := idempotentMethod(r.method) && !r.counted
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, r)
+		retry := attempt < c.retries && r.replayable && ctx.Err() == nil && (idempotent || notSent(err))
+		switch {
+		case err != nil && !retry:
+			return nil, err
+		case err == nil && resp.StatusCode < 300:
+			return resp, nil
+		case err == nil && !(retry && retryable(resp.StatusCode)):
+			defer resp.Body.Close()
+			return nil, responseError(resp)
+		}
+
+		wait := c.wait(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// wait returns how long to wait before retrying after attempt failed with
+// resp, nil on network errors: what the server asks for in Retry-After, or
+// an exponential backoff, both at most maxBackoff
+func (c *Client) wait(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if after, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && after >= 0 {
+			if after >= int(maxBackoff/time.Second) {
+				return maxBackoff
+			}
+			return time.Duration(after) * time.Second
+		}
+	}
+	wait := c.backoff << attempt
+	if wait > maxBackoff || wait < 0 {
+		wait = maxBackoff
+	}
+	wait += rand.N(wait/2 + 1) // jitter, so that clients do not retry in step
+	return min(wait, maxBackoff)
+}
+
+// retryable tells whether a request that failed with status may succeed if
+// sent again. A full server stays full, and an unimplemented route stays
+// unimplemented.
+func retryable(status int) bool {
+	return status >= 500 && status != http.StatusNotImplemented && status != http.StatusInsufficientStorage
+}
+
+// idempotentMethod tells whether sending a request with method twice has
+// the effect of sending it once
+func idempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// notSent tells whether a request failed before reaching the server, so that
+// sending it again cannot repeat its effect
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func (c *Client) attempt(ctx context.Context, r *request) (*http.Response, error) {
+	var body io.Reader
+	if r.body != nil {
+		var err error
+		if body, err = r.body(); err != nil {
+			return nil, err
+		}
+		if closer, ok := body.(io.Closer); ok {
+			// Unblocks a body still being produced when the request ends
+			// early, such as a multipart stream
+			defer closer.Close()
+		}
+	}
+
+	u := c.server + r.path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return nil, fmt.Errorf("authenticating request: %w", err)
+		}
+	}
+	if r.manageToken != "" {
+		req.Header.Set(apiv1.HeaderManageToken, r.manageToken)
+	}
+	return c.http.Do(req)
+}
+
+// getJSON fetches path and decodes the JSON response into v
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	return c.doJSON(ctx, &request{method: http.MethodGet, path: path, query: query}, v)
+}
+
+// doJSON sends the request and decodes the JSON response into v, if not nil
+func (c *Client) doJSON(ctx context.Context, r *request, v any) error {
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response of %s %s: %w", r.method, r.path, err)
+	}
+	return nil
+}
+
+// Content resolves an ID without knowing whether it names a file, a paste or
+// a bundle. Resolving a paste counts as one of its views.
+func (c *Client) Content(ctx context.Context, id string) (*apiv1.Content, error) {
+	var content apiv1.Content
+	if err := c.doJSON(ctx, &request{method: http.MethodGet, path: apiv1.ContentPath(id), counted: true}, &content); err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// Config returns the public configuration of the server, such as the TTLs
+// and sizes it accepts.
+func (c *Client) Config(ctx context.Context) (*apiv1.ServerConfig, error) {
+	var config apiv1.ServerConfig
+	if err := c.getJSON(ctx, apiv1.ConfigPath(), nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Languages returns the paste languages the server detects, sorted.
+func (c *Client) Languages(ctx context.Context) ([]string, error) {
+	var list apiv1.LanguageList
+	if err := c.getJSON(ctx, apiv1.LanguagesPath(), nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Languages, nil
+}
+
+// Me returns the user the client authenticates as.
+func (c *Client) Me(ctx context.Context) (*apiv1.User, error) {
+	var user apiv1.User
+	if err := c.getJSON(ctx, apiv1.MePath(), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Usage returns the storage used by the authenticated user, against their
+// quota.
+func (c *Client) Usage(ctx context.Context) (*apiv1.Usage, error) {
+	var usage apiv1.Usage
+	if err := c.getJSON(ctx, apiv1.MyUsagePath(), nil, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiv1.Error{Code: code, Message: "nope"})
+}
+
+func TestRetries(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(apiv1.User{Name: "alice"})
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithToken("secret"), WithRetries(3, time.Millisecond))
+	user, err := c.Me(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Name)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	_, err = New(srv.URL, WithToken("secret"), WithRetries(1, time.Millisecond)).Me(context.Background())
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Equal(t, 2, calls, "one retry")
+
+	// The server may have counted a view before failing
+	calls = 0
+	_, err = New(srv.URL, WithToken("secret"), WithRetries(3, time.Millisecond)).RawPaste(context.Background(), "abc")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 1, calls, "views are not counted twice")
+
+	// Requests that never left may be sent again, whatever their method
+	srv.Close()
+	_, err = New(srv.URL, WithRetries(1, time.Millisecond)).CreatePaste(context.Background(), apiv1.CreatePasteRequest{Content: "hello"})
+	assert.True(t, notSent(err), "%v", err)
+	assert.False(t, notSent(apiErr))
+}
+
+func TestRetryWait(t *testing.T) {
+	c := New("http://localhost", WithRetries(3, time.Second))
+	retryAfter := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": {value}}}
+	}
+	assert.Equal(t, 5*time.Second, c.wait(0, retryAfter("5")))
+	assert.Equal(t, maxBackoff, c.wait(0, retryAfter("86400")), "the server cannot make the client hang")
+	assert.Equal(t, maxBackoff, c.wait(0, retryAfter("99999999999999999")))
+
+	wait := c.wait(2, nil)
+	assert.GreaterOrEqual(t, wait, 4*time.Second)
+	assert.LessOrEqual(t, wait, 6*time.Second)
+	assert.Equal(t, maxBackoff, c.wait(10, retryAfter("soon")))
+}
+
+func TestErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case apiv1.ContentPath("expired"):
+			writeError(w, http.StatusGone, apiv1.CodeExpired)
+		case apiv1.ContentPath("used"):
+			writeError(w, http.StatusGone, apiv1.CodeLimitExceeded)
+		case apiv1.ContentPath("proxy"):
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		default:
+			writeError(w, http.StatusNotFound, apiv1.CodeNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	for id, want := range map[string]error{
+		"missing": ErrNotFound,
+		"expired": ErrExpired,
+		"used":    ErrLimitExceeded,
+		"proxy":   ErrTooLarge,
+	} {
+		_, err := c.Content(context.Background(), id)
+		assert.ErrorIs(t, err, want, id)
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		content, _ := io.ReadAll(file)
+		assert.Equal(t, "hello world", string(content), "attempt %d", calls)
+		assert.Equal(t, "notes.txt", header.Filename)
+		assert.Equal(t, "1h", r.FormValue("ttl"))
+		if calls == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(apiv1.FileUploaded{ID: "abc", Filename: header.Filename})
+	}))
+	defer srv.Close()
+	c := New(srv.URL, WithRetries(2, time.Millisecond))
+
+	var progress []int64
+	body := strings.NewReader("hello world")
+	uploaded, err := c.UploadFile(context.Background(), Upload{Name: "notes.txt", Body: body, Size: body.Size()}, FileOptions{
+		TTL:      "1h",
+		Progress: func(done, total int64) { progress = append(progress, done, total) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "abc", uploaded.ID)
+	assert.Equal(t, []int64{11, 11}, progress[len(progress)-2:])
+
+	_, err = c.UploadFile(context.Background(), Upload{Name: "notes.txt", Body: strings.NewReader("hello world"), Size: 11}, FileOptions{TTL: "1h"})
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, 2, calls, "the server may have stored what failed, so it is not sent again")
+}
+
+func TestDownloadFile(t *testing.T) {
+	data := bytes.Repeat([]byte("abcdefghij"), 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="report.bin"`)
+		http.ServeContent(w, r, "report.bin", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+
+	var done, total int64
+	d, err := c.DownloadFile(context.Background(), "abc", DownloadOptions{
+		Offset:   40,
+		Progress: func(n, t int64) { done, total = n, t },
+	})
+	require.NoError(t, err)
+	defer d.Close()
+	rest, err := io.ReadAll(d)
+	require.NoError(t, err)
+	assert.Equal(t, data[40:], rest)
+	assert.Equal(t, "report.bin", d.Filename)
+	assert.Equal(t, int64(40), d.Offset)
+	assert.Equal(t, int64(100), d.Size)
+	assert.Equal(t, []int64{100, 100}, []int64{done, total})
+
+	// A range past the end starts over
+	d, err = c.DownloadFile(context.Background(), "abc", DownloadOptions{Offset: 500})
+	require.NoError(t, err)
+	defer d.Close()
+	assert.Equal(t, int64(0), d.Offset)
+	all, _ := io.ReadAll(d)
+	assert.Equal(t, data, all)
+}
+
+func TestManageToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		if r.Header.Get(apiv1.HeaderManageToken) != "token" {
+			writeError(w, http.StatusForbidden, apiv1.CodeForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+
+	assert.NoError(t, c.DeletePaste(context.Background(), "abc", "token"))
+	err := c.DeleteFile(context.Background(), "abc", "")
+	assert.True(t, errors.Is(err, ErrForbidden))
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+// Errors an *Error can be matched against with errors.Is. They mirror the
+// error codes of the API.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrExpired       = errors.New("expired")
+	ErrLimitExceeded = errors.New("limit exceeded")
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrTooLarge      = errors.New("too large")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrStorageFull   = errors.New("storage full")
+	ErrConflict      = errors.New("conflict")
+)
+
+var codeErrors = map[string]error{
+	apiv1.CodeNotFound:      ErrNotFound,
+	apiv1.CodeExpired:       ErrExpired,
+	apiv1.CodeLimitExceeded: ErrLimitExceeded,
+	apiv1.CodeInvalidInput:  ErrInvalidInput,
+	apiv1.CodeTooLarge:      ErrTooLarge,
+	apiv1.CodeUnauthorized:  ErrUnauthorized,
+	apiv1.CodeForbidden:     ErrForbidden,
+	apiv1.CodeQuotaExceeded: ErrQuotaExceeded,
+	apiv1.CodeStorageFull:   ErrStorageFull,
+	apiv1.CodeConflict:      ErrConflict,
+}
+
+// statusErrors classify responses without an error body, such as the ones
+// of a proxy in front of the server
+var statusErrors = map[int]error{
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusGone:                  ErrExpired,
+	http.StatusBadRequest:            ErrInvalidInput,
+	http.StatusRequestEntityTooLarge: ErrTooLarge,
+	http.StatusUnauthorized:          ErrUnauthorized,
+	http.StatusForbidden:             ErrForbidden,
+	http.StatusInsufficientStorage:   ErrStorageFull,
+	http.StatusConflict:              ErrConflict,
+}
+
+// Error is a non-2xx response of the server.
+type Error struct {
+	StatusCode int
+	// Code and Message are the API error, empty if the response did not
+	// carry one
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server answered %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Code)
+}
+
+// Unwrap returns the sentinel error of the error code, or of the status
+// when the response had no code.
+func (e *Error) Unwrap() error {
+	if err, ok := codeErrors[e.Code]; ok {
+		return err
+	}
+	return statusErrors[e.StatusCode]
+}
+
+// responseError reads the error of a non-2xx response
+func responseError(resp *http.Response) error {
+	e := &Error{StatusCode: resp.StatusCode}
+	var body apiv1.Error
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil {
+		e.Code, e.Message = body.Code, body.Message
+	}
+	return e
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+// FileOptions tune a file upload.
+type FileOptions struct {
+	// TTL such as "1h", "7d" or "never", the server's default if empty
+	TTL      string
+	Progress ProgressFunc
+}
+
+// UploadFile uploads a file.
+func (c *Client) UploadFile(ctx context.Context, file Upload, opts FileOptions) (*apiv1.FileUploaded, error) {
+	r, err := multipartRequest(apiv1.Prefix+"/file", []Upload{file}, map[string]string{"ttl": opts.TTL}, opts.Progress)
+	if err != nil {
+		return nil, err
+	}
+	var uploaded apiv1.FileUploaded
+	if err := c.doJSON(ctx, r, &uploaded); err != nil {
+		return nil, err
+	}
+	return &uploaded, nil
+}
+
+// FileInfo returns the metadata of a file, without counting a download.
+func (c *Client) FileInfo(ctx context.Context, id string) (*apiv1.File, error) {
+	var file apiv1.File
+	if err := c.getJSON(ctx, apiv1.FileInfoPath(id), nil, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// DownloadFile streams a file. Every call counts as a download, including
+// ranged ones, except those resuming from opts.Offset with the
+// opts.ResumeToken of a download that was counted.
+func (c *Client) DownloadFile(ctx context.Context, id string, opts DownloadOptions) (*Download, error) {
+	return c.download(ctx, apiv1.FilePath(id), opts)
+}
+
+// DeleteFile deletes a file, authorized by the client's API key or by
+// manageToken if not empty.
+func (c *Client) DeleteFile(ctx context.Context, id, manageToken string) error {
+	return c.doJSON(ctx, &request{method: http.MethodDelete, path: apiv1.FilePath(id), manageToken: manageToken}, nil)
+}
+
+// ListOptions filter and paginate ListFiles and ListPastes. Zero values are
+// left to the server's defaults.
+type ListOptions struct {
+	// Sort is "created", "expires" or "size"
+	Sort   string
+	Limit  int
+	Cursor string
+	// Owner lists another user's content, for admins
+	Owner          string
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	ExpiringWithin string // such as "1d"
+	// Kind and ContentType only apply to files, Language to pastes
+	Kind        string
+	ContentType string
+	Language    string
+}
+
+func (o ListOptions) query() url.Values {
+	q := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("sort", o.Sort)
+	if o.Limit > 0 {
+		set("limit", strconv.Itoa(o.Limit))
+	}
+	set("cursor", o.Cursor)
+	set("owner", o.Owner)
+	if !o.CreatedAfter.IsZero() {
+		set("created_after", o.CreatedAfter.Format(time.RFC3339))
+	}
+	if !o.CreatedBefore.IsZero() {
+		set("created_before", o.CreatedBefore.Format(time.RFC3339))
+	}
+	set("expiring_within", o.ExpiringWithin)
+	set("kind", o.Kind)
+	set("content_type", o.ContentType)
+	set("language", o.Language)
+	return q
+}
+
+// ListFiles returns a page of the caller's live files. Pass the NextCursor
+// of a page as opts.Cursor to get the next one.
+func (c *Client) ListFiles(ctx context.Context, opts ListOptions) (*apiv1.FileList, error) {
+	var list apiv1.FileList
+	if err := c.getJSON(ctx, apiv1.FileListPath(), opts.query(), &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// BundleOptions tune a bundle upload.
+type BundleOptions struct {
+	TTL   string
+	Title string
+	// MaxDownloads stops serving the bundle after that many downloads, zero
+	// for unlimited
+	MaxDownloads int
+	Progress     ProgressFunc
+}
+
+// UploadBundle uploads files as one bundle behind a single link.
+func (c *Client) UploadBundle(ctx context.Context, files []Upload, opts BundleOptions) (*apiv1.Bundle, error) {
+	fields := map[string]string{"ttl": opts.TTL, "title": opts.Title}
+	if opts.MaxDownloads > 0 {
+		fields["max_downloads"] = strconv.Itoa(opts.MaxDownloads)
+	}
+	r, err := multipartRequest(apiv1.Prefix+"/bundle", files, fields, opts.Progress)
+	if err != nil {
+		return nil, err
+	}
+	var bundle apiv1.Bundle
+	if err := c.doJSON(ctx, r, &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// Bundle returns a bundle and its files, without counting a download.
+func (c *Client) Bundle(ctx context.Context, id string) (*apiv1.Bundle, error) {
+	var bundle apiv1.Bundle
+	if err := c.getJSON(ctx, apiv1.BundlePath(id), nil, &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// Archive formats of bundles.
+const (
+	ArchiveZip   = "zip"
+	ArchiveTarGz = "tar.gz"
+)
+
+// DownloadBundle streams a bundle as an ArchiveZip or ArchiveTarGz archive.
+// Archives are built as they are sent, so they cannot be resumed.
+func (c *Client) DownloadBundle(ctx context.Context, id, format string, opts DownloadOptions) (*Download, error) {
+	path := apiv1.BundleZipPath(id)
+	if format == ArchiveTarGz {
+		path = apiv1.BundleTarGzPath(id)
+	}
+	opts.Offset = 0
+	return c.download(ctx, path, opts)
+}
+
+// DeleteBundle deletes a bundle and its files, authorized like DeleteFile.
+func (c *Client) DeleteBundle(ctx context.Context, id, manageToken string) error {
+	return c.doJSON(ctx, &request{method: http.MethodDelete, path: apiv1.BundlePath(id), manageToken: manageToken}, nil)
+}
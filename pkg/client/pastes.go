@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+// CreatePaste creates a paste, or a multi-file paste when req has Files.
+func (c *Client) CreatePaste(ctx context.Context, req apiv1.CreatePasteRequest) (*apiv1.PasteCreated, error) {
+	r, err := jsonRequest(http.MethodPost, apiv1.Prefix+"/paste", req)
+	if err != nil {
+		return nil, err
+	}
+	var created apiv1.PasteCreated
+	if err := c.doJSON(ctx, r, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Paste returns a paste with its content, which counts as a view.
+func (c *Client) Paste(ctx context.Context, id string) (*apiv1.Paste, error) {
+	var paste apiv1.Paste
+	if err := c.doJSON(ctx, &request{method: http.MethodGet, path: apiv1.PastePath(id), counted: true}, &paste); err != nil {
+		return nil, err
+	}
+	return &paste, nil
+}
+
+// RawPaste streams the content of a paste, which counts as a view.
+func (c *Client) RawPaste(ctx context.Context, id string) (*Download, error) {
+	return c.download(ctx, apiv1.PasteRawPath(id), DownloadOptions{})
+}
+
+// RawPasteFile streams one file of a multi-file paste.
+func (c *Client) RawPasteFile(ctx context.Context, id, name string) (*Download, error) {
+	return c.download(ctx, apiv1.PasteFileRawPath(id, name), DownloadOptions{})
+}
+
+// DownloadPasteZip streams the files of a paste as a zip archive.
+func (c *Client) DownloadPasteZip(ctx context.Context, id string, opts DownloadOptions) (*Download, error) {
+	opts.Offset = 0
+	return c.download(ctx, apiv1.PasteZipPath(id), opts)
+}
+
+// EditPaste saves a new revision of a paste, authorized by the client's API
+// key or by manageToken if not empty. It fails with ErrConflict when
+// edit.Revision is set and the paste was edited since that revision.
+func (c *Client) EditPaste(ctx context.Context, id string, edit apiv1.EditPasteRequest, manageToken string) (*apiv1.Paste, error) {
+	r, err := jsonRequest(http.MethodPatch, apiv1.PastePath(id), edit)
+	if err != nil {
+		return nil, err
+	}
+	r.manageToken = manageToken
+	var paste apiv1.Paste
+	if err := c.doJSON(ctx, r, &paste); err != nil {
+		return nil, err
+	}
+	return &paste, nil
+}
+
+// ForkPaste copies the latest revision of a paste into a new paste owned by
+// the caller.
+func (c *Client) ForkPaste(ctx context.Context, id string, req apiv1.ForkPasteRequest) (*apiv1.PasteCreated, error) {
+	r, err := jsonRequest(http.MethodPost, apiv1.PasteForkPath(id), req)
+	if err != nil {
+		return nil, err
+	}
+	var created apiv1.PasteCreated
+	if err := c.doJSON(ctx, r, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// DeletePaste deletes a paste, authorized like EditPaste.
+func (c *Client) DeletePaste(ctx context.Context, id, manageToken string) error {
+	return c.doJSON(ctx, &request{method: http.MethodDelete, path: apiv1.PastePath(id), manageToken: manageToken}, nil)
+}
+
+// Revisions lists the revisions of a paste, oldest first, without counting
+// a view.
+func (c *Client) Revisions(ctx context.Context, id string) (*apiv1.RevisionList, error) {
+	var list apiv1.RevisionList
+	if err := c.getJSON(ctx, apiv1.PasteRevisionsPath(id), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// RawRevision streams the content of a revision of a paste, which counts as
+// a view unless the caller manages the paste, through its API key or
+// manageToken.
+func (c *Client) RawRevision(ctx context.Context, id string, number int, manageToken string) (*Download, error) {
+	r := &request{method: http.MethodGet, path: apiv1.PasteRevisionRawPath(id, number), manageToken: manageToken, counted: manageToken == ""}
+	return c.downloadRequest(ctx, r, DownloadOptions{})
+}
+
+// ListPastes returns a page of the caller's live pastes, paginated like
+// ListFiles.
+func (c *Client) ListPastes(ctx context.Context, opts ListOptions) (*apiv1.PasteList, error) {
+	var list apiv1.PasteList
+	if err := c.getJSON(ctx, apiv1.PasteListPath(), opts.query(), &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// SearchPastes searches the titles and content of the caller's pastes, best
+// match first. A limit of zero leaves it to the server.
+func (c *Client) SearchPastes(ctx context.Context, query string, limit int) (*apiv1.SearchResults, error) {
+	q := url.Values{"q": {query}}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var results apiv1.SearchResults
+	if err := c.getJSON(ctx, apiv1.PasteSearchPath(), q, &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// DiffPastes compares the latest revisions of two pastes, which counts as a
+// view of each.
+func (c *Client) DiffPastes(ctx context.Context, a, b string) (*apiv1.Diff, error) {
+	var diff apiv1.Diff
+	q := url.Values{"a": {a}, "b": {b}, "format": {apiv1.DiffFormatJSON}}
+	if err := c.doJSON(ctx, &request{method: http.MethodGet, path: apiv1.DiffPath(), query: q, counted: true}, &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// DiffText compares a paste with text, shown under name in the diff. Like
+// uploads, the request is only retried when it could not be sent, and if
+// text is an io.Seeker.
+func (c *Client) DiffText(ctx context.Context, paste, name string, text io.Reader) (*apiv1.Diff, error) {
+	// The text is the caller's to close, which net/http would do to an
+	// io.ReadCloser body
+	r := &request{
+		method:      http.MethodPost,
+		path:        apiv1.DiffPath(),
+		query:       url.Values{"a": {paste}, "name": {name}, "format": {apiv1.DiffFormatJSON}},
+		contentType: "text/plain; charset=utf-8",
+		body:        func() (io.Reader, error) { return io.NopCloser(text), nil },
+	}
+	if seeker, ok := text.(io.Seeker); ok {
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			r.replayable = true
+			r.body = func() (io.Reader, error) {
+				_, err := seeker.Seek(start, io.SeekStart)
+				return io.NopCloser(text), err
+			}
+		}
+	}
+	var diff apiv1.Diff
+	if err := c.doJSON(ctx, r, &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// DiffRevisions compares two revisions of a paste. Zero values default to
+// the latest revision for to, and to the one before to for from. Like
+// RawRevision, it counts as a view unless the caller manages the paste.
+func (c *Client) DiffRevisions(ctx context.Context, id string, from, to int, manageToken string) (*apiv1.Diff, error) {
+	q := url.Values{"format": {apiv1.DiffFormatJSON}}
+	if from > 0 {
+		q.Set("from", strconv.Itoa(from))
+	}
+	if to > 0 {
+		q.Set("to", strconv.Itoa(to))
+	}
+	var diff apiv1.Diff
+	r := &request{method: http.MethodGet, path: apiv1.PasteDiffPath(id), query: q, manageToken: manageToken, counted: manageToken == ""}
+	if err := c.doJSON(ctx, r, &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
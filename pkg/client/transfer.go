@@ -0,0 +1,260 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/Gandalf-Le-Dev/quip/pkg/api/v1"
+)
+
+// ProgressFunc is called as a transfer progresses with the bytes done so
+// far and the total, which is -1 when it is not known.
+type ProgressFunc func(done, total int64)
+
+// Upload is a file to upload, read from Body as the request is sent.
+//
+// An upload is only retried if Body is an io.Seeker, which is rewound to
+// where it was before each attempt. Other readers, such as a pipe, are read
+// once.
+type Upload struct {
+	Name string
+	Body io.Reader
+	// Size is the size of Body, or -1 if it is not known upfront. It is only
+	// used to report progress.
+	Size int64
+	// ContentType defaults to application/octet-stream
+	ContentType string
+}
+
+// multipartRequest returns a request uploading files along with fields as a
+// multipart form, streamed as it is sent
+func multipartRequest(path string, files []Upload, fields map[string]string, progress ProgressFunc) (*request, error) {
+	var boundary [16]byte
+	if _, err := rand.Read(boundary[:]); err != nil {
+		return nil, err
+	}
+	// The boundary is fixed, so that every attempt has the content type of
+	// the request
+	form := &multipartForm{boundary: hex.EncodeToString(boundary[:]), files: files, fields: fields, progress: progress}
+	replayable := true
+	for _, f := range files {
+		seeker, ok := f.Body.(io.Seeker)
+		if !ok {
+			replayable = false
+			break
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			replayable = false
+			break
+		}
+		form.offsets = append(form.offsets, offset)
+	}
+	if !replayable {
+		form.offsets = nil
+	}
+	return &request{
+		method:      http.MethodPost,
+		path:        path,
+		body:        form.open,
+		replayable:  replayable,
+		contentType: "multipart/form-data; boundary=" + form.boundary,
+	}, nil
+}
+
+// multipartForm streams a multipart form through a pipe
+type multipartForm struct {
+	boundary string
+	files    []Upload
+	fields   map[string]string
+	progress ProgressFunc
+	offsets  []int64 // where each body starts, when they can be rewound
+}
+
+func (f *multipartForm) open() (io.Reader, error) {
+	for i, offset := range f.offsets {
+		if _, err := f.files[i].Body.(io.Seeker).Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	pr, pw := io.Pipe()
+	body := &pipeBody{PipeReader: pr, done: make(chan struct{})}
+	go func() {
+		defer close(body.done)
+		pw.CloseWithError(f.write(pw))
+	}()
+	return body, nil
+}
+
+func (f *multipartForm) write(dst io.Writer) error {
+	w := multipart.NewWriter(dst)
+	if err := w.SetBoundary(f.boundary); err != nil {
+		return err
+	}
+
+	var total int64
+	for _, file := range f.files {
+		if file.Size < 0 || total < 0 {
+			total = -1
+			continue
+		}
+		total += file.Size
+	}
+	counter := &progressCounter{report: f.progress, total: total}
+
+	for _, file := range f.files {
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": file.Name}))
+		h.Set("Content-Type", contentType)
+		part, err := w.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.MultiWriter(part, counter), file.Body); err != nil {
+			return err
+		}
+	}
+	for name, value := range f.fields {
+		if value == "" {
+			continue
+		}
+		if err := w.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// pipeBody is the reading end of a streamed body. Closing it stops the
+// writer and waits for it to be done with the files it reads.
+type pipeBody struct {
+	*io.PipeReader
+	done chan struct{}
+}
+
+func (b *pipeBody) Close() error {
+	b.PipeReader.Close()
+	<-b.done
+	return nil
+}
+
+// progressCounter reports the bytes written through it
+type progressCounter struct {
+	report ProgressFunc
+	done   int64
+	total  int64
+}
+
+func (p *progressCounter) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if p.report != nil {
+		p.report(p.done, p.total)
+	}
+	return len(b), nil
+}
+
+// DownloadOptions tune a download.
+type DownloadOptions struct {
+	// Offset resumes a download from this byte, with a range request. The
+	// server may ignore it, see Download.Offset.
+	Offset int64
+	// ResumeToken is the Download.ResumeToken of the file download being
+	// resumed from Offset, so that the server does not count it again
+	ResumeToken string
+	// Progress is called as the body is read
+	Progress ProgressFunc
+}
+
+// Download is the body of a downloaded file, paste or archive, streamed as
+// it is read. It must be closed.
+type Download struct {
+	io.ReadCloser
+	// Filename is the name the server suggests saving it under, if any
+	Filename    string
+	ContentType string
+	// Size is the size of the whole content, -1 if unknown
+	Size int64
+	// Offset is where the body starts in the content: the requested offset
+	// when the server honoured the range, 0 when it sent everything
+	Offset int64
+	// ResumeToken resumes a file download without counting it again, see
+	// DownloadOptions.ResumeToken
+	ResumeToken string
+}
+
+// download fetches path, content whose downloads are counted, from
+// opts.Offset on
+func (c *Client) download(ctx context.Context, path string, opts DownloadOptions) (*Download, error) {
+	return c.downloadRequest(ctx, &request{method: http.MethodGet, path: path, counted: true}, opts)
+}
+
+// downloadRequest sends r, a GET, asking for the body from opts.Offset on
+func (c *Client) downloadRequest(ctx context.Context, r *request, opts DownloadOptions) (*Download, error) {
+	ranged := *r
+	if opts.Offset > 0 {
+		ranged.header = http.Header{"Range": {"bytes=" + strconv.FormatInt(opts.Offset, 10) + "-"}}
+		if opts.ResumeToken != "" {
+			ranged.header.Set(apiv1.HeaderResumeToken, opts.ResumeToken)
+			ranged.counted = false
+		}
+	}
+	resp, err := c.do(ctx, &ranged)
+	var e *Error
+	if opts.Offset > 0 && errors.As(err, &e) && e.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// What was already downloaded does not match the content any more
+		return c.downloadRequest(ctx, r, DownloadOptions{Progress: opts.Progress})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Download{ContentType: resp.Header.Get("Content-Type"), Size: resp.ContentLength, ResumeToken: resp.Header.Get(apiv1.HeaderResumeToken)}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		d.Filename = params["filename"]
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		d.Offset = opts.Offset
+		// Content-Range: bytes 100-999/1000
+		if _, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/"); ok {
+			if n, err := strconv.ParseInt(total, 10, 64); err == nil {
+				d.Size = n
+			} else {
+				d.Size = -1
+			}
+		}
+	}
+
+	d.ReadCloser = resp.Body
+	if opts.Progress != nil {
+		d.ReadCloser = &progressReader{ReadCloser: resp.Body, counter: progressCounter{report: opts.Progress, done: d.Offset, total: d.Size}}
+	}
+	return d, nil
+}
+
+// progressReader reports the bytes read through it
+type progressReader struct {
+	io.ReadCloser
+	counter progressCounter
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	if n > 0 {
+		r.counter.Write(b[:n])
+	}
+	return n, err
+}
@@ -25,5 +25,21 @@ export interface Paste {
   view: string;     // API generated view URL
 }
 
-export type TTL = '1h' | '24h' | '72h' | '168h'; // Added for recompilation
+// A lifetime such as '1h', '7d' or 'never'. The server lists the accepted
+// presets, bounds and default at GET /api/v1/config.
+export type TTL = string;
+
+export interface ServerConfig {
+  ttl: {
+    default: TTL;
+    min: TTL;
+    max: TTL;
+    presets: TTL[];
+    allow_permanent: boolean;
+  };
+  limits: {
+    max_file_size: number;
+    max_paste_size: number;
+  };
+}
 export const DUMMY_EXPORT = true;